@@ -0,0 +1,471 @@
+// cmd/libctl/commands.go
+package main
+
+import (
+    "bufio"
+    "context"
+    "errors"
+    "flag"
+    "fmt"
+    "net/url"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+    "auth-user-api/migrations"
+    "auth-user-api/migrator"
+    "auth-user-api/models"
+    "auth-user-api/query"
+    "auth-user-api/repository"
+    "auth-user-api/services"
+
+    "github.com/google/uuid"
+)
+
+// readPassword mengambil password dari flag, env LIBCTL_PASSWORD, atau satu baris stdin
+// agar password tidak harus muncul di riwayat shell
+func readPassword(flagValue string) (string, error) {
+    if flagValue != "" {
+        return flagValue, nil
+    }
+    if env := os.Getenv("LIBCTL_PASSWORD"); env != "" {
+        return env, nil
+    }
+
+    fmt.Fprint(os.Stderr, "Password: ")
+    line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+    if err != nil && line == "" {
+        return "", errors.New("password is required (-password, LIBCTL_PASSWORD or stdin)")
+    }
+    return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (a *app) findUser(ref string) (*models.User, error) {
+    if _, err := uuid.Parse(ref); err == nil {
+        return a.users.GetUserByID(ref)
+    }
+    return a.users.GetUserByUsername(ref)
+}
+
+func userRow(user *models.User) (interface{}, []string, [][]interface{}) {
+    data := map[string]interface{}{"id": user.ID, "username": user.Username, "email": user.Email, "role": user.Role}
+    return data, []string{"ID", "USERNAME", "EMAIL", "ROLE"}, [][]interface{}{{user.ID, user.Username, user.Email, user.Role}}
+}
+
+func (a *app) createAdmin(args []string) error {
+    fs, p := newFlagSet("create-admin")
+    username := fs.String("username", "", "admin username")
+    email := fs.String("email", "", "admin email")
+    password := fs.String("password", "", "admin password (or LIBCTL_PASSWORD / stdin)")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+    if err := p.validate(); err != nil {
+        return err
+    }
+    if *username == "" || *email == "" {
+        return errors.New("-username and -email are required")
+    }
+
+    pass, err := readPassword(*password)
+    if err != nil {
+        return err
+    }
+    if err := a.users.Register(*username, *email, pass, pass, 1); err != nil {
+        return fmt.Errorf("create admin: %w", err)
+    }
+
+    user, err := a.users.GetUserByUsername(*username)
+    if err != nil {
+        return err
+    }
+    return p.print(userRow(user))
+}
+
+func (a *app) createInvite(args []string) error {
+    fs, p := newFlagSet("create-invite")
+    role := fs.Int("role", 2, "role ID the invitee will get (1 admin, 2 member)")
+    email := fs.String("email", "", "restrict the invite to this email")
+    ttl := fs.Duration("ttl", 0, "invite lifetime (default from config)")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+    if err := p.validate(); err != nil {
+        return err
+    }
+
+    invite, code, err := a.invites.CreateInvite(*role, *email, *ttl, nil)
+    if err != nil {
+        return fmt.Errorf("create invite: %w", err)
+    }
+
+    data := map[string]interface{}{"invite": invite, "code": code}
+    return p.print(data,
+        []string{"ID", "ROLE", "EMAIL", "EXPIRES", "CODE"},
+        [][]interface{}{{invite.ID, invite.Role, invite.Email, invite.ExpiresAt, code}})
+}
+
+func (a *app) resetPassword(args []string) error {
+    fs, p := newFlagSet("reset-password")
+    ref := fs.String("user", "", "username or user ID")
+    password := fs.String("password", "", "new password (or LIBCTL_PASSWORD / stdin)")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+    if err := p.validate(); err != nil {
+        return err
+    }
+    if *ref == "" {
+        return errors.New("-user is required")
+    }
+
+    user, err := a.findUser(*ref)
+    if err != nil {
+        return fmt.Errorf("user %s: %w", *ref, err)
+    }
+    pass, err := readPassword(*password)
+    if err != nil {
+        return err
+    }
+    if err := a.users.Update(user.ID, "", "", pass, pass); err != nil {
+        return fmt.Errorf("reset password: %w", err)
+    }
+    // Sesi lama tidak boleh tetap aktif setelah password diganti
+    if err := a.auth.LogoutAll(user.ID); err != nil {
+        return fmt.Errorf("revoke sessions: %w", err)
+    }
+
+    return p.print(userRow(user))
+}
+
+func (a *app) forceReturn(args []string) error {
+    fs, p := newFlagSet("force-return")
+    loanID := fs.Uint("loan", 0, "loan record ID")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+    if err := p.validate(); err != nil {
+        return err
+    }
+    if *loanID == 0 {
+        return errors.New("-loan is required")
+    }
+
+    loan, lateFee, err := a.loans.ForceReturn(*loanID)
+    if err != nil {
+        return fmt.Errorf("force return: %w", err)
+    }
+
+    data := map[string]interface{}{"loan_id": loan.ID, "book_id": loan.BookID, "copy_id": loan.CopyID, "user_id": loan.UserID, "return_date": loan.ReturnDate, "late_fee": lateFee}
+    return p.print(data,
+        []string{"LOAN", "BOOK", "COPY", "USER", "RETURNED", "LATE FEE"},
+        [][]interface{}{{loan.ID, loan.BookID, loan.CopyID, loan.UserID, loan.ReturnDate, lateFee}})
+}
+
+func (a *app) recalcStock(args []string) error {
+    fs, p := newFlagSet("recalc-stock")
+    bookID := fs.Int("book", 0, "book ID (default: all books)")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+    if err := p.validate(); err != nil {
+        return err
+    }
+
+    results, err := a.bookCopies.RecalculateStock(*bookID)
+    if err != nil {
+        return fmt.Errorf("recalculate stock: %w", err)
+    }
+    // Eksemplar yang dilepas mungkin ditunggu antrean reservasi
+    if err := a.holds.ProcessHolds(); err != nil {
+        return fmt.Errorf("process holds: %w", err)
+    }
+
+    rows := make([][]interface{}, len(results))
+    for i, r := range results {
+        rows[i] = []interface{}{r.BookID, r.MarkedOnLoan, r.Released, r.Stock, r.MaxStock}
+    }
+    return p.print(results, []string{"BOOK", "MARKED ON_LOAN", "RELEASED", "STOCK", "MAX STOCK"}, rows)
+}
+
+func (a *app) overdue(args []string) error {
+    fs, p := newFlagSet("overdue")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+    if err := p.validate(); err != nil {
+        return err
+    }
+
+    loans, err := a.loans.GetOverdueLoans()
+    if err != nil {
+        return err
+    }
+
+    rows := make([][]interface{}, len(loans))
+    for i, loan := range loans {
+        rows[i] = []interface{}{loan["id"], loan["book_id"], loan["title"], loan["borrower_name"], loan["email"], loan["due_date"], loan["days_overdue"]}
+    }
+    return p.print(loans, []string{"LOAN", "BOOK", "TITLE", "BORROWER", "EMAIL", "DUE", "DAYS OVERDUE"}, rows)
+}
+
+// runOverdueJob menjalankan satu putaran job overdue, mis. dari cron jika scheduler server dimatikan
+func (a *app) runOverdueJob(args []string) error {
+    fs, p := newFlagSet("overdue-job")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+    if err := p.validate(); err != nil {
+        return err
+    }
+
+    result, err := a.overdueJob.RunOnce(time.Now())
+    if err != nil {
+        return fmt.Errorf("overdue job: %w", err)
+    }
+    if result.Skipped && p.format == "table" {
+        fmt.Fprintln(os.Stderr, "another instance is running the overdue job; nothing was processed")
+    }
+    return p.print(result,
+        []string{"SKIPPED", "MARKED OVERDUE", "FINES ACCRUED", "REMINDERS", "ESCALATIONS", "FAILED"},
+        [][]interface{}{{result.Skipped, result.MarkedOverdue, result.FinesAccrued, result.RemindersQueued, result.EscalationsQueued, result.Failed}})
+}
+
+// deliverNotifications mengirim notifikasi yang tertunda di outbox sekali jalan, mis. dari cron
+// jika worker server dimatikan
+func (a *app) deliverNotifications(args []string) error {
+    fs, p := newFlagSet("notify-deliver")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+    if err := p.validate(); err != nil {
+        return err
+    }
+
+    result, err := a.notifications.DeliverPending(context.Background())
+    if err != nil {
+        return fmt.Errorf("deliver notifications: %w", err)
+    }
+    return p.print(result, []string{"SENT", "RETRIED", "FAILED"}, [][]interface{}{{result.Sent, result.Retried, result.Failed}})
+}
+
+// dispatchEvents meneruskan event domain yang tertunda sekali jalan, mis. dari cron jika
+// dispatcher server dimatikan
+func (a *app) dispatchEvents(args []string) error {
+    fs, p := newFlagSet("events-dispatch")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+    if err := p.validate(); err != nil {
+        return err
+    }
+
+    result, err := a.events.DispatchPending(context.Background())
+    if err != nil {
+        return fmt.Errorf("dispatch events: %w", err)
+    }
+    return p.print(result, []string{"DISPATCHED", "RETRIED", "FAILED"}, [][]interface{}{{result.Dispatched, result.Retried, result.Failed}})
+}
+
+// purgeTrash menghapus permanen isi trash yang melewati retention sekali jalan, mis. dari
+// cron jika worker purge di server dimatikan
+func (a *app) purgeTrash(args []string) error {
+    fs, p := newFlagSet("trash-purge")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+    if err := p.validate(); err != nil {
+        return err
+    }
+
+    result, err := a.trash.Purge(time.Now())
+    if err != nil {
+        return fmt.Errorf("purge trash: %w", err)
+    }
+    if result.Skipped && p.format == "table" {
+        fmt.Fprintln(os.Stderr, "another instance is purging the trash; nothing was deleted")
+    }
+    return p.print(result, []string{"SKIPPED", "BOOKS", "AUTHORS", "PUBLISHERS", "USERS"}, [][]interface{}{{result.Skipped, result.Books, result.Authors, result.Publishers, result.Users}})
+}
+
+// testNotification mengirim notifikasi percobaan ke user lewat satu channel dan melaporkan
+// hasilnya, mis. untuk menguji konfigurasi SMTP terhadap server SMTP palsu lokal
+func (a *app) testNotification(args []string) error {
+    fs, p := newFlagSet("notify-test")
+    ref := fs.String("user", "", "username or user ID")
+    channel := fs.String("channel", models.NotificationChannelEmail, "inapp, email or webhook")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+    if err := p.validate(); err != nil {
+        return err
+    }
+    if *ref == "" {
+        return errors.New("-user is required")
+    }
+
+    user, err := a.findUser(*ref)
+    if err != nil {
+        return fmt.Errorf("user %s: %w", *ref, err)
+    }
+    n, err := a.notifications.SendTest(context.Background(), uuid.MustParse(user.ID), *channel)
+    if err != nil {
+        return fmt.Errorf("test notification: %w", err)
+    }
+    return p.print(n, []string{"ID", "CHANNEL", "LOCALE", "STATUS", "SUBJECT"}, [][]interface{}{{n.ID, n.Channel, n.Locale, n.Status, n.Subject}})
+}
+
+// importBooks menjalankan import katalog yang sama dengan POST /books/import.
+// Exit code bukan nol jika import dibatalkan sehingga bisa dipakai di skrip.
+func (a *app) importBooks(args []string) error {
+    fs, p := newFlagSet("import-books")
+    file := fs.String("file", "", "CSV or JSON file to import (- for stdin)")
+    format := fs.String("format", "", "csv or json (default: from the file extension)")
+    dryRun := fs.Bool("dry-run", false, "validate and report without saving anything")
+    mode := fs.String("mode", services.ImportModeAtomic, "atomic (all or nothing) or batch (commit per batch, skip failed rows)")
+    batchSize := fs.Int("batch-size", services.DefaultImportBatchSize, "rows per transaction in batch mode")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+    if err := p.validate(); err != nil {
+        return err
+    }
+    if *file == "" {
+        return errors.New("-file is required")
+    }
+
+    in := os.Stdin
+    if *file != "-" {
+        f, err := os.Open(*file)
+        if err != nil {
+            return err
+        }
+        defer f.Close()
+        in = f
+    }
+    if *format == "" {
+        switch strings.ToLower(filepath.Ext(*file)) {
+        case ".csv":
+            *format = services.ImportFormatCSV
+        case ".json", ".jsonl", ".ndjson":
+            *format = services.ImportFormatJSON
+        default:
+            return errors.New("-format is required when it cannot be guessed from the file name")
+        }
+    }
+
+    records, err := services.ParseImportFile(in, *format)
+    if err != nil {
+        return err
+    }
+    report, err := a.imports.Import(records, services.ImportOptions{DryRun: *dryRun, Mode: *mode, BatchSize: *batchSize})
+    if err != nil {
+        return fmt.Errorf("import books: %w", err)
+    }
+
+    rows := make([][]interface{}, len(report.Rows))
+    for i, row := range report.Rows {
+        var bookID interface{}
+        if row.BookID != nil {
+            bookID = *row.BookID
+        }
+        rows[i] = []interface{}{row.Row, row.Status, bookID, row.ISBN, row.Title, row.Error}
+    }
+    if err := p.print(report, []string{"ROW", "STATUS", "BOOK", "ISBN", "TITLE", "ERROR"}, rows); err != nil {
+        return err
+    }
+    if p.format == "table" {
+        fmt.Fprintf(os.Stderr, "%d created, %d updated, %d failed; %d authors and %d publishers created (dry run: %t, committed: %t)\n",
+            report.Created, report.Updated, report.Failed, report.AuthorsCreated, report.PublishersCreated, report.DryRun, report.Committed)
+    }
+    if !report.DryRun && !report.Committed {
+        return fmt.Errorf("import rolled back: %d of %d rows failed", report.Failed, report.Total)
+    }
+    return nil
+}
+
+// exportBooks menulis katalog ke file atau stdout. Outputnya adalah data export itu
+// sendiri, jadi command ini tidak memakai flag -o.
+func (a *app) exportBooks(args []string) error {
+    fs := flag.NewFlagSet("libctl export-books", flag.ContinueOnError)
+    format := fs.String("format", services.ExportFormatCSV, "csv, jsonl or marcxml")
+    out := fs.String("out", "-", "output file (- for stdout)")
+    filters := url.Values{}
+    fs.Func("filter", "filter like the GET /books query string, e.g. category=Fiksi or publication_year[gte]=2000 (repeatable)", func(value string) error {
+        key, val, ok := strings.Cut(value, "=")
+        if !ok {
+            return errors.New("filter must look like field=value")
+        }
+        filters.Add(key, val)
+        return nil
+    })
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+    if _, _, err := services.ExportContentType(*format); err != nil {
+        return err
+    }
+    for key := range filters {
+        if key == "limit" || key == "offset" || key == "cursor" || key == "sort" {
+            return fmt.Errorf("%s is not supported: exports always contain every matching book", key)
+        }
+    }
+    spec, err := query.Parse(filters, repository.BookQuerySchema)
+    if err != nil {
+        return err
+    }
+
+    dest := os.Stdout
+    if *out != "-" {
+        f, err := os.Create(*out)
+        if err != nil {
+            return err
+        }
+        defer f.Close()
+        dest = f
+    }
+    w := bufio.NewWriter(dest)
+    if err := a.exports.ExportBooks(w, *format, spec); err != nil {
+        return fmt.Errorf("export books: %w", err)
+    }
+    return w.Flush()
+}
+
+// migrate menjalankan migrasi yang sama dengan server; "status" mendukung -o json
+func (a *app) migrate(args []string) error {
+    if len(args) == 0 {
+        return migrator.ErrUsage
+    }
+    fs, p := newFlagSet("migrate " + args[0])
+    if err := fs.Parse(args[1:]); err != nil {
+        return err
+    }
+    if err := p.validate(); err != nil {
+        return err
+    }
+
+    m, err := migrator.New(a.db, migrations.Files)
+    if err != nil {
+        return err
+    }
+    if args[0] != "status" || p.format == "table" {
+        return migrator.RunCommand(m, append([]string{args[0]}, fs.Args()...), os.Stdout)
+    }
+
+    statuses, err := m.Status()
+    if err != nil {
+        return err
+    }
+    data := make([]map[string]interface{}, len(statuses))
+    for i, status := range statuses {
+        data[i] = map[string]interface{}{
+            "version":    status.Version,
+            "name":       status.Name,
+            "applied":    status.Applied,
+            "applied_at": status.AppliedAt,
+            "drifted":    status.Drifted,
+            "missing":    status.Missing,
+        }
+    }
+    return p.print(data, nil, nil)
+}
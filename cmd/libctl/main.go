@@ -0,0 +1,136 @@
+// cmd/libctl/main.go
+package main
+
+import (
+    "fmt"
+    "os"
+    "auth-user-api/config"
+    "auth-user-api/events"
+    "auth-user-api/notify"
+    "auth-user-api/repository"
+    "auth-user-api/services"
+
+    "gorm.io/driver/postgres"
+    "gorm.io/gorm"
+    "gorm.io/gorm/logger"
+)
+
+const usage = `libctl - admin tool for the library API
+
+usage:
+  libctl [config flags] <command> [command flags]
+
+commands:
+  create-admin     create an admin user (-username, -email, -password)
+  create-invite    issue an invite code for a role (-role, -email, -ttl)
+  reset-password   set a new password and log out all sessions (-user, -password)
+  force-return     close a loan even if its copy is no longer on loan (-loan)
+  recalc-stock     reconcile copy statuses with open loans and recount stock (-book, default all)
+  overdue          list unreturned loans past their due date
+  overdue-job      mark overdue loans, accrue fines and queue reminders once (skipped if another instance holds the lock)
+  import-books     import books from CSV or JSON (-file, -format, -dry-run, -mode, -batch-size)
+  export-books     write the catalog as csv, jsonl or marcxml (-format, -out, -filter)
+  notify-deliver   send pending notifications from the outbox once
+  notify-test      send a test notification to a user right away (-user, -channel)
+  events-dispatch  send pending domain events to subscribers and webhooks once
+  trash-purge      permanently delete trashed rows older than the retention period
+  migrate          up | down [n] | status
+
+Config flags are the same as the server (e.g. -config config.yaml); run "libctl -h" to list them.
+Every command except export-books accepts -o table|json.`
+
+// app menyimpan service yang dipakai subcommand, dibangun dari layer repository/service yang sama dengan server
+type app struct {
+    db         *gorm.DB
+    users      services.UserService
+    auth       services.AuthService
+    loans      *services.LoanService
+    holds      *services.HoldService
+    bookCopies services.BookCopyService
+    invites    services.InviteService
+    imports    *services.CatalogImportService
+    exports    services.CatalogExportService
+    overdueJob *services.OverdueJobService
+
+    notifications *services.NotificationService
+    events        *services.EventDispatcher
+    trash         *services.TrashService
+}
+
+func newApp(cfg *config.Config, db *gorm.DB) *app {
+    userService := services.NewUserService(repository.NewUserRepository(db))
+    authService := services.NewAuthService(userService, repository.NewAuthTokenRepository(db), []byte(cfg.Auth.JWTSecret), cfg.Auth.AccessTokenTTL, cfg.Auth.RefreshTokenTTL)
+
+    loanRepo := repository.NewLoanRepository(db)
+    policyService := services.NewCirculationPolicyService(repository.NewCirculationPolicyRepository(db))
+    holdService := services.NewHoldService(loanRepo, cfg.Circulation.HoldPickupWindow)
+    fineService := services.NewFineService(loanRepo, cfg.Circulation.FineBlockThreshold)
+    eligibilityService := services.NewEligibilityService(loanRepo, policyService, fineService, cfg.Circulation.MaxOpenItems)
+    notificationService := services.NewNotificationService(loanRepo, notify.NewNotifiers(cfg.Notifications), cfg.Notifications.DefaultLocale, cfg.Notifications.MaxAttempts, cfg.Notifications.DeliveryTimeout)
+    loanService := services.NewLoanService(loanRepo, holdService, policyService, fineService, eligibilityService, notificationService)
+
+    return &app{
+        db:         db,
+        users:      userService,
+        auth:       authService,
+        loans:      loanService,
+        holds:      holdService,
+        bookCopies: services.NewBookCopyService(repository.NewBookCopyRepository(db), repository.NewBookRepository(db)),
+        invites:    services.NewInviteService(repository.NewInviteRepository(db), repository.NewRoleRepository(db), []byte(cfg.Auth.JWTSecret), cfg.Auth.InviteTTL, cfg.Auth.InviteMaxTTL, ""),
+        imports:    services.NewCatalogImportService(repository.NewCatalogImportRepository(db)),
+        exports:    services.NewCatalogExportService(repository.NewBookRepository(db)),
+        overdueJob: services.NewOverdueJobService(loanService, cfg.Circulation.ReminderDaysBefore, cfg.Circulation.EscalationIntervalDays, cfg.Circulation.MaxEscalations),
+
+        notifications: notificationService,
+        events:        services.NewEventDispatcher(repository.NewEventRepository(db), events.NewBusFromConfig(cfg.Events), cfg.Events.MaxAttempts, cfg.Events.HandlerTimeout),
+        trash:         services.NewTrashService(repository.NewTrashRepository(db), cfg.Trash.Retention),
+    }
+}
+
+func main() {
+    cfg, args, err := config.Load("libctl", os.Args[1:])
+    if err != nil {
+        fatal(err)
+    }
+    if len(args) == 0 {
+        fmt.Fprintln(os.Stderr, usage)
+        os.Exit(2)
+    }
+
+    db, err := gorm.Open(postgres.Open(cfg.Database.DSN()), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+    if err != nil {
+        fatal(fmt.Errorf("failed to connect to database: %w", err))
+    }
+
+    a := newApp(cfg, db)
+    commands := map[string]func([]string) error{
+        "create-admin":    a.createAdmin,
+        "create-invite":   a.createInvite,
+        "reset-password":  a.resetPassword,
+        "force-return":    a.forceReturn,
+        "recalc-stock":    a.recalcStock,
+        "overdue":         a.overdue,
+        "overdue-job":     a.runOverdueJob,
+        "import-books":    a.importBooks,
+        "export-books":    a.exportBooks,
+        "notify-deliver":  a.deliverNotifications,
+        "notify-test":     a.testNotification,
+        "events-dispatch": a.dispatchEvents,
+        "trash-purge":     a.purgeTrash,
+        "migrate":         a.migrate,
+    }
+
+    command, ok := commands[args[0]]
+    if !ok {
+        fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s\n", args[0], usage)
+        os.Exit(2)
+    }
+    if err := command(args[1:]); err != nil {
+        fatal(err)
+    }
+}
+
+func fatal(err error) {
+    fmt.Fprintln(os.Stderr, "libctl:", err)
+    os.Exit(1)
+}
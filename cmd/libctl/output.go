@@ -0,0 +1,75 @@
+// cmd/libctl/output.go
+package main
+
+import (
+    "encoding/json"
+    "flag"
+    "fmt"
+    "io"
+    "os"
+    "strings"
+    "text/tabwriter"
+    "time"
+)
+
+// printer menulis hasil command sebagai tabel (default) atau JSON
+type printer struct {
+    format string
+    out    io.Writer
+}
+
+// newFlagSet membuat FlagSet subcommand yang sudah punya flag -o
+func newFlagSet(name string) (*flag.FlagSet, *printer) {
+    fs := flag.NewFlagSet("libctl "+name, flag.ContinueOnError)
+    p := &printer{out: os.Stdout}
+    fs.StringVar(&p.format, "o", "table", "output format: table or json")
+    return fs, p
+}
+
+func (p *printer) validate() error {
+    if p.format != "table" && p.format != "json" {
+        return fmt.Errorf("unknown output format %q (use table or json)", p.format)
+    }
+    return nil
+}
+
+// print writes data as JSON, or as a table with the given columns. Each row holds
+// the values for columns in the same order.
+func (p *printer) print(data interface{}, columns []string, rows [][]interface{}) error {
+    if p.format == "json" {
+        encoder := json.NewEncoder(p.out)
+        encoder.SetIndent("", "  ")
+        return encoder.Encode(data)
+    }
+
+    w := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+    fmt.Fprintln(w, strings.Join(columns, "\t"))
+    for _, row := range rows {
+        cells := make([]string, len(row))
+        for i, value := range row {
+            cells[i] = formatCell(value)
+        }
+        fmt.Fprintln(w, strings.Join(cells, "\t"))
+    }
+    return w.Flush()
+}
+
+func formatCell(value interface{}) string {
+    switch v := value.(type) {
+    case nil:
+        return "-"
+    case time.Time:
+        return v.Format("2006-01-02 15:04")
+    case *time.Time:
+        if v == nil {
+            return "-"
+        }
+        return v.Format("2006-01-02 15:04")
+    case *uint:
+        if v == nil {
+            return "-"
+        }
+        return fmt.Sprint(*v)
+    }
+    return fmt.Sprint(value)
+}
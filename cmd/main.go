@@ -4,12 +4,18 @@ package main
 import (
     "fmt"
     "log"
+    "os"
+    "auth-user-api/config"
     "auth-user-api/controllers"
+    "auth-user-api/events"
     "auth-user-api/repository"
     "auth-user-api/services"
     "auth-user-api/models"
     "auth-user-api/utils"
     "auth-user-api/middleware"
+    "auth-user-api/migrations"
+    "auth-user-api/migrator"
+    "auth-user-api/notify"
 
     "github.com/labstack/echo/v4"
     echoMiddleware "github.com/labstack/echo/v4/middleware"
@@ -18,28 +24,82 @@ import (
 )
 
 func main() {
+    // "migrate create" hanya menulis file sehingga tidak butuh konfigurasi maupun database
+    if len(os.Args) > 2 && os.Args[1] == "migrate" && os.Args[2] == "create" {
+        runMigrateCreate(os.Args[3:])
+        return
+    }
+
+    // Muat konfigurasi dari default, file YAML, env dan flag
+    cfg, args, err := config.Load(os.Args[0], os.Args[1:])
+    if err != nil {
+        log.Fatalf("Failed to load configuration: %v", err)
+    }
+
     // Konfigurasi Database
-    dsn := "host=localhost user=postgres password=arnoarno dbname=api-auth port=5432 sslmode=disable TimeZone=Asia/Jakarta"
-    db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+    db, err := gorm.Open(postgres.Open(cfg.Database.DSN()), &gorm.Config{})
     if err != nil {
         log.Fatalf("Failed to connect to database: %v", err)
     }
 
-    // Jalankan Migrasi
-    err = db.Exec("CREATE EXTENSION IF NOT EXISTS \"pgcrypto\";").Error
-    if err != nil {
-        log.Fatalf("Failed to create extension: %v", err)
+    if len(args) > 0 {
+        if args[0] != "migrate" {
+            log.Fatalf("Unknown command %q\n%s", args[0], migrator.Usage)
+        }
+        runMigrate(db, args[1:])
+        return
     }
 
-    err = db.AutoMigrate(&models.User{}, &models.Book{}, &models.Author{}, &models.Publisher{}, &models.LoanRequest{}, &models.LoanRecord{})
+    // Jalankan Migrasi SQL dari folder migrations/
+    m, err := migrator.New(db, migrations.Files)
     if err != nil {
-        log.Fatalf("Failed to migrate database: %v", err)
+        log.Fatalf("Failed to load migrations: %v", err)
+    }
+    if cfg.Database.MigrateOnStart {
+        applied, err := m.Up()
+        if err != nil {
+            log.Fatalf("Failed to migrate database: %v", err)
+        }
+        for _, migration := range applied {
+            log.Printf("Applied migration %03d_%s", migration.Version, migration.Name)
+        }
+    } else if err := m.Verify(); err != nil {
+        log.Fatalf("Database schema is not up to date: %v", err)
+    }
+
+    // GORM AutoMigrate hanya untuk development, skema resmi ada di migrations/
+    if cfg.Database.AutoMigrate {
+        err = db.AutoMigrate(&models.User{}, &models.Book{}, &models.Author{}, &models.Publisher{}, &models.LoanRequest{}, &models.LoanRecord{}, &models.BookCopy{}, &models.Hold{}, &models.LoanRenewal{}, &models.CirculationPolicy{}, &models.Fine{}, &models.Payment{}, &models.Role{}, &models.RolePermission{}, &models.RefreshToken{}, &models.RevokedToken{}, &models.Invite{}, &models.BookContributor{}, &models.LoanReminder{}, &models.Notification{}, &models.DomainEvent{}, &models.AuditLog{})
+        if err != nil {
+            log.Fatalf("Failed to auto-migrate database: %v", err)
+        }
+    }
+
+    // Buat eksemplar untuk buku lama yang masih memakai stok manual
+    bookCopyRepo := repository.NewBookCopyRepository(db)
+    if err := bookCopyRepo.BackfillCopies(); err != nil {
+        log.Fatalf("Failed to backfill book copies: %v", err)
     }
 
+    // Seed role bawaan (admin & member) beserta permission-nya
+    roleRepo := repository.NewRoleRepository(db)
+    roleService := services.NewRoleService(roleRepo, cfg.Auth.PermissionCacheTTL)
+    if err := roleService.EnsureDefaultRoles(); err != nil {
+        log.Fatalf("Failed to seed roles: %v", err)
+    }
+    roleController := controllers.NewRoleController(roleService)
+
     // Inisialisasi Repository, Service, dan Controller
     userRepo := repository.NewUserRepository(db)
     userService := services.NewUserService(userRepo)
-    userController := controllers.NewUserController(userService)
+    inviteService := services.NewInviteService(repository.NewInviteRepository(db), roleRepo, []byte(cfg.Auth.JWTSecret), cfg.Auth.InviteTTL, cfg.Auth.InviteMaxTTL, cfg.Auth.BootstrapToken)
+    userController := controllers.NewUserController(userService, inviteService)
+    inviteController := controllers.NewInviteController(inviteService)
+
+    // Access token berumur pendek, refresh token dirotasi setiap dipakai
+    authTokenRepo := repository.NewAuthTokenRepository(db)
+    authService := services.NewAuthService(userService, authTokenRepo, []byte(cfg.Auth.JWTSecret), cfg.Auth.AccessTokenTTL, cfg.Auth.RefreshTokenTTL)
+    authController := controllers.NewAuthController(authService)
 
     bookRepo := repository.NewBookRepository(db)
     authorRepo := repository.NewAuthorRepository(db)
@@ -49,78 +109,231 @@ func main() {
     authorService := services.NewAuthorService(authorRepo)
     publisherService := services.NewPublisherService(publisherRepo)
 
+    bookCopyService := services.NewBookCopyService(bookCopyRepo, bookRepo)
+    catalogImportService := services.NewCatalogImportService(repository.NewCatalogImportRepository(db))
+    catalogExportService := services.NewCatalogExportService(bookRepo)
+
     bookController := controllers.NewBookController(bookService, authorService, publisherService)
+    bookCopyController := controllers.NewBookCopyController(bookCopyService)
+    catalogImportController := controllers.NewCatalogImportController(catalogImportService)
+    catalogExportController := controllers.NewCatalogExportController(catalogExportService)
     authorController := controllers.NewAuthorController(authorService)
     publisherController := controllers.NewPublisherController(publisherService)
 
     // Inisialisasi Loan Repository, Service, dan Controller
     loanRepo := repository.NewLoanRepository(db)
-    loanService := services.NewLoanService(loanRepo) // LoanService needs access to Book and User repositories
+    policyRepo := repository.NewCirculationPolicyRepository(db)
+    policyService := services.NewCirculationPolicyService(policyRepo)
+    if err := policyService.EnsureDefaultPolicy(); err != nil {
+        log.Fatalf("Failed to seed circulation policy: %v", err)
+    }
+    policyController := controllers.NewCirculationPolicyController(policyService)
+
+    holdService := services.NewHoldService(loanRepo, cfg.Circulation.HoldPickupWindow)
+    fineService := services.NewFineService(loanRepo, cfg.Circulation.FineBlockThreshold)
+    eligibilityService := services.NewEligibilityService(loanRepo, policyService, fineService, cfg.Circulation.MaxOpenItems)
+    notificationService := services.NewNotificationService(loanRepo, notify.NewNotifiers(cfg.Notifications), cfg.Notifications.DefaultLocale, cfg.Notifications.MaxAttempts, cfg.Notifications.DeliveryTimeout)
+    loanService := services.NewLoanService(loanRepo, holdService, policyService, fineService, eligibilityService, notificationService) // LoanService needs access to Book and User repositories
     loanController := controllers.NewLoanController(loanService)
+    holdController := controllers.NewHoldController(holdService)
+    fineController := controllers.NewFineController(fineService)
+    notificationController := controllers.NewNotificationController(notificationService)
+
+    // Hanguskan reservasi yang tidak diambil secara berkala
+    go holdService.RunExpiryWorker(cfg.Circulation.HoldExpiryInterval)
+
+    // Tandai pinjaman terlambat, tambah denda dan antrekan pengingat. Aman dijalankan di
+    // beberapa instance karena dijaga advisory lock.
+    overdueJob := services.NewOverdueJobService(loanService, cfg.Circulation.ReminderDaysBefore, cfg.Circulation.EscalationIntervalDays, cfg.Circulation.MaxEscalations)
+    if cfg.Circulation.OverdueJobInterval > 0 {
+        go overdueJob.RunWorker(cfg.Circulation.OverdueJobInterval)
+    }
+
+    // Kirim notifikasi dari outbox; kegagalan kirim dicoba lagi tanpa menyentuh transaksi asalnya
+    if cfg.Notifications.DeliveryInterval > 0 {
+        go notificationService.RunDeliveryWorker(cfg.Notifications.DeliveryInterval)
+    }
+
+    // Teruskan event domain dari outbox ke subscriber in-process dan webhook
+    eventDispatcher := services.NewEventDispatcher(repository.NewEventRepository(db), events.NewBusFromConfig(cfg.Events), cfg.Events.MaxAttempts, cfg.Events.HandlerTimeout)
+    if cfg.Events.DispatchInterval > 0 {
+        go eventDispatcher.RunWorker(cfg.Events.DispatchInterval)
+    }
+
+    // Audit log append-only untuk setiap perubahan yang berhasil
+    auditService := services.NewAuditService(repository.NewAuditRepository(db))
+    auditController := controllers.NewAuditController(auditService)
+
+    // Data yang dihapus bisa dipulihkan selama retention, setelah itu dihapus permanen
+    trashService := services.NewTrashService(repository.NewTrashRepository(db), cfg.Trash.Retention)
+    trashController := controllers.NewTrashController(trashService)
+    if cfg.Trash.PurgeInterval > 0 {
+        go trashService.RunPurgeWorker(cfg.Trash.PurgeInterval)
+    }
+
+    // Bersihkan jti dan refresh token yang sudah kedaluwarsa
+    go authService.RunCleanupWorker(cfg.Auth.TokenCleanupInterval)
 
     // Inisialisasi Echo
     e := echo.New()
 
+    // IP client untuk audit log: X-Forwarded-For hanya dipercaya dari reverse proxy yang
+    // dikonfigurasi, selain itu header bisa dipalsukan client
+    trustedProxies, _ := cfg.Server.TrustedProxyList() // Sudah divalidasi saat config dimuat
+    if len(trustedProxies) == 0 {
+        e.IPExtractor = echo.ExtractIPDirect()
+    } else {
+        trustOptions := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+        for _, ipRange := range trustedProxies {
+            trustOptions = append(trustOptions, echo.TrustIPRange(ipRange))
+        }
+        e.IPExtractor = echo.ExtractIPFromXFFHeader(trustOptions...)
+    }
+
     // Middleware
     e.Use(echoMiddleware.Logger())
     e.Use(echoMiddleware.Recover())
+    e.Use(echoMiddleware.RequestID())
+    e.Use(middleware.NewAuditMiddleware(auditService).Audit)
 
     // Validator
     e.Validator = utils.NewValidator()
 
-    // JWT Middleware
-    jwtMiddleware := middleware.NewJWTMiddleware(userService)
+    // JWT & RBAC Middleware
+    jwtMiddleware := middleware.NewJWTMiddleware(userService, authService)
+    rbac := middleware.NewRBACMiddleware(roleService)
+    auth := jwtMiddleware.JWTMiddleware
 
     // Routes
-    // User Routes
+    // User Routes (publik)
     e.POST("/register", userController.RegisterUser)
-    e.POST("/login", userController.LoginUser)
+    e.POST("/login", authController.LoginUser)
+    e.POST("/token/refresh", authController.RefreshToken)
+
+    // Session Routes
+    e.POST("/logout", authController.Logout, auth, rbac.Require())
+    e.POST("/logout/all", authController.LogoutAll, auth, rbac.Require())
 
     // Protected User Routes
-    e.POST("/register", userController.RegisterUser)
-    e.POST("/login", userController.LoginUser)
-    e.GET("/users", userController.GetAllUsers)
-    e.PUT("/update/:id", userController.UpdateUser)
-    e.DELETE("/delete", userController.DeleteUser)
-    
+    e.GET("/users", userController.GetAllUsers, auth, rbac.Require(models.PermUsersAdmin))
+    e.POST("/users", userController.CreateUser, auth, rbac.Require(models.PermUsersAdmin))
+    e.PUT("/update/:id", userController.UpdateUser, auth, rbac.Require(models.PermUsersSelf))
+    e.DELETE("/delete", userController.DeleteUser, auth, rbac.Require(models.PermUsersAdmin))
+
+    // Role Routes
+    roleGroup := e.Group("/roles", auth, rbac.Require(models.PermUsersAdmin))
+    roleGroup.GET("", roleController.GetAllRoles)
+    roleGroup.PUT("/:id/permissions", roleController.UpdateRolePermissions)
+
+    // Audit Routes
+    e.GET("/audit", auditController.GetAuditLogs, auth, rbac.Require(models.PermAuditRead))
+
+    // Invite Routes
+    inviteGroup := e.Group("/invites", auth, rbac.Require(models.PermUsersAdmin))
+    inviteGroup.POST("", inviteController.CreateInvite)
+    inviteGroup.GET("", inviteController.GetAllInvites)
+    inviteGroup.DELETE("/:id", inviteController.RevokeInvite)
+
+    // Katalog (buku, eksemplar, author, publisher) tetap bisa dibaca tanpa login seperti
+    // sebelum RBAC; hanya perubahan yang butuh catalog:write
+    catalogWrite := rbac.Require(models.PermCatalogWrite)
+
     // Book Routes
-    e.POST("/books", bookController.CreateBook)
+    e.POST("/books", bookController.CreateBook, auth, catalogWrite)
+    e.POST("/books/import", catalogImportController.ImportBooks, auth, catalogWrite)
+    e.GET("/books/search", bookController.SearchBooks)
     e.GET("/books/:id", bookController.GetBookByID)
     e.GET("/books", bookController.GetAllBooks)
-    e.PUT("/books/:id", bookController.UpdateBook)
-    e.DELETE("/books/:id", bookController.DeleteBook)
+    e.PUT("/books/:id", bookController.UpdateBook, auth, catalogWrite)
+    e.DELETE("/books/:id", bookController.DeleteBook, auth, catalogWrite)
+
+    // Book Copy Routes
+    e.POST("/books/:id/copies", bookCopyController.CreateCopy, auth, catalogWrite)
+    e.GET("/books/:id/copies", bookCopyController.GetCopiesByBook)
+    e.GET("/copies/:id", bookCopyController.GetCopyByID)
+    e.PUT("/copies/:id", bookCopyController.UpdateCopy, auth, catalogWrite)
+    e.GET("/copies/:id/loans", bookCopyController.GetCopyLoanHistory, auth, rbac.Require(models.PermLoansRead))
 
     // Author Routes
-    e.POST("/authors", authorController.CreateAuthor)
+    e.POST("/authors", authorController.CreateAuthor, auth, catalogWrite)
     e.GET("/authors/:id", authorController.GetAuthorByID)
     e.GET("/authors", authorController.GetAllAuthors)
-    e.PUT("/authors/:id", authorController.UpdateAuthor)
-    e.DELETE("/authors/:id", authorController.DeleteAuthor)
+    e.PUT("/authors/:id", authorController.UpdateAuthor, auth, catalogWrite)
+    e.DELETE("/authors/:id", authorController.DeleteAuthor, auth, catalogWrite)
 
     // Publisher Routes
-    e.POST("/publishers", publisherController.CreatePublisher)
+    e.POST("/publishers", publisherController.CreatePublisher, auth, catalogWrite)
     e.GET("/publishers/:id", publisherController.GetPublisherByID)
     e.GET("/publishers", publisherController.GetAllPublishers)
-    e.PUT("/publishers/:id", publisherController.UpdatePublisher)
-    e.DELETE("/publishers/:id", publisherController.DeletePublisher)
+    e.PUT("/publishers/:id", publisherController.UpdatePublisher, auth, catalogWrite)
+    e.DELETE("/publishers/:id", publisherController.DeletePublisher, auth, catalogWrite)
+
+    // Trash Routes (buku, author dan publisher yang dihapus bisa dipulihkan oleh staf katalog)
+    trashGroup := e.Group("/trash", auth)
+    trashGroup.GET("/books", trashController.GetTrashedBooks, catalogWrite)
+    trashGroup.POST("/books/:id/restore", trashController.RestoreBook, catalogWrite)
+    trashGroup.GET("/authors", trashController.GetTrashedAuthors, catalogWrite)
+    trashGroup.POST("/authors/:id/restore", trashController.RestoreAuthor, catalogWrite)
+    trashGroup.GET("/publishers", trashController.GetTrashedPublishers, catalogWrite)
+    trashGroup.POST("/publishers/:id/restore", trashController.RestorePublisher, catalogWrite)
+    trashGroup.GET("/users", trashController.GetTrashedUsers, rbac.Require(models.PermUsersAdmin))
+    trashGroup.POST("/users/:id/restore", trashController.RestoreUser, rbac.Require(models.PermUsersAdmin))
+
+    // Export Routes (seluruh katalog di-stream, hanya untuk staf)
+    e.GET("/export/books", catalogExportController.ExportBooks, auth, catalogWrite)
 
     // Loan Routes
-    loanGroup := e.Group("/loans", jwtMiddleware.JWTMiddleware)
-    loanGroup.POST("/request", loanController.CreateLoanRequest)
-    loanGroup.PUT("/cancel/:id", loanController.CancelLoanRequest)           
-    loanGroup.PUT("/approve/:id", loanController.ApproveLoanRequest)       
-    loanGroup.PUT("/return/:id", loanController.ReturnBook)                
-    e.GET("/loan-requests", loanController.GetAllLoanRequests)
-    e.GET("/loan-records", loanController.GetAllLoanRecords)
-    e.GET("/loans/search/:username", loanController.SearchLoansByUsername)
+    loanRequest := rbac.Require(models.PermLoansRequest)
+    loanRead := rbac.Require(models.PermLoansRead)
+    loanGroup := e.Group("/loans", auth)
+    loanGroup.POST("/request", loanController.CreateLoanRequest, loanRequest)
+    loanGroup.PUT("/cancel/:id", loanController.CancelLoanRequest, loanRequest)
+    loanGroup.PUT("/approve/:id", loanController.ApproveLoanRequest, rbac.Require(models.PermLoansApprove))
+    loanGroup.PUT("/return/:id", loanController.ReturnBook, rbac.Require(models.PermLoansReturn))
+    loanGroup.PUT("/renew/:id", loanController.RenewLoan, loanRequest)
+    loanGroup.GET("/eligibility/:book_id", loanController.CheckEligibility, loanRequest)
+    loanGroup.GET("/me", loanController.GetMyLoans, loanRequest)
+    loanGroup.GET("/me/requests", loanController.GetMyLoanRequests, loanRequest)
+    loanGroup.GET("/search/:username", loanController.SearchLoansByUsername, loanRead)
+    e.GET("/loan-requests", loanController.GetAllLoanRequests, auth, loanRead)
+    e.GET("/loan-records", loanController.GetAllLoanRecords, auth, loanRead)
+
+    // Circulation Policy Routes
+    policyGroup := e.Group("/policies", auth)
+    policyGroup.GET("", policyController.GetAllPolicies, rbac.Require(models.PermPoliciesRead))
+    policyGroup.GET("/resolve", policyController.ResolvePolicy, rbac.Require(models.PermPoliciesRead))
+    policyGroup.POST("", policyController.CreatePolicy, rbac.Require(models.PermPoliciesWrite))
+    policyGroup.PUT("/:id", policyController.UpdatePolicy, rbac.Require(models.PermPoliciesWrite))
+    policyGroup.DELETE("/:id", policyController.DeletePolicy, rbac.Require(models.PermPoliciesWrite))
+
+    // Fine Routes
+    fineGroup := e.Group("/fines", auth)
+    fineGroup.GET("/me", fineController.GetMyFines, rbac.Require(models.PermFinesRead))
+    fineGroup.POST("/:id/payments", fineController.RecordPayment, rbac.Require(models.PermFinesManage))
+    fineGroup.PUT("/:id/waive", fineController.WaiveFine, rbac.Require(models.PermFinesManage))
+    e.GET("/users/:id/fines", fineController.GetUserFines, auth, rbac.Require(models.PermFinesRead))
+
+    // Hold Routes
+    e.GET("/books/:id/holds", holdController.GetQueue, auth, rbac.Require(models.PermHoldsRequest))
+    e.POST("/books/:id/holds", holdController.JoinQueue, auth, rbac.Require(models.PermHoldsRequest))
+    holdGroup := e.Group("/holds", auth)
+    holdGroup.DELETE("/:id", holdController.LeaveQueue, rbac.Require(models.PermHoldsRequest))
+    holdGroup.PUT("/:id/position", holdController.ReorderQueue, rbac.Require(models.PermHoldsManage))
+
+    // Notification Routes (inbox in-app milik user yang login)
+    notificationGroup := e.Group("/me/notifications", auth, rbac.Require(models.PermUsersSelf))
+    notificationGroup.GET("", notificationController.GetMyNotifications)
+    notificationGroup.POST("/read-all", notificationController.MarkAllRead)
+    notificationGroup.POST("/:id/read", notificationController.MarkRead)
+    notificationGroup.GET("/preferences", notificationController.GetPreferences)
+    notificationGroup.PUT("/preferences", notificationController.UpdatePreferences)
 
     // Protected Hello Route Example
-    e.GET("/protected/hello", userController.HelloProtected, jwtMiddleware.JWTMiddleware)
+    e.GET("/protected/hello", userController.HelloProtected, auth, rbac.Require())
 
     // Start Server
-    port := "8080"
-    fmt.Printf("Server running on port %s\n", port)
-    if err := e.Start(":" + port); err != nil {
+    fmt.Printf("Server running on port %d (%s)\n", cfg.Server.Port, cfg.Env)
+    if err := e.Start(cfg.Server.Addr()); err != nil {
         log.Fatalf("Failed to start server: %v", err)
     }
 }
@@ -0,0 +1,38 @@
+// cmd/migrate.go
+package main
+
+import (
+    "fmt"
+    "log"
+    "os"
+    "auth-user-api/migrations"
+    "auth-user-api/migrator"
+
+    "gorm.io/gorm"
+)
+
+// runMigrateCreate membuat file migrasi baru; tidak butuh koneksi database maupun konfigurasi
+func runMigrateCreate(args []string) {
+    if len(args) != 1 {
+        log.Fatal(migrator.Usage)
+    }
+
+    paths, err := migrator.Create("migrations", args[0])
+    if err != nil {
+        log.Fatalf("Failed to create migration: %v", err)
+    }
+    for _, path := range paths {
+        fmt.Println("Created", path)
+    }
+}
+
+// runMigrate menjalankan subcommand migrate up|down|status terhadap database dari konfigurasi
+func runMigrate(db *gorm.DB, args []string) {
+    m, err := migrator.New(db, migrations.Files)
+    if err != nil {
+        log.Fatalf("Failed to load migrations: %v", err)
+    }
+    if err := migrator.RunCommand(m, args, os.Stdout); err != nil {
+        log.Fatal(err)
+    }
+}
@@ -0,0 +1,387 @@
+// config/config.go
+package config
+
+import (
+    "errors"
+    "flag"
+    "fmt"
+    "net"
+    "net/url"
+    "os"
+    "strings"
+    "time"
+
+    "gopkg.in/yaml.v3"
+)
+
+// Config holds every runtime setting of the API. Nilai diisi berurutan dari default,
+// file YAML (opsional), environment variable lalu flag CLI; sumber terakhir menang.
+type Config struct {
+    Env           string              `yaml:"env" env:"APP_ENV" flag:"env" usage:"runtime environment (development, staging, production)"`
+    Server        ServerConfig        `yaml:"server"`
+    Database      DatabaseConfig      `yaml:"database"`
+    Auth          AuthConfig          `yaml:"auth"`
+    Circulation   CirculationConfig   `yaml:"circulation"`
+    Notifications NotificationsConfig `yaml:"notifications"`
+    Events        EventsConfig        `yaml:"events"`
+    Trash         TrashConfig         `yaml:"trash"`
+}
+
+type ServerConfig struct {
+    Port int `yaml:"port" env:"APP_PORT" flag:"port" usage:"HTTP listen port"`
+    // TrustedProxies adalah daftar CIDR dipisah koma milik reverse proxy; hanya proxy ini yang
+    // dipercaya mengisi X-Forwarded-For. Kosong berarti IP client diambil dari koneksi langsung.
+    TrustedProxies string `yaml:"trusted_proxies" env:"APP_TRUSTED_PROXIES" flag:"trusted-proxies" usage:"comma-separated CIDRs of reverse proxies trusted to set X-Forwarded-For"`
+}
+
+type DatabaseConfig struct {
+    Host     string `yaml:"host" env:"DB_HOST" flag:"db-host" usage:"Postgres host"`
+    Port     int    `yaml:"port" env:"DB_PORT" flag:"db-port" usage:"Postgres port"`
+    User     string `yaml:"user" env:"DB_USER" flag:"db-user" usage:"Postgres user"`
+    Password string `yaml:"password" env:"DB_PASSWORD" usage:"Postgres password"` // Sengaja tanpa flag agar tidak terlihat di daftar proses
+    Name     string `yaml:"name" env:"DB_NAME" flag:"db-name" usage:"Postgres database name"`
+    SSLMode  string `yaml:"sslmode" env:"DB_SSLMODE" flag:"db-sslmode" usage:"Postgres sslmode"`
+    TimeZone string `yaml:"timezone" env:"DB_TIMEZONE" flag:"db-timezone" usage:"session time zone"`
+
+    // MigrateOnStart menjalankan migrasi SQL yang tertunda saat server start. Jika false,
+    // server menolak start selama masih ada migrasi tertunda.
+    MigrateOnStart bool `yaml:"migrate_on_start" env:"DB_MIGRATE_ON_START" flag:"migrate-on-start" usage:"apply pending SQL migrations on startup"`
+    // AutoMigrate menjalankan GORM AutoMigrate setelah migrasi SQL; hanya untuk development
+    AutoMigrate bool `yaml:"auto_migrate" env:"DB_AUTO_MIGRATE" flag:"auto-migrate" usage:"also run GORM AutoMigrate (development only)"`
+}
+
+type AuthConfig struct {
+    JWTSecret            string        `yaml:"jwt_secret" env:"JWT_SECRET" usage:"HS256 signing secret"`
+    AccessTokenTTL       time.Duration `yaml:"access_token_ttl" env:"JWT_ACCESS_TTL" flag:"access-token-ttl" usage:"access token lifetime"`
+    RefreshTokenTTL      time.Duration `yaml:"refresh_token_ttl" env:"JWT_REFRESH_TTL" flag:"refresh-token-ttl" usage:"refresh token lifetime"`
+    TokenCleanupInterval time.Duration `yaml:"token_cleanup_interval" env:"TOKEN_CLEANUP_INTERVAL" flag:"token-cleanup-interval" usage:"how often expired tokens are purged"`
+    PermissionCacheTTL   time.Duration `yaml:"permission_cache_ttl" env:"PERMISSION_CACHE_TTL" flag:"permission-cache-ttl" usage:"how long role permissions are cached"`
+    InviteTTL            time.Duration `yaml:"invite_ttl" env:"INVITE_TTL" flag:"invite-ttl" usage:"default lifetime of invite codes"`
+    InviteMaxTTL         time.Duration `yaml:"invite_max_ttl" env:"INVITE_MAX_TTL" flag:"invite-max-ttl" usage:"maximum lifetime an admin may give an invite code"`
+    // BootstrapToken mengizinkan registrasi admin pertama selama belum ada admin; kosongkan setelah dipakai
+    BootstrapToken string `yaml:"bootstrap_token" env:"BOOTSTRAP_ADMIN_TOKEN" usage:"one-time token for registering the first admin"`
+}
+
+type CirculationConfig struct {
+    HoldPickupWindow   time.Duration `yaml:"hold_pickup_window" env:"HOLD_PICKUP_WINDOW" flag:"hold-pickup-window" usage:"how long a READY hold is kept for pickup"`
+    HoldExpiryInterval time.Duration `yaml:"hold_expiry_interval" env:"HOLD_EXPIRY_INTERVAL" flag:"hold-expiry-interval" usage:"how often expired holds are processed"`
+    FineBlockThreshold int           `yaml:"fine_block_threshold" env:"FINE_BLOCK_THRESHOLD" flag:"fine-block-threshold" usage:"outstanding fine balance that blocks borrowing"`
+    MaxOpenItems       int           `yaml:"max_open_items" env:"MAX_OPEN_ITEMS" flag:"max-open-items" usage:"maximum active loans plus pending requests per member"`
+
+    // OverdueJobInterval mengatur seberapa sering job overdue berjalan di server; 0 mematikan
+    // scheduler sehingga job hanya dijalankan lewat "libctl overdue-job" (mis. dari cron)
+    OverdueJobInterval     time.Duration `yaml:"overdue_job_interval" env:"OVERDUE_JOB_INTERVAL" flag:"overdue-job-interval" usage:"how often overdue loans, fines and reminders are processed (0 disables the scheduler)"`
+    ReminderDaysBefore     int           `yaml:"reminder_days_before" env:"REMINDER_DAYS_BEFORE" flag:"reminder-days-before" usage:"days before the due date to queue a reminder (0 disables)"`
+    EscalationIntervalDays int           `yaml:"escalation_interval_days" env:"ESCALATION_INTERVAL_DAYS" flag:"escalation-interval-days" usage:"days between overdue escalations"`
+    MaxEscalations         int           `yaml:"max_escalations" env:"MAX_ESCALATIONS" flag:"max-escalations" usage:"number of overdue escalations per loan (0 disables)"`
+}
+
+type NotificationsConfig struct {
+    // Channels adalah daftar channel aktif dipisah koma: inapp, email, webhook
+    Channels         string        `yaml:"channels" env:"NOTIFY_CHANNELS" flag:"notify-channels" usage:"comma-separated notification channels (inapp, email, webhook)"`
+    DefaultLocale    string        `yaml:"default_locale" env:"NOTIFY_DEFAULT_LOCALE" flag:"notify-default-locale" usage:"locale for users without a preference (id or en)"`
+    DeliveryInterval time.Duration `yaml:"delivery_interval" env:"NOTIFY_DELIVERY_INTERVAL" flag:"notify-delivery-interval" usage:"how often pending notifications are delivered (0 disables the worker)"`
+    MaxAttempts      int           `yaml:"max_attempts" env:"NOTIFY_MAX_ATTEMPTS" flag:"notify-max-attempts" usage:"delivery attempts before a notification is marked FAILED"`
+    DeliveryTimeout  time.Duration `yaml:"delivery_timeout" env:"NOTIFY_DELIVERY_TIMEOUT" flag:"notify-delivery-timeout" usage:"timeout of one SMTP or webhook delivery"`
+
+    SMTPHost     string `yaml:"smtp_host" env:"SMTP_HOST" flag:"smtp-host" usage:"SMTP server host"`
+    SMTPPort     int    `yaml:"smtp_port" env:"SMTP_PORT" flag:"smtp-port" usage:"SMTP server port"`
+    SMTPUsername string `yaml:"smtp_username" env:"SMTP_USERNAME" flag:"smtp-username" usage:"SMTP username (empty disables auth)"`
+    SMTPPassword string `yaml:"smtp_password" env:"SMTP_PASSWORD" usage:"SMTP password"` // Sengaja tanpa flag, sama seperti password database
+    SMTPFrom     string `yaml:"smtp_from" env:"SMTP_FROM" flag:"smtp-from" usage:"sender address of notification emails"`
+
+    WebhookURL    string `yaml:"webhook_url" env:"NOTIFY_WEBHOOK_URL" flag:"notify-webhook-url" usage:"URL that receives webhook notifications"`
+    WebhookSecret string `yaml:"webhook_secret" env:"NOTIFY_WEBHOOK_SECRET" usage:"HMAC-SHA256 secret for signing webhook payloads"`
+}
+
+type EventsConfig struct {
+    DispatchInterval time.Duration `yaml:"dispatch_interval" env:"EVENTS_DISPATCH_INTERVAL" flag:"events-dispatch-interval" usage:"how often domain events are dispatched (0 disables the worker)"`
+    MaxAttempts      int           `yaml:"max_attempts" env:"EVENTS_MAX_ATTEMPTS" flag:"events-max-attempts" usage:"dispatch attempts before a domain event is marked FAILED"`
+    HandlerTimeout   time.Duration `yaml:"handler_timeout" env:"EVENTS_HANDLER_TIMEOUT" flag:"events-handler-timeout" usage:"timeout of one subscriber or webhook call"`
+
+    // WebhookURLs adalah daftar URL dipisah koma yang menerima setiap event domain
+    WebhookURLs   string `yaml:"webhook_urls" env:"EVENTS_WEBHOOK_URLS" flag:"events-webhook-urls" usage:"comma-separated URLs that receive every domain event"`
+    WebhookSecret string `yaml:"webhook_secret" env:"EVENTS_WEBHOOK_SECRET" usage:"HMAC-SHA256 secret for signing event webhooks"`
+    // Log menulis setiap event ke log server; berguna di development untuk melihat event yang terjadi
+    Log bool `yaml:"log" env:"EVENTS_LOG" flag:"events-log" usage:"log every dispatched domain event"`
+}
+
+type TrashConfig struct {
+    // Retention adalah lama baris soft-deleted disimpan di trash sebelum dihapus permanen
+    Retention     time.Duration `yaml:"retention" env:"TRASH_RETENTION" flag:"trash-retention" usage:"how long soft-deleted rows stay restorable before they are purged"`
+    PurgeInterval time.Duration `yaml:"purge_interval" env:"TRASH_PURGE_INTERVAL" flag:"trash-purge-interval" usage:"how often expired trash is purged (0 disables the worker)"`
+}
+
+// TrustedProxyList returns the configured reverse proxy ranges
+func (s ServerConfig) TrustedProxyList() ([]*net.IPNet, error) {
+    var ranges []*net.IPNet
+    for _, cidr := range strings.Split(s.TrustedProxies, ",") {
+        if cidr = strings.TrimSpace(cidr); cidr == "" {
+            continue
+        }
+        _, ipRange, err := net.ParseCIDR(cidr)
+        if err != nil {
+            return nil, err
+        }
+        ranges = append(ranges, ipRange)
+    }
+    return ranges, nil
+}
+
+// WebhookURLList returns the configured event webhook URLs
+func (e EventsConfig) WebhookURLList() []string {
+    var urls []string
+    for _, u := range strings.Split(e.WebhookURLs, ",") {
+        if u = strings.TrimSpace(u); u != "" {
+            urls = append(urls, u)
+        }
+    }
+    return urls
+}
+
+// ChannelList returns the enabled notification channels
+func (n NotificationsConfig) ChannelList() []string {
+    var channels []string
+    for _, channel := range strings.Split(n.Channels, ",") {
+        if channel = strings.TrimSpace(channel); channel != "" {
+            channels = append(channels, channel)
+        }
+    }
+    return channels
+}
+
+// Default returns the settings used when no other source overrides them.
+// JWT secret dan password database tidak punya default.
+func Default() *Config {
+    return &Config{
+        Env: "development",
+        Server: ServerConfig{
+            Port: 8080,
+        },
+        Database: DatabaseConfig{
+            Host:     "localhost",
+            Port:     5432,
+            User:     "postgres",
+            Name:     "api-auth",
+            SSLMode:  "disable",
+            TimeZone: "Asia/Jakarta",
+
+            MigrateOnStart: true,
+        },
+        Auth: AuthConfig{
+            AccessTokenTTL:       15 * time.Minute,
+            RefreshTokenTTL:      30 * 24 * time.Hour,
+            TokenCleanupInterval: time.Hour,
+            PermissionCacheTTL:   time.Minute,
+            InviteTTL:            72 * time.Hour,
+            InviteMaxTTL:         30 * 24 * time.Hour,
+        },
+        Circulation: CirculationConfig{
+            HoldPickupWindow:   48 * time.Hour,
+            HoldExpiryInterval: time.Minute,
+            FineBlockThreshold: 50000,
+            MaxOpenItems:       5,
+
+            OverdueJobInterval:     15 * time.Minute,
+            ReminderDaysBefore:     2,
+            EscalationIntervalDays: 7,
+            MaxEscalations:         3,
+        },
+        Notifications: NotificationsConfig{
+            Channels:         "inapp",
+            DefaultLocale:    "id",
+            DeliveryInterval: 30 * time.Second,
+            MaxAttempts:      5,
+            DeliveryTimeout:  10 * time.Second,
+            SMTPPort:         25,
+        },
+        Events: EventsConfig{
+            DispatchInterval: 5 * time.Second,
+            MaxAttempts:      10,
+            HandlerTimeout:   10 * time.Second,
+        },
+        Trash: TrashConfig{
+            Retention:     30 * 24 * time.Hour,
+            PurgeInterval: 24 * time.Hour,
+        },
+    }
+}
+
+// Load builds the configuration for a process started with args (without the program name)
+// and returns the arguments left after the flags, e.g. a subcommand.
+// File konfigurasi dibaca dari flag -config atau env APP_CONFIG_FILE jika diberikan.
+func Load(name string, args []string) (*Config, []string, error) {
+    cfg := Default()
+
+    fs := flag.NewFlagSet(name, flag.ContinueOnError)
+    configFile := fs.String("config", os.Getenv("APP_CONFIG_FILE"), "path to a YAML config file")
+    flags := registerFlags(fs, cfg)
+    if err := fs.Parse(args); err != nil {
+        return nil, nil, err
+    }
+
+    if *configFile != "" {
+        if err := loadFile(cfg, *configFile); err != nil {
+            return nil, nil, err
+        }
+    }
+
+    if err := applyEnv(cfg, os.LookupEnv); err != nil {
+        return nil, nil, err
+    }
+
+    // Hanya flag yang benar-benar diberikan yang menimpa sumber sebelumnya
+    var flagErr error
+    fs.Visit(func(f *flag.Flag) {
+        if setter, ok := flags[f.Name]; ok && flagErr == nil {
+            if err := setter(f.Value.String()); err != nil {
+                flagErr = fmt.Errorf("flag -%s: %w", f.Name, err)
+            }
+        }
+    })
+    if flagErr != nil {
+        return nil, nil, flagErr
+    }
+
+    if err := cfg.Validate(); err != nil {
+        return nil, nil, err
+    }
+    return cfg, fs.Args(), nil
+}
+
+func loadFile(cfg *Config, path string) error {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return fmt.Errorf("read config file: %w", err)
+    }
+
+    decoder := yaml.NewDecoder(strings.NewReader(string(data)))
+    decoder.KnownFields(true) // Salah ketik nama field langsung ketahuan
+    if err := decoder.Decode(cfg); err != nil {
+        return fmt.Errorf("parse config file %s: %w", path, err)
+    }
+    return nil
+}
+
+// Validate checks that the loaded settings are usable
+func (c *Config) Validate() error {
+    var problems []string
+
+    switch c.Env {
+    case "development", "staging", "production":
+    default:
+        problems = append(problems, "env must be development, staging or production")
+    }
+    if c.Server.Port <= 0 || c.Server.Port > 65535 {
+        problems = append(problems, "server.port must be between 1 and 65535")
+    }
+    if _, err := c.Server.TrustedProxyList(); err != nil {
+        problems = append(problems, "server.trusted_proxies must be comma-separated CIDRs")
+    }
+    if c.Database.Host == "" || c.Database.User == "" || c.Database.Name == "" {
+        problems = append(problems, "database host, user and name are required")
+    }
+    if c.Database.Port <= 0 || c.Database.Port > 65535 {
+        problems = append(problems, "database.port must be between 1 and 65535")
+    }
+    if _, err := time.LoadLocation(c.Database.TimeZone); err != nil {
+        problems = append(problems, "database.timezone is not a valid time zone")
+    }
+    if c.Env == "production" && c.Database.AutoMigrate {
+        problems = append(problems, "database.auto_migrate must be disabled in production")
+    }
+    if c.Auth.JWTSecret == "" {
+        problems = append(problems, "auth.jwt_secret (JWT_SECRET) is required")
+    } else if c.Env == "production" && len(c.Auth.JWTSecret) < 32 {
+        problems = append(problems, "auth.jwt_secret must be at least 32 characters in production")
+    }
+    if c.Auth.AccessTokenTTL <= 0 || c.Auth.RefreshTokenTTL <= c.Auth.AccessTokenTTL {
+        problems = append(problems, "auth token TTLs must be positive and refresh_token_ttl must exceed access_token_ttl")
+    }
+    if c.Auth.TokenCleanupInterval <= 0 || c.Auth.PermissionCacheTTL <= 0 {
+        problems = append(problems, "auth token_cleanup_interval and permission_cache_ttl must be positive")
+    }
+    if c.Auth.InviteTTL <= 0 || c.Auth.InviteMaxTTL < c.Auth.InviteTTL {
+        problems = append(problems, "auth invite_ttl must be positive and not exceed invite_max_ttl")
+    }
+    if c.Auth.BootstrapToken != "" && len(c.Auth.BootstrapToken) < 16 {
+        problems = append(problems, "auth.bootstrap_token must be at least 16 characters")
+    }
+    if c.Circulation.HoldPickupWindow <= 0 || c.Circulation.HoldExpiryInterval <= 0 {
+        problems = append(problems, "circulation hold_pickup_window and hold_expiry_interval must be positive")
+    }
+    if c.Circulation.FineBlockThreshold < 0 {
+        problems = append(problems, "circulation.fine_block_threshold must not be negative")
+    }
+    if c.Circulation.MaxOpenItems <= 0 {
+        problems = append(problems, "circulation.max_open_items must be positive")
+    }
+    if c.Circulation.OverdueJobInterval < 0 || c.Circulation.ReminderDaysBefore < 0 || c.Circulation.MaxEscalations < 0 {
+        problems = append(problems, "circulation overdue_job_interval, reminder_days_before and max_escalations must not be negative")
+    }
+    if c.Circulation.MaxEscalations > 0 && c.Circulation.EscalationIntervalDays <= 0 {
+        problems = append(problems, "circulation.escalation_interval_days must be positive when escalations are enabled")
+    }
+    for _, channel := range c.Notifications.ChannelList() {
+        switch channel {
+        case "inapp":
+        case "email":
+            if c.Notifications.SMTPHost == "" || c.Notifications.SMTPFrom == "" {
+                problems = append(problems, "notifications.smtp_host and smtp_from are required for the email channel")
+            }
+        case "webhook":
+            if c.Notifications.WebhookURL == "" {
+                problems = append(problems, "notifications.webhook_url is required for the webhook channel")
+            }
+        default:
+            problems = append(problems, fmt.Sprintf("notifications.channels: unknown channel %q", channel))
+        }
+    }
+    if c.Notifications.DefaultLocale != "id" && c.Notifications.DefaultLocale != "en" {
+        problems = append(problems, "notifications.default_locale must be id or en")
+    }
+    if c.Notifications.DeliveryInterval < 0 || c.Notifications.MaxAttempts <= 0 || c.Notifications.DeliveryTimeout <= 0 {
+        problems = append(problems, "notifications delivery_interval must not be negative, max_attempts and delivery_timeout must be positive")
+    }
+    if c.Notifications.SMTPPort <= 0 || c.Notifications.SMTPPort > 65535 {
+        problems = append(problems, "notifications.smtp_port must be between 1 and 65535")
+    }
+    if c.Events.DispatchInterval < 0 || c.Events.MaxAttempts <= 0 || c.Events.HandlerTimeout <= 0 {
+        problems = append(problems, "events dispatch_interval must not be negative, max_attempts and handler_timeout must be positive")
+    }
+    for _, raw := range c.Events.WebhookURLList() {
+        if u, err := url.Parse(raw); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+            problems = append(problems, fmt.Sprintf("events.webhook_urls: %q is not an http(s) URL", raw))
+        }
+    }
+    if c.Env == "production" && len(c.Events.WebhookURLList()) > 0 && c.Events.WebhookSecret == "" {
+        problems = append(problems, "events.webhook_secret is required for event webhooks in production")
+    }
+    if c.Trash.Retention <= 0 || c.Trash.PurgeInterval < 0 {
+        problems = append(problems, "trash retention must be positive and purge_interval must not be negative")
+    }
+
+    if len(problems) > 0 {
+        return errors.New("invalid configuration: " + strings.Join(problems, "; "))
+    }
+    return nil
+}
+
+// DSN returns the Postgres connection string for GORM
+func (d DatabaseConfig) DSN() string {
+    return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s TimeZone=%s",
+        dsnValue(d.Host), dsnValue(d.User), dsnValue(d.Password), dsnValue(d.Name), d.Port, dsnValue(d.SSLMode), dsnValue(d.TimeZone))
+}
+
+// dsnValue mengutip nilai kosong atau yang mengandung spasi/kutip sesuai format keyword=value libpq
+func dsnValue(v string) string {
+    if v != "" && !strings.ContainsAny(v, " '\\") {
+        return v
+    }
+    return "'" + strings.NewReplacer("\\", "\\\\", "'", "\\'").Replace(v) + "'"
+}
+
+// Addr returns the listen address for the HTTP server
+func (s ServerConfig) Addr() string {
+    return fmt.Sprintf(":%d", s.Port)
+}
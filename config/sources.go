@@ -0,0 +1,107 @@
+// config/sources.go
+package config
+
+import (
+    "flag"
+    "fmt"
+    "reflect"
+    "strconv"
+    "time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// field is one leaf setting of Config together with its env/flag names
+type field struct {
+    value reflect.Value
+    env   string
+    flag  string
+    usage string
+}
+
+// fields walks Config and returns every leaf field that can be set from env or flags
+func fields(cfg *Config) []field {
+    var out []field
+    var walk func(v reflect.Value)
+    walk = func(v reflect.Value) {
+        t := v.Type()
+        for i := 0; i < t.NumField(); i++ {
+            sf := t.Field(i)
+            fv := v.Field(i)
+            if sf.Type.Kind() == reflect.Struct && sf.Type != durationType {
+                walk(fv)
+                continue
+            }
+            out = append(out, field{
+                value: fv,
+                env:   sf.Tag.Get("env"),
+                flag:  sf.Tag.Get("flag"),
+                usage: sf.Tag.Get("usage"),
+            })
+        }
+    }
+    walk(reflect.ValueOf(cfg).Elem())
+    return out
+}
+
+// set parses raw according to the field's type
+func (f field) set(raw string) error {
+    switch {
+    case f.value.Type() == durationType:
+        d, err := time.ParseDuration(raw)
+        if err != nil {
+            return err
+        }
+        f.value.SetInt(int64(d))
+    case f.value.Kind() == reflect.String:
+        f.value.SetString(raw)
+    case f.value.Kind() == reflect.Int:
+        n, err := strconv.Atoi(raw)
+        if err != nil {
+            return err
+        }
+        f.value.SetInt(int64(n))
+    case f.value.Kind() == reflect.Bool:
+        b, err := strconv.ParseBool(raw)
+        if err != nil {
+            return err
+        }
+        f.value.SetBool(b)
+    default:
+        return fmt.Errorf("unsupported config type %s", f.value.Type())
+    }
+    return nil
+}
+
+// applyEnv overrides fields whose env variable is set
+func applyEnv(cfg *Config, lookup func(string) (string, bool)) error {
+    for _, f := range fields(cfg) {
+        if f.env == "" {
+            continue
+        }
+        if raw, ok := lookup(f.env); ok {
+            if err := f.set(raw); err != nil {
+                return fmt.Errorf("env %s: %w", f.env, err)
+            }
+        }
+    }
+    return nil
+}
+
+// registerFlags mendaftarkan flag untuk setiap field bertag flag. Nilai flag baru diterapkan
+// setelah file dan env dibaca, lewat setter yang dikembalikan.
+func registerFlags(fs *flag.FlagSet, cfg *Config) map[string]func(string) error {
+    setters := map[string]func(string) error{}
+    for _, f := range fields(cfg) {
+        if f.flag == "" {
+            continue
+        }
+        if f.value.Kind() == reflect.Bool {
+            fs.Bool(f.flag, f.value.Bool(), f.usage)
+        } else {
+            fs.String(f.flag, fmt.Sprint(f.value.Interface()), f.usage)
+        }
+        setters[f.flag] = f.set
+    }
+    return setters
+}
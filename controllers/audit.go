@@ -0,0 +1,23 @@
+// controllers/audit.go
+package controllers
+
+import (
+    "fmt"
+    "auth-user-api/services"
+
+    "github.com/labstack/echo/v4"
+)
+
+// recordAudit melaporkan satu perubahan ke AuditMiddleware, yang menyimpannya bersama actor,
+// IP dan request ID setelah handler selesai dengan sukses. before nil berarti create,
+// after nil berarti delete. Pakai struct response agar field sensitif tidak ikut tercatat.
+func recordAudit(ctx echo.Context, action, entityType string, entityID interface{}, before, after interface{}) {
+    entries, _ := ctx.Get("audit_entries").([]*services.AuditEntry)
+    ctx.Set("audit_entries", append(entries, &services.AuditEntry{
+        Action:     action,
+        EntityType: entityType,
+        EntityID:   fmt.Sprint(entityID),
+        Before:     before,
+        After:      after,
+    }))
+}
@@ -0,0 +1,37 @@
+// controllers/audit_controller.go
+package controllers
+
+import (
+    "net/http"
+    "auth-user-api/domains"
+    "auth-user-api/query"
+    "auth-user-api/repository"
+    "auth-user-api/services"
+    "github.com/labstack/echo/v4"
+)
+
+type AuditController struct {
+    service services.AuditService
+}
+
+func NewAuditController(service services.AuditService) *AuditController {
+    return &AuditController{service}
+}
+
+// GetAuditLogs lists audit entries, filterable by actor, entity, action and time range,
+// e.g. /audit?entity_type=book&entity_id=42 or /audit?actor_id=...&created_at[gte]=2026-01-01
+func (c *AuditController) GetAuditLogs(ctx echo.Context) error {
+    spec, err := query.Parse(ctx.QueryParams(), repository.AuditLogQuerySchema)
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid list query", err.Error()))
+    }
+
+    entries, page, err := c.service.GetAuditLogs(spec)
+    if err != nil {
+        response := domains.NewErrorResponse("500", "Failed to retrieve audit logs", err.Error())
+        return ctx.JSON(http.StatusInternalServerError, response)
+    }
+
+    response := domains.NewPaginatedResponse("200", "Audit logs retrieved successfully", entries, buildPagination(ctx, spec, page))
+    return ctx.JSON(http.StatusOK, response)
+}
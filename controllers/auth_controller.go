@@ -0,0 +1,122 @@
+// controllers/auth_controller.go
+package controllers
+
+import (
+    "errors"
+    "net/http"
+    "time"
+    "auth-user-api/domains"
+    "auth-user-api/services"
+    "github.com/labstack/echo/v4"
+)
+
+type AuthController struct {
+    service services.AuthService
+}
+
+func NewAuthController(service services.AuthService) *AuthController {
+    return &AuthController{service}
+}
+
+func tokenResponse(pair *services.TokenPair) domains.TokenResponse {
+    return domains.TokenResponse{
+        Token:            pair.AccessToken,
+        ExpiresAt:        pair.AccessExpiresAt.Format(time.RFC3339),
+        RefreshToken:     pair.RefreshToken,
+        RefreshExpiresAt: pair.RefreshExpiresAt.Format(time.RFC3339),
+    }
+}
+
+// Login User
+func (c *AuthController) LoginUser(ctx echo.Context) error {
+    type LoginRequest struct {
+        Username string `json:"username" validate:"required"`
+        Password string `json:"password" validate:"required"`
+    }
+
+    var req LoginRequest
+    if err := ctx.Bind(&req); err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.BaseResponse{
+            Code:    "400",
+            Message: "Invalid input",
+            Error:   err.Error(),
+        })
+    }
+
+    if err := ctx.Validate(req); err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.BaseResponse{
+            Code:    "400",
+            Message: "Validation error",
+            Error:   err.Error(),
+        })
+    }
+
+    pair, err := c.service.Login(req.Username, req.Password)
+    if err != nil {
+        return ctx.JSON(http.StatusUnauthorized, domains.BaseResponse{
+            Code:    "401",
+            Message: "Invalid username or password",
+            Error:   "AuthenticationError",
+        })
+    }
+
+    return ctx.JSON(http.StatusOK, domains.BaseResponse{
+        Code:    "200",
+        Message: "Successful login",
+        Data:    tokenResponse(pair),
+    })
+}
+
+// RefreshToken menukar refresh token dengan access token dan refresh token baru
+func (c *AuthController) RefreshToken(ctx echo.Context) error {
+    type RefreshRequest struct {
+        RefreshToken string `json:"refresh_token" validate:"required"`
+    }
+
+    var req RefreshRequest
+    if err := ctx.Bind(&req); err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid input", err.Error()))
+    }
+    if err := ctx.Validate(req); err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Validation error", err.Error()))
+    }
+
+    pair, err := c.service.Refresh(req.RefreshToken)
+    if err != nil {
+        if errors.Is(err, services.ErrInvalidRefreshToken) || errors.Is(err, services.ErrRefreshTokenReused) {
+            return ctx.JSON(http.StatusUnauthorized, domains.NewErrorResponse("401", "Invalid refresh token", err.Error()))
+        }
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to refresh token", err.Error()))
+    }
+
+    response := domains.NewSuccessResponseWithData("200", "Token refreshed successfully", tokenResponse(pair))
+    return ctx.JSON(http.StatusOK, response)
+}
+
+// Logout mencabut access token yang dipakai request ini beserta sesinya
+func (c *AuthController) Logout(ctx echo.Context) error {
+    claims, ok := ctx.Get("claims").(*services.TokenClaims)
+    if !ok {
+        return ctx.JSON(http.StatusUnauthorized, domains.NewErrorResponse("401", "Authentication required", "Missing token claims"))
+    }
+
+    if err := c.service.Logout(claims); err != nil {
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to log out", err.Error()))
+    }
+
+    return ctx.JSON(http.StatusOK, domains.NewSuccessResponse("200", "Logged out successfully"))
+}
+
+// LogoutAll mencabut semua sesi milik user yang sedang login
+func (c *AuthController) LogoutAll(ctx echo.Context) error {
+    userID, err := currentUserID(ctx)
+    if err != nil {
+        return ctx.JSON(http.StatusUnauthorized, domains.NewErrorResponse("401", "Authentication required", err.Error()))
+    }
+
+    if err := c.service.LogoutAll(userID.String()); err != nil {
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to log out all sessions", err.Error()))
+    }
+
+    return ctx.JSON(http.StatusOK, domains.NewSuccessResponse("200", "All sessions logged out successfully"))
+}
@@ -1,8 +1,11 @@
 package controllers
 
 import (
+    "errors"
     "net/http"
     "strconv"
+    "auth-user-api/query"
+    "auth-user-api/repository"
     "auth-user-api/services"
     "auth-user-api/models"
     "auth-user-api/domains"
@@ -17,6 +20,27 @@ func NewAuthorController(service services.AuthorService) *AuthorController {
     return &AuthorController{service}
 }
 
+// buildAuthorResponse maps an author and the books they contributed to into the response
+func buildAuthorResponse(author *models.Author, contributions []repository.AuthorContribution) domains.AuthorResponse {
+    books := make([]domains.AuthorBookResponse, len(contributions))
+    for i, contribution := range contributions {
+        books[i] = domains.AuthorBookResponse{
+            BookID: contribution.BookID,
+            Title:  contribution.Title,
+            Role:   contribution.Role,
+        }
+    }
+
+    return domains.AuthorResponse{
+        ID:        author.ID,
+        Name:      author.Name,
+        Books:     books,
+        CreatedAt: author.CreatedAt.String(),
+        UpdatedAt: author.UpdatedAt.String(),
+        DeletedAt: nil,
+    }
+}
+
 // CreateAuthor handles creating a new author
 func (c *AuthorController) CreateAuthor(ctx echo.Context) error {
     author := new(models.Author)
@@ -30,13 +54,8 @@ func (c *AuthorController) CreateAuthor(ctx echo.Context) error {
         return ctx.JSON(http.StatusInternalServerError, response)
     }
 
-    data := domains.AuthorResponse{
-        ID:        author.ID,
-        Name:      author.Name,
-        CreatedAt: author.CreatedAt.String(),
-        UpdatedAt: author.UpdatedAt.String(),
-        DeletedAt: nil,
-    }
+    data := buildAuthorResponse(author, nil)
+    recordAudit(ctx, "author.create", "author", author.ID, nil, data)
     response := domains.NewSuccessResponseWithData("200", "Author created successfully", data)
     return ctx.JSON(http.StatusOK, response)
 }
@@ -50,36 +69,46 @@ func (c *AuthorController) GetAuthorByID(ctx echo.Context) error {
         return ctx.JSON(http.StatusNotFound, response)
     }
 
-    data := domains.AuthorResponse{
-        ID:        author.ID,
-        Name:      author.Name,
-        CreatedAt: author.CreatedAt.String(),
-        UpdatedAt: author.UpdatedAt.String(),
-        DeletedAt: nil,
+    contributions, err := c.service.GetContributions(author.ID)
+    if err != nil {
+        response := domains.NewErrorResponse("500", "Failed to retrieve author books", err.Error())
+        return ctx.JSON(http.StatusInternalServerError, response)
     }
+
+    data := buildAuthorResponse(author, contributions[author.ID])
     response := domains.NewSuccessResponseWithData("200", "Author retrieved successfully", data)
     return ctx.JSON(http.StatusOK, response)
 }
 
-// GetAllAuthors retrieves all authors
+// GetAllAuthors retrieves a page of authors
 func (c *AuthorController) GetAllAuthors(ctx echo.Context) error {
-    authors, err := c.service.GetAllAuthors()
+    spec, err := query.Parse(ctx.QueryParams(), repository.AuthorQuerySchema)
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid list query", err.Error()))
+    }
+
+    authors, page, err := c.service.GetAllAuthors(spec)
     if err != nil {
         response := domains.NewErrorResponse("500", "Failed to retrieve authors", err.Error())
         return ctx.JSON(http.StatusInternalServerError, response)
     }
 
+    // Buku seluruh author di halaman ini diambil dengan satu query
+    ids := make([]int, len(authors))
+    for i, author := range authors {
+        ids[i] = author.ID
+    }
+    contributions, err := c.service.GetContributions(ids...)
+    if err != nil {
+        response := domains.NewErrorResponse("500", "Failed to retrieve author books", err.Error())
+        return ctx.JSON(http.StatusInternalServerError, response)
+    }
+
     authorData := make([]domains.AuthorResponse, len(authors))
     for i, author := range authors {
-        authorData[i] = domains.AuthorResponse{
-            ID:        author.ID,
-            Name:      author.Name,
-            CreatedAt: author.CreatedAt.String(),
-            UpdatedAt: author.UpdatedAt.String(),
-            DeletedAt: nil,
-        }
+        authorData[i] = buildAuthorResponse(author, contributions[author.ID])
     }
-    response := domains.NewSuccessResponseWithData("200", "Authors retrieved successfully", authorData)
+    response := domains.NewPaginatedResponse("200", "Authors retrieved successfully", authorData, buildPagination(ctx, spec, page))
     return ctx.JSON(http.StatusOK, response)
 }
 
@@ -91,6 +120,7 @@ func (c *AuthorController) UpdateAuthor(ctx echo.Context) error {
         response := domains.NewErrorResponse("404", "Author not found", err.Error())
         return ctx.JSON(http.StatusNotFound, response)
     }
+    before := buildAuthorResponse(author, nil)
 
     if err := ctx.Bind(author); err != nil {
         response := domains.NewErrorResponse("400", "Invalid input", err.Error())
@@ -101,25 +131,36 @@ func (c *AuthorController) UpdateAuthor(ctx echo.Context) error {
         response := domains.NewErrorResponse("500", "Failed to update author", err.Error())
         return ctx.JSON(http.StatusInternalServerError, response)
     }
+    recordAudit(ctx, "author.update", "author", author.ID, before, buildAuthorResponse(author, nil))
 
-    data := domains.AuthorResponse{
-        ID:        author.ID,
-        Name:      author.Name,
-        CreatedAt: author.CreatedAt.String(),
-        UpdatedAt: author.UpdatedAt.String(),
-        DeletedAt: nil,
+    contributions, err := c.service.GetContributions(author.ID)
+    if err != nil {
+        response := domains.NewErrorResponse("500", "Failed to retrieve author books", err.Error())
+        return ctx.JSON(http.StatusInternalServerError, response)
     }
+
+    data := buildAuthorResponse(author, contributions[author.ID])
     response := domains.NewSuccessResponseWithData("200", "Author updated successfully", data)
     return ctx.JSON(http.StatusOK, response)
 }
 
-// DeleteAuthor handles deleting an author by ID
+// DeleteAuthor moves an author to the trash. Authors still used by books need ?force=true.
 func (c *AuthorController) DeleteAuthor(ctx echo.Context) error {
     id, _ := strconv.Atoi(ctx.Param("id"))
-    if err := c.service.DeleteAuthor(id); err != nil {
+    force, _ := strconv.ParseBool(ctx.QueryParam("force"))
+    var before interface{}
+    if author, err := c.service.GetAuthorByID(id); err == nil {
+        before = buildAuthorResponse(author, nil)
+    }
+    if err := c.service.DeleteAuthor(id, force); err != nil {
+        var conflict *services.ConflictError
+        if errors.As(err, &conflict) {
+            return ctx.JSON(http.StatusConflict, domains.NewErrorResponse("409", "Author could not be deleted", err.Error()))
+        }
         response := domains.NewErrorResponse("404", "Failed to delete author", err.Error())
         return ctx.JSON(http.StatusNotFound, response)
     }
+    recordAudit(ctx, "author.delete", "author", id, before, nil)
 
     data := domains.DeleteResponse{UserID: strconv.Itoa(id)}
     response := domains.NewSuccessResponseWithData("200", "Author deleted successfully", data)
@@ -3,11 +3,16 @@
 package controllers
 
 import (
+    "errors"
+    "fmt"
     "net/http"
+    "net/url"
     "strconv"
     "time"
     "auth-user-api/domains"
     "auth-user-api/models"
+    "auth-user-api/query"
+    "auth-user-api/repository"
     "auth-user-api/services"
     "github.com/labstack/echo/v4"
 )
@@ -34,27 +39,70 @@ func buildBookResponse(book *models.Book) domains.BookResponse {
         deletedAt = &dt
     }
 
+    subjects := []string(book.Subjects)
+    if subjects == nil {
+        subjects = []string{}
+    }
+
+    contributors := make([]domains.BookContributorResponse, len(book.Contributors))
+    for i, contributor := range book.Contributors {
+        contributors[i] = domains.BookContributorResponse{
+            AuthorID: contributor.AuthorID,
+            Name:     contributor.Author.Name,
+            Role:     contributor.Role,
+            Position: contributor.Position,
+        }
+    }
+
     return domains.BookResponse{
-        ID:          book.ID,
-        Title:       book.Title,
-        Summary:     book.Summary,
-        AuthorID:    book.AuthorID,
-        Author:      domains.BookAuthorResponse{
+        ID:              book.ID,
+        Title:           book.Title,
+        Summary:         book.Summary,
+        Category:        book.Category,
+        ISBN:            book.ISBN,
+        PublicationYear: book.PublicationYear,
+        Edition:         book.Edition,
+        Language:        book.Language,
+        PageCount:       book.PageCount,
+        Subjects:        subjects,
+        CoverImageURL:   book.CoverImageURL,
+        AuthorID:        book.AuthorID,
+        Author:          domains.BookAuthorResponse{
             Name: book.Author.Name,
         },
-        PublisherID: book.PublisherID,
-        Publisher:   domains.BookPublisherResponse{
+        Contributors:    contributors,
+        PublisherID:     book.PublisherID,
+        Publisher:       domains.BookPublisherResponse{
             Name: book.Publisher.Name,
         },
-        Stock:     book.Stock,
-        MaxStock:  book.MaxStock,
-        CreatedAt: book.CreatedAt.Format(time.RFC3339),
-        UpdatedAt: book.UpdatedAt.Format(time.RFC3339),
-        DeletedAt: deletedAt,
+        Stock:           book.Stock,
+        MaxStock:        book.MaxStock,
+        CreatedAt:       book.CreatedAt.Format(time.RFC3339),
+        UpdatedAt:       book.UpdatedAt.Format(time.RFC3339),
+        DeletedAt:       deletedAt,
+    }
+}
+
+// loadContributorAuthors validates every contributor's author through AuthorService and
+// attaches it, sehingga response bisa menampilkan nama tanpa query ulang
+func (c *BookController) loadContributorAuthors(contributors []models.BookContributor) (map[int]*models.Author, error) {
+    authors := make(map[int]*models.Author, len(contributors))
+    for i := range contributors {
+        author, ok := authors[contributors[i].AuthorID]
+        if !ok {
+            var err error
+            author, err = c.authorService.GetAuthorByID(contributors[i].AuthorID)
+            if err != nil {
+                return nil, fmt.Errorf("author %d not found", contributors[i].AuthorID)
+            }
+            authors[author.ID] = author
+        }
+        contributors[i].Author = *author
     }
+    return authors, nil
 }
 
-// CreateBook with AuthorID and PublisherID validation
+// CreateBook with contributor (or AuthorID) and PublisherID validation
 func (c *BookController) CreateBook(ctx echo.Context) error {
     book := new(models.Book)
     if err := ctx.Bind(book); err != nil {
@@ -62,13 +110,15 @@ func (c *BookController) CreateBook(ctx echo.Context) error {
         return ctx.JSON(http.StatusBadRequest, response)
     }
 
-    // Validate Author ID
-    author, err := c.authorService.GetAuthorByID(book.AuthorID)
+    // Validate every contributor; tanpa contributors, author_id menjadi satu-satunya penulis
+    if len(book.Contributors) == 0 {
+        book.Contributors = []models.BookContributor{{AuthorID: book.AuthorID, Role: models.ContributorRoleAuthor}}
+    }
+    authors, err := c.loadContributorAuthors(book.Contributors)
     if err != nil {
-        response := domains.NewErrorResponse("400", "Invalid author ID", "Author not found")
+        response := domains.NewErrorResponse("400", "Invalid contributor", err.Error())
         return ctx.JSON(http.StatusBadRequest, response)
     }
-    book.Author = *author
 
     // Validate Publisher ID
     publisher, err := c.publisherService.GetPublisherByID(book.PublisherID)
@@ -80,20 +130,29 @@ func (c *BookController) CreateBook(ctx echo.Context) error {
 
     // Create Book
     if err := c.bookService.CreateBook(book); err != nil {
+        var conflict *services.ConflictError
         var code, message string
-        if err.Error() == "stock cannot exceed max_stock" {
+        switch {
+        case err.Error() == "copies cannot be negative":
+            code = "400"
+            message = "max_stock cannot be negative"
+        case errors.Is(err, services.ErrInvalidBook):
             code = "400"
-            message = "Stock cannot exceed max_stock"
-        } else {
+            message = "Invalid book metadata"
+        case errors.As(err, &conflict):
+            return ctx.JSON(http.StatusConflict, domains.NewErrorResponse("409", "Failed to create book", err.Error()))
+        default:
             code = "500"
             message = "Failed to create book"
         }
         response := domains.NewErrorResponse(code, message, err.Error())
         return ctx.JSON(http.StatusBadRequest, response)
     }
+    book.Author = *authors[book.AuthorID]
 
     // Build and send success response
     data := buildBookResponse(book)
+    recordAudit(ctx, "book.create", "book", book.ID, nil, data)
     response := domains.NewSuccessResponseWithData("200", "Book created successfully", data)
     return ctx.JSON(http.StatusOK, response)
 }
@@ -113,9 +172,14 @@ func (c *BookController) GetBookByID(ctx echo.Context) error {
     return ctx.JSON(http.StatusOK, response)
 }
 
-// GetAllBooks retrieves all books
+// GetAllBooks retrieves a page of books, filtered and sorted by the query string
 func (c *BookController) GetAllBooks(ctx echo.Context) error {
-    books, err := c.bookService.GetAllBooks()
+    spec, err := query.Parse(ctx.QueryParams(), repository.BookQuerySchema)
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid list query", err.Error()))
+    }
+
+    books, page, err := c.bookService.GetAllBooks(spec)
     if err != nil {
         response := domains.NewErrorResponse("500", "Failed to retrieve books", err.Error())
         return ctx.JSON(http.StatusInternalServerError, response)
@@ -126,7 +190,38 @@ func (c *BookController) GetAllBooks(ctx echo.Context) error {
     for i, book := range books {
         bookResponses[i] = buildBookResponse(book)
     }
-    response := domains.NewSuccessResponseWithData("200", "Books retrieved successfully", bookResponses)
+    response := domains.NewPaginatedResponse("200", "Books retrieved successfully", bookResponses, buildPagination(ctx, spec, page))
+    return ctx.JSON(http.StatusOK, response)
+}
+
+// SearchBooks handles GET /books/search?q=...: ranked full-text matches with highlighted
+// snippets and facet counts. Filter author_id, publisher_id, category dan in_stock ikut
+// membatasi facet; paginasi hanya mendukung limit/offset.
+func (c *BookController) SearchBooks(ctx echo.Context) error {
+    params := url.Values{}
+    for key, vals := range ctx.QueryParams() {
+        if key != "q" {
+            params[key] = vals
+        }
+    }
+    spec, err := query.Parse(params, repository.BookSearchQuerySchema)
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid list query", err.Error()))
+    }
+    if spec.Cursor != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid list query", "search results support limit/offset pagination only"))
+    }
+
+    result, page, err := c.bookService.SearchBooks(ctx.QueryParam("q"), spec)
+    if err != nil {
+        if errors.Is(err, services.ErrEmptySearchQuery) {
+            return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid search query", err.Error()))
+        }
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to search books", err.Error()))
+    }
+
+    pagination := buildPagination(ctx, spec, page)
+    response := domains.NewPaginatedResponse("200", "Books retrieved successfully", result, pagination)
     return ctx.JSON(http.StatusOK, response)
 }
 
@@ -138,6 +233,7 @@ func (c *BookController) UpdateBook(ctx echo.Context) error {
         response := domains.NewErrorResponse("404", "Book not found", "No book with specified ID")
         return ctx.JSON(http.StatusNotFound, response)
     }
+    before := buildBookResponse(book)
 
     // Temporary struct to hold the incoming update data
     var updateData struct {
@@ -145,8 +241,20 @@ func (c *BookController) UpdateBook(ctx echo.Context) error {
         AuthorID    *int    `json:"author_id"`
         PublisherID *int    `json:"publisher_id"`
         Summary     *string `json:"summary"`
+        Category    *string `json:"category"`
         Stock       *int    `json:"stock"`
         MaxStock    *int    `json:"max_stock"`
+
+        ISBN            *string   `json:"isbn"` // String kosong menghapus ISBN
+        PublicationYear *int      `json:"publication_year"`
+        Edition         *string   `json:"edition"`
+        Language        *string   `json:"language"`
+        PageCount       *int      `json:"page_count"`
+        Subjects        *[]string `json:"subjects"`
+        CoverImageURL   *string   `json:"cover_image_url"`
+
+        // Contributors menggantikan seluruh daftar kontributor
+        Contributors *[]models.BookContributor `json:"contributors"`
     }
 
     // Bind the incoming data
@@ -162,22 +270,58 @@ func (c *BookController) UpdateBook(ctx echo.Context) error {
     if updateData.Summary != nil {
         book.Summary = *updateData.Summary
     }
-    if updateData.Stock != nil {
-        book.Stock = *updateData.Stock
+    if updateData.Category != nil {
+        book.Category = *updateData.Category
+    }
+    if updateData.ISBN != nil {
+        book.ISBN = updateData.ISBN
+    }
+    if updateData.PublicationYear != nil {
+        book.PublicationYear = updateData.PublicationYear
+    }
+    if updateData.Edition != nil {
+        book.Edition = *updateData.Edition
+    }
+    if updateData.Language != nil {
+        book.Language = *updateData.Language
+    }
+    if updateData.PageCount != nil {
+        book.PageCount = updateData.PageCount
+    }
+    if updateData.Subjects != nil {
+        book.Subjects = *updateData.Subjects
     }
-    if updateData.MaxStock != nil {
-        book.MaxStock = *updateData.MaxStock
+    if updateData.CoverImageURL != nil {
+        book.CoverImageURL = *updateData.CoverImageURL
+    }
+    // Stok dihitung dari status eksemplar, ubah lewat endpoint /copies
+    if updateData.Stock != nil || updateData.MaxStock != nil {
+        response := domains.NewErrorResponse("400", "Stock cannot be updated directly", "Manage stock through book copies")
+        return ctx.JSON(http.StatusBadRequest, response)
     }
 
-    // Validate and update AuthorID if provided
-    if updateData.AuthorID != nil {
-        author, err := c.authorService.GetAuthorByID(*updateData.AuthorID)
-        if err != nil {
-            response := domains.NewErrorResponse("400", "Invalid author ID", "Author not found")
-            return ctx.JSON(http.StatusBadRequest, response)
+    // Validate and update AuthorID if provided; author_id saja mengganti penulis utama
+    if updateData.AuthorID != nil && updateData.Contributors == nil {
+        replaced := false
+        for i := range book.Contributors {
+            if book.Contributors[i].AuthorID == book.AuthorID && book.Contributors[i].Role == models.ContributorRoleAuthor {
+                book.Contributors[i].AuthorID = *updateData.AuthorID
+                replaced = true
+                break
+            }
         }
-        book.AuthorID = *updateData.AuthorID
-        book.Author = *author
+        if !replaced {
+            primary := models.BookContributor{AuthorID: *updateData.AuthorID, Role: models.ContributorRoleAuthor}
+            book.Contributors = append([]models.BookContributor{primary}, book.Contributors...)
+        }
+    }
+    if updateData.Contributors != nil {
+        book.Contributors = *updateData.Contributors
+    }
+    authors, err := c.loadContributorAuthors(book.Contributors)
+    if err != nil {
+        response := domains.NewErrorResponse("400", "Invalid contributor", err.Error())
+        return ctx.JSON(http.StatusBadRequest, response)
     }
 
     // Validate and update PublisherID if provided
@@ -193,27 +337,32 @@ func (c *BookController) UpdateBook(ctx echo.Context) error {
 
     // Update the book
     if err := c.bookService.UpdateBook(book); err != nil {
-        var code, message string
-        if err.Error() == "stock cannot exceed max_stock" {
-            code = "400"
-            message = "Stock cannot exceed max_stock"
-        } else {
-            code = "500"
-            message = "Failed to update book"
+        var conflict *services.ConflictError
+        if errors.Is(err, services.ErrInvalidBook) {
+            return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid book metadata", err.Error()))
         }
-        response := domains.NewErrorResponse(code, message, err.Error())
-        return ctx.JSON(http.StatusBadRequest, response)
+        if errors.As(err, &conflict) {
+            return ctx.JSON(http.StatusConflict, domains.NewErrorResponse("409", "Failed to update book", err.Error()))
+        }
+        response := domains.NewErrorResponse("500", "Failed to update book", err.Error())
+        return ctx.JSON(http.StatusInternalServerError, response)
+    }
+    if author, ok := authors[book.AuthorID]; ok {
+        book.Author = *author
     }
 
     // Build and send success response
     data := buildBookResponse(book)
+    recordAudit(ctx, "book.update", "book", book.ID, before, data)
     response := domains.NewSuccessResponseWithData("200", "Book updated successfully", data)
     return ctx.JSON(http.StatusOK, response)
 }
 
-// DeleteBook deletes a book by ID
+// DeleteBook moves a book to the trash. Books with unreturned loans need ?force=true;
+// books with pending loan requests or holds are always refused.
 func (c *BookController) DeleteBook(ctx echo.Context) error {
     id, err := strconv.Atoi(ctx.Param("id"))
+    force, _ := strconv.ParseBool(ctx.QueryParam("force"))
     response := domains.BaseResponse{
         Parameter: "id",
     }
@@ -226,12 +375,22 @@ func (c *BookController) DeleteBook(ctx echo.Context) error {
         return ctx.JSON(http.StatusBadRequest, response)
     }
 
-    if err := c.bookService.DeleteBook(id); err != nil {
+    // Snapshot sebelum dihapus untuk audit log
+    var before interface{}
+    if book, err := c.bookService.GetBookByID(id); err == nil {
+        before = buildBookResponse(book)
+    }
+    if err := c.bookService.DeleteBook(id, force); err != nil {
+        var conflict *services.ConflictError
         status := http.StatusInternalServerError
         response.Message = "Failed to delete book"
-        if err.Error() == "book not found" {
+        switch {
+        case err.Error() == "book not found":
             status = http.StatusNotFound
             response.Message = "Book not found"
+        case errors.As(err, &conflict):
+            status = http.StatusConflict
+            response.Message = "Book could not be deleted"
         }
         response.Code = strconv.Itoa(status)
         response.Error = err.Error()
@@ -240,6 +399,7 @@ func (c *BookController) DeleteBook(ctx echo.Context) error {
     }
 
     response.Code = strconv.Itoa(http.StatusOK)
+    recordAudit(ctx, "book.delete", "book", id, before, nil)
     response.Message = "Book deleted successfully"
     response.Data = map[string]interface{}{
         "id": id,
@@ -0,0 +1,178 @@
+// controllers/book_copy_controller.go
+
+package controllers
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+    "time"
+    "auth-user-api/domains"
+    "auth-user-api/models"
+    "auth-user-api/services"
+    "github.com/labstack/echo/v4"
+)
+
+type BookCopyController struct {
+    service services.BookCopyService
+}
+
+func NewBookCopyController(service services.BookCopyService) *BookCopyController {
+    return &BookCopyController{service}
+}
+
+// Helper function to build BookCopyResponse from a copy model
+func buildBookCopyResponse(bookCopy *models.BookCopy) domains.BookCopyResponse {
+    return domains.BookCopyResponse{
+        ID:              bookCopy.ID,
+        BookID:          bookCopy.BookID,
+        Barcode:         bookCopy.Barcode,
+        AcquisitionDate: bookCopy.AcquisitionDate.Format(time.RFC3339),
+        Condition:       bookCopy.Condition,
+        ShelfLocation:   bookCopy.ShelfLocation,
+        Status:          bookCopy.Status,
+    }
+}
+
+// CreateCopy registers a new physical copy for a book
+func (c *BookCopyController) CreateCopy(ctx echo.Context) error {
+    bookID, err := strconv.Atoi(ctx.Param("id"))
+    if err != nil {
+        response := domains.NewErrorResponse("400", "Invalid book ID", err.Error())
+        return ctx.JSON(http.StatusBadRequest, response)
+    }
+
+    bookCopy := new(models.BookCopy)
+    if err := ctx.Bind(bookCopy); err != nil {
+        response := domains.NewErrorResponse("400", "Invalid input", "Binding error")
+        return ctx.JSON(http.StatusBadRequest, response)
+    }
+    bookCopy.ID = 0
+    bookCopy.BookID = bookID
+
+    if err := c.service.AddCopy(bookCopy); err != nil {
+        if errors.Is(err, services.ErrInvalidCopyStatus) || err.Error() == "barcode is required" {
+            response := domains.NewErrorResponse("400", "Invalid copy data", err.Error())
+            return ctx.JSON(http.StatusBadRequest, response)
+        }
+        if err.Error() == "record not found" {
+            response := domains.NewErrorResponse("404", "Book not found", "No book with specified ID")
+            return ctx.JSON(http.StatusNotFound, response)
+        }
+        response := domains.NewErrorResponse("500", "Failed to create copy", err.Error())
+        return ctx.JSON(http.StatusInternalServerError, response)
+    }
+
+    data := buildBookCopyResponse(bookCopy)
+    recordAudit(ctx, "copy.create", "book_copy", bookCopy.ID, nil, data)
+    response := domains.NewSuccessResponseWithData("200", "Copy created successfully", data)
+    return ctx.JSON(http.StatusOK, response)
+}
+
+// GetCopiesByBook retrieves all copies of a book
+func (c *BookCopyController) GetCopiesByBook(ctx echo.Context) error {
+    bookID, err := strconv.Atoi(ctx.Param("id"))
+    if err != nil {
+        response := domains.NewErrorResponse("400", "Invalid book ID", err.Error())
+        return ctx.JSON(http.StatusBadRequest, response)
+    }
+
+    copies, err := c.service.GetCopiesByBookID(bookID)
+    if err != nil {
+        response := domains.NewErrorResponse("500", "Failed to retrieve copies", err.Error())
+        return ctx.JSON(http.StatusInternalServerError, response)
+    }
+
+    copyResponses := make([]domains.BookCopyResponse, len(copies))
+    for i, bookCopy := range copies {
+        copyResponses[i] = buildBookCopyResponse(bookCopy)
+    }
+    response := domains.NewSuccessResponseWithData("200", "Copies retrieved successfully", copyResponses)
+    return ctx.JSON(http.StatusOK, response)
+}
+
+// GetCopyByID retrieves a single copy by ID
+func (c *BookCopyController) GetCopyByID(ctx echo.Context) error {
+    id, _ := strconv.Atoi(ctx.Param("id"))
+    bookCopy, err := c.service.GetCopyByID(uint(id))
+    if err != nil {
+        response := domains.NewErrorResponse("404", "Copy not found", "No copy with specified ID")
+        return ctx.JSON(http.StatusNotFound, response)
+    }
+
+    response := domains.NewSuccessResponseWithData("200", "Copy retrieved successfully", buildBookCopyResponse(bookCopy))
+    return ctx.JSON(http.StatusOK, response)
+}
+
+// UpdateCopy updates condition, shelf location or status of a copy
+func (c *BookCopyController) UpdateCopy(ctx echo.Context) error {
+    id, _ := strconv.Atoi(ctx.Param("id"))
+    bookCopy, err := c.service.GetCopyByID(uint(id))
+    if err != nil {
+        response := domains.NewErrorResponse("404", "Copy not found", "No copy with specified ID")
+        return ctx.JSON(http.StatusNotFound, response)
+    }
+    before := buildBookCopyResponse(bookCopy)
+
+    var updateData struct {
+        Barcode       *string `json:"barcode"`
+        Condition     *string `json:"condition"`
+        ShelfLocation *string `json:"shelf_location"`
+        Status        *string `json:"status"`
+    }
+    if err := ctx.Bind(&updateData); err != nil {
+        response := domains.NewErrorResponse("400", "Invalid input", "Binding error")
+        return ctx.JSON(http.StatusBadRequest, response)
+    }
+
+    bookCopy, err = c.service.UpdateCopy(bookCopy.ID, &services.CopyUpdate{
+        Barcode:       updateData.Barcode,
+        Condition:     updateData.Condition,
+        ShelfLocation: updateData.ShelfLocation,
+        Status:        updateData.Status,
+    })
+    if err != nil {
+        if errors.Is(err, services.ErrInvalidCopyStatus) || errors.Is(err, services.ErrCopyStatusManaged) {
+            response := domains.NewErrorResponse("400", "Invalid copy status", err.Error())
+            return ctx.JSON(http.StatusBadRequest, response)
+        }
+        response := domains.NewErrorResponse("500", "Failed to update copy", err.Error())
+        return ctx.JSON(http.StatusInternalServerError, response)
+    }
+
+    data := buildBookCopyResponse(bookCopy)
+    recordAudit(ctx, "copy.update", "book_copy", bookCopy.ID, before, data)
+    response := domains.NewSuccessResponseWithData("200", "Copy updated successfully", data)
+    return ctx.JSON(http.StatusOK, response)
+}
+
+// GetCopyLoanHistory lists every loan that used a copy, for auditing lost or damaged items
+func (c *BookCopyController) GetCopyLoanHistory(ctx echo.Context) error {
+    id, _ := strconv.Atoi(ctx.Param("id"))
+    records, err := c.service.GetLoanHistory(uint(id))
+    if err != nil {
+        response := domains.NewErrorResponse("500", "Failed to retrieve copy loan history", err.Error())
+        return ctx.JSON(http.StatusInternalServerError, response)
+    }
+
+    history := make([]domains.LoanRecordResponse, len(records))
+    for i, record := range records {
+        var returnDate *string
+        if record.ReturnDate != nil {
+            rd := record.ReturnDate.Format(time.RFC3339)
+            returnDate = &rd
+        }
+        history[i] = domains.LoanRecordResponse{
+            ID:         record.ID,
+            BookID:     record.BookID,
+            CopyID:     record.CopyID,
+            UserID:     record.UserID.String(),
+            LoanDate:   record.LoanDate.Format(time.RFC3339),
+            DueDate:    record.DueDate.Format(time.RFC3339),
+            Returned:   record.Returned,
+            ReturnDate: returnDate,
+        }
+    }
+    response := domains.NewSuccessResponseWithData("200", "Copy loan history retrieved successfully", history)
+    return ctx.JSON(http.StatusOK, response)
+}
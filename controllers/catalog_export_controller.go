@@ -0,0 +1,71 @@
+// controllers/catalog_export_controller.go
+package controllers
+
+import (
+    "fmt"
+    "net/http"
+    "net/url"
+    "time"
+    "auth-user-api/domains"
+    "auth-user-api/query"
+    "auth-user-api/repository"
+    "auth-user-api/services"
+
+    "github.com/labstack/echo/v4"
+)
+
+type CatalogExportController struct {
+    exportService services.CatalogExportService
+}
+
+func NewCatalogExportController(exportService services.CatalogExportService) *CatalogExportController {
+    return &CatalogExportController{exportService: exportService}
+}
+
+// ExportBooks handles GET /export/books?format=csv|jsonl|marcxml. Filter yang sama dengan
+// GET /books boleh dipakai; hasilnya selalu seluruh buku yang cocok sehingga parameter
+// paginasi ditolak. Response di-stream per batch, bukan ditampung dulu di memori.
+func (c *CatalogExportController) ExportBooks(ctx echo.Context) error {
+    format := ctx.QueryParam("format")
+    if format == "" {
+        format = services.ExportFormatCSV
+    }
+    contentType, extension, err := services.ExportContentType(format)
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid export format", err.Error()))
+    }
+
+    params := url.Values{}
+    for key, vals := range ctx.QueryParams() {
+        switch key {
+        case "format":
+        case "limit", "offset", "cursor", "sort":
+            return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid list query", fmt.Sprintf("%s is not supported: exports always contain every matching book", key)))
+        default:
+            params[key] = vals
+        }
+    }
+    spec, err := query.Parse(params, repository.BookQuerySchema)
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid list query", err.Error()))
+    }
+
+    filename := fmt.Sprintf("books-%s%s", time.Now().Format("20060102"), extension)
+    header := ctx.Response().Header()
+    header.Set(echo.HeaderContentType, contentType)
+    header.Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+
+    // Status 200 baru dikirim bersama byte pertama; error sebelum itu masih bisa berupa JSON
+    ctx.Response().Status = http.StatusOK
+    if err := c.exportService.ExportBooks(ctx.Response(), format, spec); err != nil {
+        if ctx.Response().Committed {
+            // Sebagian file sudah terkirim. ErrAbortHandler memutus koneksi agar client
+            // tidak mengira file yang terpotong sudah lengkap.
+            ctx.Logger().Errorf("export books: %v", err)
+            panic(http.ErrAbortHandler)
+        }
+        header.Del(echo.HeaderContentDisposition)
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to export books", err.Error()))
+    }
+    return nil
+}
@@ -0,0 +1,132 @@
+// controllers/catalog_import_controller.go
+package controllers
+
+import (
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "auth-user-api/domains"
+    "auth-user-api/services"
+
+    "github.com/labstack/echo/v4"
+)
+
+// maxImportBytes membatasi ukuran file import (body mentah maupun field multipart)
+const maxImportBytes = 10 << 20
+
+type CatalogImportController struct {
+    importService *services.CatalogImportService
+}
+
+func NewCatalogImportController(importService *services.CatalogImportService) *CatalogImportController {
+    return &CatalogImportController{importService: importService}
+}
+
+// ImportBooks handles POST /books/import. File dikirim sebagai body mentah atau field
+// multipart "file"; format diambil dari ?format=csv|json, atau ditebak dari nama file
+// dan Content-Type. Query dry_run, mode (atomic|batch) dan batch_size mengatur transaksi.
+func (c *CatalogImportController) ImportBooks(ctx echo.Context) error {
+    opts := services.ImportOptions{Mode: ctx.QueryParam("mode")}
+    if raw := ctx.QueryParam("dry_run"); raw != "" {
+        dryRun, err := strconv.ParseBool(raw)
+        if err != nil {
+            return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid input", "dry_run must be true or false"))
+        }
+        opts.DryRun = dryRun
+    }
+    if raw := ctx.QueryParam("batch_size"); raw != "" {
+        size, err := strconv.Atoi(raw)
+        if err != nil {
+            return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid input", "batch_size must be an integer"))
+        }
+        opts.BatchSize = size
+    }
+
+    ctx.Request().Body = http.MaxBytesReader(ctx.Response(), ctx.Request().Body, maxImportBytes)
+    invalidFile := func(err error) error {
+        var tooLarge *http.MaxBytesError
+        if errors.As(err, &tooLarge) {
+            return ctx.JSON(http.StatusRequestEntityTooLarge, domains.NewErrorResponse("413", "Import file too large", fmt.Sprintf("files are limited to %d MB", maxImportBytes>>20)))
+        }
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid import file", err.Error()))
+    }
+
+    body, filename, err := importFile(ctx)
+    if err != nil {
+        return invalidFile(err)
+    }
+    defer body.Close()
+
+    format := ctx.QueryParam("format")
+    if format == "" {
+        format = guessImportFormat(filename, ctx.Request().Header.Get(echo.HeaderContentType))
+    }
+
+    records, err := services.ParseImportFile(body, format)
+    if err != nil {
+        return invalidFile(err)
+    }
+
+    report, err := c.importService.Import(records, opts)
+    if err != nil {
+        if errors.Is(err, services.ErrInvalidImportOptions) {
+            return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid input", err.Error()))
+        }
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to import books", err.Error()))
+    }
+
+    // Import atomic dengan baris gagal dibatalkan seluruhnya; laporan tetap dikirim untuk diperbaiki
+    if !report.DryRun && !report.Committed {
+        response := domains.NewErrorResponse("422", "Import rolled back", fmt.Sprintf("%d of %d rows failed", report.Failed, report.Total))
+        response.Data = report
+        return ctx.JSON(http.StatusUnprocessableEntity, response)
+    }
+
+    message := "Books imported successfully"
+    if report.DryRun {
+        message = "Import validated (dry run, nothing was saved)"
+    } else if report.Failed > 0 {
+        message = "Books imported with errors"
+    }
+    return ctx.JSON(http.StatusOK, domains.NewSuccessResponseWithData("200", message, report))
+}
+
+// importFile mengambil field multipart "file" jika ada, selain itu body request
+func importFile(ctx echo.Context) (io.ReadCloser, string, error) {
+    if !strings.HasPrefix(ctx.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+        return ctx.Request().Body, "", nil
+    }
+    header, err := ctx.FormFile("file")
+    var tooLarge *http.MaxBytesError
+    if errors.As(err, &tooLarge) {
+        return nil, "", err
+    }
+    if err != nil {
+        return nil, "", errors.New("multipart upload must contain a \"file\" field")
+    }
+    file, err := header.Open()
+    if err != nil {
+        return nil, "", err
+    }
+    return file, header.Filename, nil
+}
+
+func guessImportFormat(filename, contentType string) string {
+    switch strings.ToLower(filepath.Ext(filename)) {
+    case ".csv":
+        return services.ImportFormatCSV
+    case ".json", ".jsonl", ".ndjson":
+        return services.ImportFormatJSON
+    }
+    switch {
+    case strings.HasPrefix(contentType, "text/csv"):
+        return services.ImportFormatCSV
+    case strings.HasPrefix(contentType, echo.MIMEApplicationJSON), strings.HasPrefix(contentType, "application/x-ndjson"):
+        return services.ImportFormatJSON
+    }
+    return ""
+}
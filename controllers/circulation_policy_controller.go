@@ -0,0 +1,112 @@
+// controllers/circulation_policy_controller.go
+package controllers
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+    "auth-user-api/domains"
+    "auth-user-api/services"
+    "github.com/labstack/echo/v4"
+)
+
+type CirculationPolicyController struct {
+    service services.CirculationPolicyService
+}
+
+func NewCirculationPolicyController(service services.CirculationPolicyService) *CirculationPolicyController {
+    return &CirculationPolicyController{service}
+}
+
+// GetAllPolicies lists every circulation policy
+func (c *CirculationPolicyController) GetAllPolicies(ctx echo.Context) error {
+    policies, err := c.service.GetAllPolicies()
+    if err != nil {
+        response := domains.NewErrorResponse("500", "Failed to retrieve policies", err.Error())
+        return ctx.JSON(http.StatusInternalServerError, response)
+    }
+
+    response := domains.NewSuccessResponseWithData("200", "Policies retrieved successfully", policies)
+    return ctx.JSON(http.StatusOK, response)
+}
+
+// ResolvePolicy shows which policy applies to a role and book category
+func (c *CirculationPolicyController) ResolvePolicy(ctx echo.Context) error {
+    role, _ := strconv.Atoi(ctx.QueryParam("role"))
+    policy, err := c.service.ResolvePolicy(role, ctx.QueryParam("category"))
+    if err != nil {
+        response := domains.NewErrorResponse("500", "Failed to resolve policy", err.Error())
+        return ctx.JSON(http.StatusInternalServerError, response)
+    }
+
+    response := domains.NewSuccessResponseWithData("200", "Policy resolved successfully", policy)
+    return ctx.JSON(http.StatusOK, response)
+}
+
+// CreatePolicy adds a circulation policy for a role and category
+func (c *CirculationPolicyController) CreatePolicy(ctx echo.Context) error {
+    policy := services.DefaultCirculationPolicy()
+    if err := ctx.Bind(policy); err != nil {
+        response := domains.NewErrorResponse("400", "Invalid input", err.Error())
+        return ctx.JSON(http.StatusBadRequest, response)
+    }
+    policy.ID = 0
+
+    if err := c.service.CreatePolicy(policy); err != nil {
+        return policyErrorResponse(ctx, "Failed to create policy", err)
+    }
+
+    recordAudit(ctx, "policy.create", "circulation_policy", policy.ID, nil, policy)
+    response := domains.NewSuccessResponseWithData("200", "Policy created successfully", policy)
+    return ctx.JSON(http.StatusOK, response)
+}
+
+// UpdatePolicy updates an existing circulation policy
+func (c *CirculationPolicyController) UpdatePolicy(ctx echo.Context) error {
+    id, _ := strconv.Atoi(ctx.Param("id"))
+    policy, err := c.service.GetPolicyByID(uint(id))
+    if err != nil {
+        response := domains.NewErrorResponse("404", "Policy not found", err.Error())
+        return ctx.JSON(http.StatusNotFound, response)
+    }
+    before := *policy
+
+    if err := ctx.Bind(policy); err != nil {
+        response := domains.NewErrorResponse("400", "Invalid input", err.Error())
+        return ctx.JSON(http.StatusBadRequest, response)
+    }
+    policy.ID = uint(id)
+
+    if err := c.service.UpdatePolicy(policy); err != nil {
+        return policyErrorResponse(ctx, "Failed to update policy", err)
+    }
+
+    recordAudit(ctx, "policy.update", "circulation_policy", policy.ID, before, policy)
+    response := domains.NewSuccessResponseWithData("200", "Policy updated successfully", policy)
+    return ctx.JSON(http.StatusOK, response)
+}
+
+// DeletePolicy removes a circulation policy
+func (c *CirculationPolicyController) DeletePolicy(ctx echo.Context) error {
+    id, _ := strconv.Atoi(ctx.Param("id"))
+    var before interface{}
+    if policy, err := c.service.GetPolicyByID(uint(id)); err == nil {
+        before = policy
+    }
+    if err := c.service.DeletePolicy(uint(id)); err != nil {
+        response := domains.NewErrorResponse("404", "Failed to delete policy", err.Error())
+        return ctx.JSON(http.StatusNotFound, response)
+    }
+    recordAudit(ctx, "policy.delete", "circulation_policy", id, before, nil)
+
+    response := domains.NewSuccessResponseWithData("200", "Policy deleted successfully", map[string]interface{}{"id": id})
+    return ctx.JSON(http.StatusOK, response)
+}
+
+func policyErrorResponse(ctx echo.Context, message string, err error) error {
+    if errors.Is(err, services.ErrInvalidPolicy) {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", message, err.Error()))
+    }
+    return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", message, err.Error()))
+}
+
@@ -0,0 +1,167 @@
+// controllers/fine_controller.go
+package controllers
+
+import (
+    "auth-user-api/domains"
+    "auth-user-api/models"
+    "auth-user-api/services"
+    "errors"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/labstack/echo/v4"
+    "gorm.io/gorm"
+)
+
+type FineController struct {
+    Service *services.FineService
+}
+
+func NewFineController(service *services.FineService) *FineController {
+    return &FineController{Service: service}
+}
+
+// Helper function to build FineResponse from a fine model
+func buildFineResponse(fine *models.Fine) domains.FineResponse {
+    return domains.FineResponse{
+        ID:           fine.ID,
+        LoanRecordID: fine.LoanRecordID,
+        Amount:       fine.Amount,
+        PaidAmount:   fine.PaidAmount,
+        WaivedAmount: fine.WaivedAmount,
+        Balance:      fine.Balance(),
+        Status:       fine.Status,
+        Reason:       fine.Reason,
+        WaiveReason:  fine.WaiveReason,
+        CreatedAt:    fine.CreatedAt.Format(time.RFC3339),
+    }
+}
+
+// GetMyFines shows the logged-in member's fines and balance
+func (fc *FineController) GetMyFines(ctx echo.Context) error {
+    userID, err := currentUserID(ctx)
+    if err != nil {
+        return ctx.JSON(http.StatusUnauthorized, domains.NewErrorResponse("401", "Failed to resolve current user", err.Error()))
+    }
+    return fc.respondWithAccount(ctx, userID.String())
+}
+
+// GetUserFines shows a member's fines and balance. Without fines:manage only the own account is visible.
+func (fc *FineController) GetUserFines(ctx echo.Context) error {
+    userID := ctx.Param("id")
+    if !hasPermission(ctx, models.PermFinesManage) {
+        currentID, err := currentUserID(ctx)
+        if err != nil || currentID.String() != userID {
+            return ctx.JSON(http.StatusForbidden, domains.NewErrorResponse("403", "Access denied", "Members can only view their own fines"))
+        }
+    }
+    return fc.respondWithAccount(ctx, userID)
+}
+
+func (fc *FineController) respondWithAccount(ctx echo.Context, userID string) error {
+    id, err := uuid.Parse(userID)
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid user UUID", err.Error()))
+    }
+
+    fines, balance, err := fc.Service.GetAccount(id)
+    if err != nil {
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to retrieve fines", err.Error()))
+    }
+
+    fineResponses := make([]domains.FineResponse, len(fines))
+    for i, fine := range fines {
+        fineResponses[i] = buildFineResponse(fine)
+    }
+
+    account := domains.FineAccountResponse{
+        UserID:  id.String(),
+        Balance: balance,
+        Fines:   fineResponses,
+    }
+    return ctx.JSON(http.StatusOK, domains.NewSuccessResponseWithData("200", "Fines retrieved successfully", account))
+}
+
+// RecordPayment records a full or partial payment against a fine
+func (fc *FineController) RecordPayment(ctx echo.Context) error {
+    fineID, err := strconv.Atoi(ctx.Param("id"))
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid fine ID", err.Error()))
+    }
+
+    var body struct {
+        Amount int    `json:"amount"`
+        Method string `json:"method"`
+    }
+    if err := ctx.Bind(&body); err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Failed to parse request body", err.Error()))
+    }
+    if body.Method == "" {
+        body.Method = "CASH"
+    }
+
+    before, err := fc.Service.GetFineByID(uint(fineID))
+    if err != nil {
+        return fineErrorResponse(ctx, "Failed to record payment", err)
+    }
+
+    fine, payment, err := fc.Service.RecordPayment(uint(fineID), body.Amount, body.Method, ctx.Get("username").(string))
+    if err != nil {
+        return fineErrorResponse(ctx, "Failed to record payment", err)
+    }
+
+    paymentData := domains.PaymentResponse{
+        ID:         payment.ID,
+        Amount:     payment.Amount,
+        Method:     payment.Method,
+        ReceivedBy: payment.ReceivedBy,
+        Fine:       buildFineResponse(fine),
+    }
+    recordAudit(ctx, "fine.payment", "fine", fine.ID, buildFineResponse(before), buildFineResponse(fine))
+    return ctx.JSON(http.StatusOK, domains.NewSuccessResponseWithData("200", "Payment recorded successfully", paymentData))
+}
+
+// WaiveFine waives part or all of a fine with a reason
+func (fc *FineController) WaiveFine(ctx echo.Context) error {
+    fineID, err := strconv.Atoi(ctx.Param("id"))
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid fine ID", err.Error()))
+    }
+
+    var body struct {
+        Amount int    `json:"amount"` // 0 = hapus seluruh sisa denda
+        Reason string `json:"reason"`
+    }
+    if err := ctx.Bind(&body); err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Failed to parse request body", err.Error()))
+    }
+
+    before, err := fc.Service.GetFineByID(uint(fineID))
+    if err != nil {
+        return fineErrorResponse(ctx, "Failed to waive fine", err)
+    }
+
+    fine, err := fc.Service.WaiveFine(uint(fineID), body.Amount, body.Reason, ctx.Get("username").(string))
+    if err != nil {
+        return fineErrorResponse(ctx, "Failed to waive fine", err)
+    }
+
+    data := buildFineResponse(fine)
+    recordAudit(ctx, "fine.waive", "fine", fine.ID, buildFineResponse(before), data)
+    return ctx.JSON(http.StatusOK, domains.NewSuccessResponseWithData("200", "Fine waived successfully", data))
+}
+
+func fineErrorResponse(ctx echo.Context, message string, err error) error {
+    var conflict *services.ConflictError
+    switch {
+    case errors.Is(err, gorm.ErrRecordNotFound):
+        return ctx.JSON(http.StatusNotFound, domains.NewErrorResponse("404", "Fine not found", err.Error()))
+    case errors.As(err, &conflict):
+        return ctx.JSON(http.StatusConflict, domains.NewErrorResponse("409", message, err.Error()))
+    case errors.Is(err, services.ErrInvalidAmount), errors.Is(err, services.ErrWaiveReasonNeeded):
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", message, err.Error()))
+    }
+    return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", message, err.Error()))
+}
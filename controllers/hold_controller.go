@@ -0,0 +1,170 @@
+// controllers/hold_controller.go
+package controllers
+
+import (
+    "auth-user-api/domains"
+    "auth-user-api/models"
+    "auth-user-api/services"
+    "errors"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/labstack/echo/v4"
+    "gorm.io/gorm"
+)
+
+type HoldController struct {
+    Service *services.HoldService
+}
+
+func NewHoldController(service *services.HoldService) *HoldController {
+    return &HoldController{Service: service}
+}
+
+// Helper function to build HoldResponse from a hold model
+func buildHoldResponse(hold *models.Hold) domains.HoldResponse {
+    var expiresAt *string
+    if hold.ExpiresAt != nil {
+        ea := hold.ExpiresAt.Format(time.RFC3339)
+        expiresAt = &ea
+    }
+
+    return domains.HoldResponse{
+        ID:        hold.ID,
+        BookID:    hold.BookID,
+        UserID:    hold.UserID.String(),
+        Position:  hold.Position,
+        Status:    hold.Status,
+        CopyID:    hold.CopyID,
+        ExpiresAt: expiresAt,
+    }
+}
+
+// GetQueue lists the active reservation queue of a book. Only users with holds:manage see who is queued.
+func (hc *HoldController) GetQueue(ctx echo.Context) error {
+    bookID, err := strconv.Atoi(ctx.Param("id"))
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid book ID", err.Error()))
+    }
+
+    // Anggota biasa tidak boleh melihat siapa saja yang mengantre
+    viewerID, _ := currentUserID(ctx)
+    holds, err := hc.Service.GetQueue(bookID, viewerID, hasPermission(ctx, models.PermHoldsManage))
+    if err != nil {
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to retrieve hold queue", err.Error()))
+    }
+
+    data := domains.HoldQueueResponse{
+        BookID:      bookID,
+        QueueLength: len(holds),
+        Holds:       holds,
+    }
+    return ctx.JSON(http.StatusOK, domains.NewSuccessResponseWithData("200", "Hold queue retrieved successfully", data))
+}
+
+// JoinQueue places the logged-in member at the end of a book's queue.
+// Users with holds:manage may enqueue another member by passing user_id.
+func (hc *HoldController) JoinQueue(ctx echo.Context) error {
+    bookID, err := strconv.Atoi(ctx.Param("id"))
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid book ID", err.Error()))
+    }
+
+    var body struct {
+        UserID string `json:"user_id"`
+    }
+    if err := ctx.Bind(&body); err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Failed to parse request body", err.Error()))
+    }
+
+    admin := hasPermission(ctx, models.PermHoldsManage)
+    var userID uuid.UUID
+    if body.UserID != "" {
+        if !admin {
+            return ctx.JSON(http.StatusForbidden, domains.NewErrorResponse("403", "Access denied", "Only admins can place holds for other members"))
+        }
+        userID, err = uuid.Parse(body.UserID)
+        if err != nil {
+            return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid user UUID", err.Error()))
+        }
+    } else {
+        userID, err = currentUserID(ctx)
+        if err != nil {
+            return ctx.JSON(http.StatusUnauthorized, domains.NewErrorResponse("401", "Failed to resolve current user", err.Error()))
+        }
+    }
+
+    hold, err := hc.Service.JoinQueue(bookID, userID, admin)
+    if err != nil {
+        switch {
+        case errors.Is(err, gorm.ErrRecordNotFound):
+            return ctx.JSON(http.StatusNotFound, domains.NewErrorResponse("404", "Book not found", err.Error()))
+        case errors.Is(err, services.ErrAlreadyInQueue), errors.Is(err, services.ErrBookAvailable), errors.Is(err, services.ErrBookDeleted):
+            return ctx.JSON(http.StatusConflict, domains.NewErrorResponse("409", "Failed to join hold queue", err.Error()))
+        }
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to join hold queue", err.Error()))
+    }
+
+    return ctx.JSON(http.StatusOK, domains.NewSuccessResponseWithData("200", "Joined hold queue successfully", buildHoldResponse(hold)))
+}
+
+// LeaveQueue cancels a hold. Members may only cancel their own holds; holds:manage may cancel any.
+func (hc *HoldController) LeaveQueue(ctx echo.Context) error {
+    holdID, err := strconv.Atoi(ctx.Param("id"))
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid hold ID", err.Error()))
+    }
+
+    hold, err := hc.Service.GetHoldByID(uint(holdID))
+    if err != nil {
+        return ctx.JSON(http.StatusNotFound, domains.NewErrorResponse("404", "Hold not found", err.Error()))
+    }
+
+    if !hasPermission(ctx, models.PermHoldsManage) {
+        userID, err := currentUserID(ctx)
+        if err != nil || userID != hold.UserID {
+            return ctx.JSON(http.StatusForbidden, domains.NewErrorResponse("403", "Access denied", "User does not own this hold"))
+        }
+    }
+
+    hold, err = hc.Service.LeaveQueue(uint(holdID))
+    if err != nil {
+        var conflict *services.ConflictError
+        if errors.As(err, &conflict) {
+            return ctx.JSON(http.StatusConflict, domains.NewErrorResponse("409", "Failed to leave hold queue", err.Error()))
+        }
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to leave hold queue", err.Error()))
+    }
+
+    return ctx.JSON(http.StatusOK, domains.NewSuccessResponseWithData("200", "Hold cancelled successfully", buildHoldResponse(hold)))
+}
+
+// ReorderQueue moves a waiting hold to a new position in the queue
+func (hc *HoldController) ReorderQueue(ctx echo.Context) error {
+    holdID, err := strconv.Atoi(ctx.Param("id"))
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid hold ID", err.Error()))
+    }
+
+    var body struct {
+        Position int `json:"position"`
+    }
+    if err := ctx.Bind(&body); err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Failed to parse request body", err.Error()))
+    }
+
+    hold, err := hc.Service.MoveInQueue(uint(holdID), body.Position)
+    if err != nil {
+        switch {
+        case errors.Is(err, gorm.ErrRecordNotFound):
+            return ctx.JSON(http.StatusNotFound, domains.NewErrorResponse("404", "Hold not found", err.Error()))
+        case errors.Is(err, services.ErrHoldNotWaiting), errors.Is(err, services.ErrInvalidQueueOrder):
+            return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Failed to reorder hold queue", err.Error()))
+        }
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to reorder hold queue", err.Error()))
+    }
+
+    return ctx.JSON(http.StatusOK, domains.NewSuccessResponseWithData("200", "Hold queue reordered successfully", buildHoldResponse(hold)))
+}
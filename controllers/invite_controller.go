@@ -0,0 +1,87 @@
+// controllers/invite_controller.go
+package controllers
+
+import (
+    "errors"
+    "net/http"
+    "time"
+    "auth-user-api/domains"
+    "auth-user-api/services"
+    "github.com/google/uuid"
+    "github.com/labstack/echo/v4"
+    "gorm.io/gorm"
+)
+
+type InviteController struct {
+    service services.InviteService
+}
+
+func NewInviteController(service services.InviteService) *InviteController {
+    return &InviteController{service}
+}
+
+// CreateInvite issues a signed, expiring invite code for a role
+func (c *InviteController) CreateInvite(ctx echo.Context) error {
+    var body struct {
+        Role           int    `json:"role" validate:"required"`
+        Email          string `json:"email" validate:"omitempty,email"`
+        ExpiresInHours int    `json:"expires_in_hours"` // 0 = masa berlaku default
+    }
+    if err := ctx.Bind(&body); err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid input", err.Error()))
+    }
+    if err := ctx.Validate(body); err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Validation error", err.Error()))
+    }
+
+    var createdBy *uuid.UUID
+    if actorID, err := currentUserID(ctx); err == nil {
+        createdBy = &actorID
+    }
+
+    invite, code, err := c.service.CreateInvite(body.Role, body.Email, time.Duration(body.ExpiresInHours)*time.Hour, createdBy)
+    if err != nil {
+        if errors.Is(err, services.ErrUnknownRole) || errors.Is(err, services.ErrInvalidInviteTTL) {
+            return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid invite", err.Error()))
+        }
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to create invite", err.Error()))
+    }
+
+    response := domains.NewSuccessResponseWithData("201", "Invite created successfully", map[string]interface{}{
+        "invite": invite,
+        "code":   code,
+    })
+    return ctx.JSON(http.StatusCreated, response)
+}
+
+// GetAllInvites lists issued invites (codes are never stored or shown again)
+func (c *InviteController) GetAllInvites(ctx echo.Context) error {
+    invites, err := c.service.GetAllInvites()
+    if err != nil {
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to retrieve invites", err.Error()))
+    }
+
+    response := domains.NewSuccessResponseWithData("200", "Invites retrieved successfully", invites)
+    return ctx.JSON(http.StatusOK, response)
+}
+
+// RevokeInvite cancels an unused invite
+func (c *InviteController) RevokeInvite(ctx echo.Context) error {
+    id, err := uuid.Parse(ctx.Param("id"))
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid invite ID", err.Error()))
+    }
+
+    if err := c.service.RevokeInvite(id); err != nil {
+        var conflict *services.ConflictError
+        switch {
+        case errors.Is(err, gorm.ErrRecordNotFound):
+            return ctx.JSON(http.StatusNotFound, domains.NewErrorResponse("404", "Invite not found", err.Error()))
+        case errors.As(err, &conflict):
+            return ctx.JSON(http.StatusConflict, domains.NewErrorResponse("409", "Invite can no longer be revoked", err.Error()))
+        }
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to revoke invite", err.Error()))
+    }
+
+    return ctx.JSON(http.StatusOK, domains.NewSuccessResponse("200", "Invite revoked successfully"))
+}
@@ -3,11 +3,14 @@ package controllers
 
 import (
     "auth-user-api/models"
+    "auth-user-api/query"
+    "auth-user-api/repository"
     "auth-user-api/services"
     "auth-user-api/domains"
     "net/http"
     "github.com/labstack/echo/v4"
     "github.com/google/uuid"
+    "gorm.io/gorm"
     "strconv"
     "errors"
     "time"
@@ -22,27 +25,63 @@ func NewLoanController(service *services.LoanService) *LoanController {
 }
 
 // Create Loan Request
+// Peminjam diambil dari token JWT. Admin dapat mengajukan atas nama anggota lain
+// lewat on_behalf_of; admin tersebut dicatat sebagai requested_by.
 func (lc *LoanController) CreateLoanRequest(ctx echo.Context) error {
-    var req models.LoanRequest
-    if err := ctx.Bind(&req); err != nil {
+    var body struct {
+        BookID     int    `json:"book_id"`
+        OnBehalfOf string `json:"on_behalf_of"`
+        UserID     string `json:"user_id"` // Field lama, diperlakukan sama dengan on_behalf_of
+    }
+    if err := ctx.Bind(&body); err != nil {
         return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Failed to parse request", err.Error()))
     }
 
-    userID, err := uuid.Parse(req.UserID.String())
+    actorID, err := currentUserID(ctx)
     if err != nil {
-        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid user UUID", err.Error()))
+        return ctx.JSON(http.StatusUnauthorized, domains.NewErrorResponse("401", "Failed to resolve current user", err.Error()))
+    }
+
+    req := models.LoanRequest{
+        BookID: body.BookID,
+        UserID: actorID,
+    }
+
+    target := body.OnBehalfOf
+    if target == "" {
+        target = body.UserID
+    }
+    if target != "" && target != actorID.String() {
+        if !hasPermission(ctx, models.PermLoansManage) {
+            return ctx.JSON(http.StatusForbidden, domains.NewErrorResponse("403", "Access denied", "Only admins can file loan requests on behalf of other members"))
+        }
+        borrowerID, err := uuid.Parse(target)
+        if err != nil {
+            return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid user UUID", err.Error()))
+        }
+        req.UserID = borrowerID
+        req.RequestedBy = &actorID
     }
-    req.UserID = userID
 
     if err := lc.Service.CreateLoanRequest(&req); err != nil {
         // Tangani error stok habis sebagai 404
         if errors.Is(err, services.ErrBookOutOfStock) {
             return ctx.JSON(http.StatusNotFound, domains.NewErrorResponse("404", "Book out of stock", err.Error()))
         }
+        var ineligible *services.EligibilityError
+        if errors.As(err, &ineligible) {
+            response := domains.NewErrorResponse("403", "Loan request not allowed", err.Error())
+            response.Data = ineligible.Reasons
+            return ctx.JSON(http.StatusForbidden, response)
+        }
+        var conflict *services.ConflictError
+        if errors.As(err, &conflict) {
+            return ctx.JSON(http.StatusConflict, domains.NewErrorResponse("409", "Loan request could not be created", err.Error()))
+        }
         return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to create loan request", err.Error()))
     }
 
-    username, err := lc.Service.Repo.GetUsernameByUserID(req.UserID)
+    username, err := lc.Service.GetBorrowerName(req.UserID)
     if err != nil {
         return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to retrieve borrower username", err.Error()))
     }
@@ -55,19 +94,17 @@ func (lc *LoanController) CreateLoanRequest(ctx echo.Context) error {
         Status:       "PENDING",
         RequestDate:  time.Now().Format(time.RFC3339),
     }
+    if req.RequestedBy != nil {
+        requestedBy := req.RequestedBy.String()
+        loanResponse.RequestedBy = &requestedBy
+    }
 
     response := domains.NewSuccessResponseWithData("200", "Loan request created successfully", loanResponse)
     return ctx.JSON(http.StatusOK, response)
 }
 
-// ApproveLoanRequest handles loan request approval.
+// ApproveLoanRequest handles loan request approval. Requires loans:approve.
 func (lc *LoanController) ApproveLoanRequest(ctx echo.Context) error {
-    // Check if the user is an admin
-    role := ctx.Get("role").(int)
-    if role != 1 {
-        return ctx.JSON(http.StatusForbidden, domains.NewErrorResponse("403", "Access denied", "Only admins can approve loan requests"))
-    }
-
     requestID, err := strconv.Atoi(ctx.Param("id"))
     if err != nil {
         return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid request ID", err.Error()))
@@ -84,18 +121,26 @@ func (lc *LoanController) ApproveLoanRequest(ctx echo.Context) error {
     if body.Approve {
         loan, err := lc.Service.ApproveLoanRequest(uint(requestID))
         if err != nil {
+            var conflict *services.ConflictError
+            if errors.As(err, &conflict) {
+                return ctx.JSON(http.StatusConflict, domains.NewErrorResponse("409", "Loan request could not be approved", err.Error()))
+            }
             return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to approve loan request", err.Error()))
         }
 
         loanInfo := domains.LoanApprovalResponse{
             ID:        loan.ID,
             BookID:    loan.BookID,
+            CopyID:    loan.CopyID,
             UserID:    loan.UserID.String(),
             LoanDate:  loan.LoanDate.Format(time.RFC3339),
             DueDate:   loan.DueDate.Format(time.RFC3339),
             Returned:  false,
         }
 
+        recordAudit(ctx, "loan_request.approve", "loan_request", requestID,
+            map[string]interface{}{"status": "PENDING"},
+            map[string]interface{}{"status": "APPROVED", "loan_record_id": loan.ID, "copy_id": loan.CopyID, "due_date": loanInfo.DueDate})
         response := domains.NewSuccessResponseWithData("200", "Loan request approved", loanInfo)
         return ctx.JSON(http.StatusOK, response)
     } else {
@@ -105,6 +150,10 @@ func (lc *LoanController) ApproveLoanRequest(ctx echo.Context) error {
         }
 
         if err := lc.Service.RejectLoanRequest(uint(requestID), reason); err != nil {
+            var conflict *services.ConflictError
+            if errors.As(err, &conflict) {
+                return ctx.JSON(http.StatusConflict, domains.NewErrorResponse("409", "Loan request could not be rejected", err.Error()))
+            }
             return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to reject loan request", err.Error()))
         }
 
@@ -113,19 +162,16 @@ func (lc *LoanController) ApproveLoanRequest(ctx echo.Context) error {
             Status: "REJECTED",
             Reason: reason,
         }
+        recordAudit(ctx, "loan_request.reject", "loan_request", requestID,
+            map[string]interface{}{"status": "PENDING"},
+            map[string]interface{}{"status": "REJECTED", "reason": reason})
         response := domains.NewSuccessResponseWithData("200", "Loan request rejected", rejectionData)
         return ctx.JSON(http.StatusOK, response)
     }
 }
 
-// ReturnBook with Late Fee Handling
+// ReturnBook with Late Fee Handling. Requires loans:return.
 func (lc *LoanController) ReturnBook(ctx echo.Context) error {
-    // Check if the user is an admin
-    role := ctx.Get("role").(int)
-    if role != 1 {
-        return ctx.JSON(http.StatusForbidden, domains.NewErrorResponse("403", "Access denied", "Only admins can return books"))
-    }
-
     loanID, err := strconv.Atoi(ctx.Param("id"))
     if err != nil {
         return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid loan ID", err.Error()))
@@ -133,10 +179,14 @@ func (lc *LoanController) ReturnBook(ctx echo.Context) error {
 
     loan, lateFee, err := lc.Service.ReturnBook(uint(loanID))
     if err != nil {
+        var conflict *services.ConflictError
+        if errors.As(err, &conflict) {
+            return ctx.JSON(http.StatusConflict, domains.NewErrorResponse("409", "Book could not be returned", err.Error()))
+        }
         return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to return book", err.Error()))
     }
 
-    username, err := lc.Service.Repo.GetUsernameByUserID(loan.UserID)
+    username, err := lc.Service.GetBorrowerName(loan.UserID)
     if err != nil {
         return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to retrieve borrower username", err.Error()))
     }
@@ -149,29 +199,140 @@ func (lc *LoanController) ReturnBook(ctx echo.Context) error {
         LateFee:      lateFee,
     }
 
+    recordAudit(ctx, "loan.return", "loan", loan.ID,
+        map[string]interface{}{"returned": false},
+        map[string]interface{}{"returned": true, "return_date": loanRecord.ReturnDate, "late_fee": lateFee})
     response := domains.NewSuccessResponseWithData("200", "Book returned successfully", loanRecord)
     return ctx.JSON(http.StatusOK, response)
 }
 
-// GetAllLoanRequests retrieves all loan requests with borrower names.
+// RenewLoan extends the due date of a loan. Only the borrower or an admin may renew.
+func (lc *LoanController) RenewLoan(ctx echo.Context) error {
+    username := ctx.Get("username").(string)
+
+    loanID, err := strconv.Atoi(ctx.Param("id"))
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid loan ID", err.Error()))
+    }
+
+    loan, err := lc.Service.GetLoanRecord(uint(loanID))
+    if err != nil {
+        return ctx.JSON(http.StatusNotFound, domains.NewErrorResponse("404", "Loan not found", err.Error()))
+    }
+
+    if !hasPermission(ctx, models.PermLoansManage) {
+        userID, err := currentUserID(ctx)
+        if err != nil || userID != loan.UserID {
+            return ctx.JSON(http.StatusForbidden, domains.NewErrorResponse("403", "Access denied", "Only the borrower or an admin can renew this loan"))
+        }
+    }
+
+    previousDueDate := loan.DueDate.Format(time.RFC3339)
+    loan, policy, err := lc.Service.RenewLoan(uint(loanID), username)
+    if err != nil {
+        var conflict *services.ConflictError
+        if errors.As(err, &conflict) {
+            return ctx.JSON(http.StatusConflict, domains.NewErrorResponse("409", "Loan cannot be renewed", err.Error()))
+        }
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to renew loan", err.Error()))
+    }
+
+    history := make([]domains.LoanRenewalHistory, len(loan.Renewals))
+    for i, renewal := range loan.Renewals {
+        history[i] = domains.LoanRenewalHistory{
+            PreviousDueDate: renewal.PreviousDueDate.Format(time.RFC3339),
+            NewDueDate:      renewal.NewDueDate.Format(time.RFC3339),
+            RenewedBy:       renewal.RenewedBy,
+            RenewedAt:       renewal.RenewedAt.Format(time.RFC3339),
+        }
+    }
+
+    renewalData := domains.LoanRenewalResponse{
+        ID:                loan.ID,
+        BookID:            loan.BookID,
+        CopyID:            loan.CopyID,
+        DueDate:           loan.DueDate.Format(time.RFC3339),
+        RenewalCount:      loan.RenewalCount,
+        RemainingRenewals: policy.MaxRenewals - loan.RenewalCount,
+        History:           history,
+    }
+
+    recordAudit(ctx, "loan.renew", "loan", loan.ID,
+        map[string]interface{}{"due_date": previousDueDate, "renewal_count": loan.RenewalCount - 1},
+        map[string]interface{}{"due_date": renewalData.DueDate, "renewal_count": loan.RenewalCount})
+    response := domains.NewSuccessResponseWithData("200", "Loan renewed successfully", renewalData)
+    return ctx.JSON(http.StatusOK, response)
+}
+
+// CheckEligibility reports every reason that would block a loan request for a book
+func (lc *LoanController) CheckEligibility(ctx echo.Context) error {
+    bookID, err := strconv.Atoi(ctx.Param("book_id"))
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid book ID", err.Error()))
+    }
+
+    userID, err := currentUserID(ctx)
+    if err != nil {
+        return ctx.JSON(http.StatusUnauthorized, domains.NewErrorResponse("401", "Failed to resolve current user", err.Error()))
+    }
+
+    // Admin dapat memeriksa kelayakan anggota lain lewat query user_id
+    if other := ctx.QueryParam("user_id"); other != "" && other != userID.String() {
+        if !hasPermission(ctx, models.PermLoansManage) {
+            return ctx.JSON(http.StatusForbidden, domains.NewErrorResponse("403", "Access denied", "Only admins can check eligibility for other members"))
+        }
+        userID, err = uuid.Parse(other)
+        if err != nil {
+            return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid user UUID", err.Error()))
+        }
+    }
+
+    reasons, err := lc.Service.CheckEligibility(userID, bookID)
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        return ctx.JSON(http.StatusNotFound, domains.NewErrorResponse("404", "Book not found", err.Error()))
+    }
+    if err != nil {
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to check eligibility", err.Error()))
+    }
+
+    eligibility := domains.LoanEligibilityResponse{
+        BookID:   bookID,
+        UserID:   userID.String(),
+        Eligible: len(reasons) == 0,
+        Reasons:  reasons,
+    }
+    return ctx.JSON(http.StatusOK, domains.NewSuccessResponseWithData("200", "Eligibility checked successfully", eligibility))
+}
+
+// GetAllLoanRequests retrieves a page of loan requests with borrower names.
 func (lc *LoanController) GetAllLoanRequests(ctx echo.Context) error {
-    loanRequests, err := lc.Service.GetAllLoanRequests()
+    spec, err := query.Parse(ctx.QueryParams(), repository.LoanRequestQuerySchema)
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid list query", err.Error()))
+    }
+
+    loanRequests, page, err := lc.Service.GetAllLoanRequests(spec)
     if err != nil {
         return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to fetch loan requests", err.Error()))
     }
 
-    response := domains.NewSuccessResponseWithData("200", "Loan requests retrieved successfully", loanRequests)
+    response := domains.NewPaginatedResponse("200", "Loan requests retrieved successfully", loanRequests, buildPagination(ctx, spec, page))
     return ctx.JSON(http.StatusOK, response)
 }
 
-// GetAllLoanRecords retrieves all loan records with borrower and loan details.
+// GetAllLoanRecords retrieves a page of loan records with borrower and loan details.
 func (lc *LoanController) GetAllLoanRecords(ctx echo.Context) error {
-    loanRecords, err := lc.Service.GetAllLoanRecords()
+    spec, err := query.Parse(ctx.QueryParams(), repository.LoanRecordQuerySchema)
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid list query", err.Error()))
+    }
+
+    loanRecords, page, err := lc.Service.GetAllLoanRecords(spec)
     if err != nil {
         return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to retrieve loan records", err.Error()))
     }
 
-    response := domains.NewSuccessResponseWithData("200", "Loan records retrieved successfully", loanRecords)
+    response := domains.NewPaginatedResponse("200", "Loan records retrieved successfully", loanRecords, buildPagination(ctx, spec, page))
     return ctx.JSON(http.StatusOK, response)
 }
 
@@ -193,10 +354,47 @@ func (lc *LoanController) SearchLoansByUsername(ctx echo.Context) error {
     return ctx.JSON(http.StatusOK, response)
 }
 
+// GetMyLoans retrieves the loan records of the authenticated user.
+func (lc *LoanController) GetMyLoans(ctx echo.Context) error {
+    userID, err := currentUserID(ctx)
+    if err != nil {
+        return ctx.JSON(http.StatusUnauthorized, domains.NewErrorResponse("401", "Failed to resolve current user", err.Error()))
+    }
+
+    loans, err := lc.Service.GetLoansByUserID(userID)
+    if err != nil {
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to fetch loans", err.Error()))
+    }
+
+    responseData := domains.LoanSearchResponse{
+        Username: ctx.Get("username").(string),
+        Loans:    loans,
+    }
+    return ctx.JSON(http.StatusOK, domains.NewSuccessResponseWithData("200", "Loans retrieved successfully", responseData))
+}
+
+// GetMyLoanRequests retrieves the loan requests of the authenticated user.
+func (lc *LoanController) GetMyLoanRequests(ctx echo.Context) error {
+    userID, err := currentUserID(ctx)
+    if err != nil {
+        return ctx.JSON(http.StatusUnauthorized, domains.NewErrorResponse("401", "Failed to resolve current user", err.Error()))
+    }
+
+    requests, err := lc.Service.GetLoanRequestsByUserID(userID)
+    if err != nil {
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to fetch loan requests", err.Error()))
+    }
+
+    return ctx.JSON(http.StatusOK, domains.NewSuccessResponseWithData("200", "Loan requests retrieved successfully", requests))
+}
+
 // CancelLoanRequest handles loan request cancellation with a reason
 func (lc *LoanController) CancelLoanRequest(ctx echo.Context) error {
     // Validasi token melalui middleware JWT yang sudah ada
-    username := ctx.Get("username").(string)
+    actorID, err := currentUserID(ctx)
+    if err != nil {
+        return ctx.JSON(http.StatusUnauthorized, domains.NewErrorResponse("401", "Failed to resolve current user", err.Error()))
+    }
 
     requestID, err := strconv.Atoi(ctx.Param("id"))
     if err != nil {
@@ -215,18 +413,21 @@ func (lc *LoanController) CancelLoanRequest(ctx echo.Context) error {
         reason = "No specific reason provided"
     }
 
-    // Ambil data loan request berdasarkan ID dan verifikasi apakah username sesuai
-    loanRequest, err := lc.Service.Repo.GetLoanRequestByID(uint(requestID))
+    // Ambil data loan request berdasarkan ID; hanya peminjam atau admin (atas nama peminjam) yang boleh membatalkan
+    loanRequest, err := lc.Service.GetLoanRequest(uint(requestID))
     if err != nil {
         return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to retrieve loan request", err.Error()))
     }
 
-    borrowerUsername, err := lc.Service.Repo.GetUsernameByUserID(loanRequest.UserID)
-    if err != nil || borrowerUsername != username {
+    if loanRequest.UserID != actorID && !hasPermission(ctx, models.PermLoansManage) {
         return ctx.JSON(http.StatusUnauthorized, domains.NewErrorResponse("401", "Unauthorized to cancel this loan request", "User does not match loan borrower"))
     }
 
-    if err := lc.Service.CancelLoanRequest(uint(requestID), reason); err != nil {
+    if err := lc.Service.CancelLoanRequest(uint(requestID), reason, actorID); err != nil {
+        var conflict *services.ConflictError
+        if errors.As(err, &conflict) {
+            return ctx.JSON(http.StatusConflict, domains.NewErrorResponse("409", "Loan request could not be cancelled", err.Error()))
+        }
         return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to cancel loan request", err.Error()))
     }
 
@@ -235,6 +436,9 @@ func (lc *LoanController) CancelLoanRequest(ctx echo.Context) error {
         Status: "CANCELLED",
         Reason: reason,
     }
+    recordAudit(ctx, "loan_request.cancel", "loan_request", requestID,
+        map[string]interface{}{"status": loanRequest.Status},
+        map[string]interface{}{"status": "CANCELLED", "reason": reason})
     response := domains.NewSuccessResponseWithData("200", "Loan request cancelled", cancellationData)
     return ctx.JSON(http.StatusOK, response)
 }
@@ -0,0 +1,145 @@
+// controllers/notification_controller.go
+package controllers
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+    "time"
+    "auth-user-api/domains"
+    "auth-user-api/models"
+    "auth-user-api/query"
+    "auth-user-api/repository"
+    "auth-user-api/services"
+
+    "github.com/labstack/echo/v4"
+    "gorm.io/gorm"
+)
+
+type NotificationController struct {
+    Service *services.NotificationService
+}
+
+func NewNotificationController(service *services.NotificationService) *NotificationController {
+    return &NotificationController{Service: service}
+}
+
+// Helper function to build NotificationResponse from a notification model
+func buildNotificationResponse(n *models.Notification) domains.NotificationResponse {
+    response := domains.NotificationResponse{
+        ID:        n.ID,
+        Event:     n.Event,
+        Subject:   n.Subject,
+        Body:      n.Body,
+        Data:      n.Data,
+        Read:      n.ReadAt != nil,
+        CreatedAt: n.CreatedAt.Format(time.RFC3339),
+    }
+    if n.ReadAt != nil {
+        readAt := n.ReadAt.Format(time.RFC3339)
+        response.ReadAt = &readAt
+    }
+    return response
+}
+
+// GetMyNotifications lists the logged-in user's in-app notifications, newest first.
+// Filter ?read=false hanya menampilkan yang belum dibaca.
+func (nc *NotificationController) GetMyNotifications(ctx echo.Context) error {
+    userID, err := currentUserID(ctx)
+    if err != nil {
+        return ctx.JSON(http.StatusUnauthorized, domains.NewErrorResponse("401", "Failed to resolve current user", err.Error()))
+    }
+
+    spec, err := query.Parse(ctx.QueryParams(), repository.NotificationQuerySchema)
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid list query", err.Error()))
+    }
+
+    notifications, page, unread, err := nc.Service.GetInbox(userID, spec)
+    if err != nil {
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to retrieve notifications", err.Error()))
+    }
+
+    inbox := domains.NotificationInboxResponse{
+        Unread:        unread,
+        Notifications: make([]domains.NotificationResponse, len(notifications)),
+    }
+    for i, n := range notifications {
+        inbox.Notifications[i] = buildNotificationResponse(n)
+    }
+    return ctx.JSON(http.StatusOK, domains.NewPaginatedResponse("200", "Notifications retrieved successfully", inbox, buildPagination(ctx, spec, page)))
+}
+
+// MarkRead marks one of the user's notifications as read
+func (nc *NotificationController) MarkRead(ctx echo.Context) error {
+    userID, err := currentUserID(ctx)
+    if err != nil {
+        return ctx.JSON(http.StatusUnauthorized, domains.NewErrorResponse("401", "Failed to resolve current user", err.Error()))
+    }
+
+    id, err := strconv.Atoi(ctx.Param("id"))
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid notification ID", err.Error()))
+    }
+
+    notification, err := nc.Service.MarkRead(userID, uint(id))
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        return ctx.JSON(http.StatusNotFound, domains.NewErrorResponse("404", "Notification not found", err.Error()))
+    }
+    if err != nil {
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to mark notification as read", err.Error()))
+    }
+    return ctx.JSON(http.StatusOK, domains.NewSuccessResponseWithData("200", "Notification marked as read", buildNotificationResponse(notification)))
+}
+
+// MarkAllRead marks every unread notification of the user as read
+func (nc *NotificationController) MarkAllRead(ctx echo.Context) error {
+    userID, err := currentUserID(ctx)
+    if err != nil {
+        return ctx.JSON(http.StatusUnauthorized, domains.NewErrorResponse("401", "Failed to resolve current user", err.Error()))
+    }
+
+    count, err := nc.Service.MarkAllRead(userID)
+    if err != nil {
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to mark notifications as read", err.Error()))
+    }
+    return ctx.JSON(http.StatusOK, domains.NewSuccessResponseWithData("200", "Notifications marked as read", map[string]int64{"updated": count}))
+}
+
+// GetPreferences shows the user's notification language and the channels in use
+func (nc *NotificationController) GetPreferences(ctx echo.Context) error {
+    userID, err := currentUserID(ctx)
+    if err != nil {
+        return ctx.JSON(http.StatusUnauthorized, domains.NewErrorResponse("401", "Failed to resolve current user", err.Error()))
+    }
+
+    preferences, err := nc.Service.GetPreferences(userID)
+    if err != nil {
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to retrieve notification preferences", err.Error()))
+    }
+    return ctx.JSON(http.StatusOK, domains.NewSuccessResponseWithData("200", "Notification preferences retrieved successfully", preferences))
+}
+
+// UpdatePreferences changes the language notifications are written in ("id" atau "en")
+func (nc *NotificationController) UpdatePreferences(ctx echo.Context) error {
+    userID, err := currentUserID(ctx)
+    if err != nil {
+        return ctx.JSON(http.StatusUnauthorized, domains.NewErrorResponse("401", "Failed to resolve current user", err.Error()))
+    }
+
+    var body struct {
+        Locale string `json:"locale"`
+    }
+    if err := ctx.Bind(&body); err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Failed to parse request body", err.Error()))
+    }
+
+    preferences, err := nc.Service.UpdateLocale(userID, body.Locale)
+    if errors.Is(err, services.ErrUnsupportedLocale) {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid input", err.Error()))
+    }
+    if err != nil {
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to update notification preferences", err.Error()))
+    }
+    return ctx.JSON(http.StatusOK, domains.NewSuccessResponseWithData("200", "Notification preferences updated successfully", preferences))
+}
@@ -0,0 +1,63 @@
+// controllers/pagination.go
+package controllers
+
+import (
+    "net/url"
+    "strconv"
+    "auth-user-api/domains"
+    "auth-user-api/query"
+
+    "github.com/labstack/echo/v4"
+)
+
+// buildPagination converts a query.Page into the response block, with next/prev links
+// that keep the request's filters and sort. Link memakai cursor jika request memakai
+// cursor, selain itu memakai offset.
+func buildPagination(ctx echo.Context, spec *query.Spec, page *query.Page) *domains.Pagination {
+    pagination := &domains.Pagination{
+        Total:      page.Total,
+        Limit:      page.Limit,
+        Offset:     page.Offset,
+        NextCursor: page.NextCursor,
+        PrevCursor: page.PrevCursor,
+    }
+
+    link := func(cursor string, offset int) string {
+        params := url.Values{}
+        for key, vals := range ctx.QueryParams() {
+            if key != "cursor" && key != "offset" {
+                params[key] = vals
+            }
+        }
+        params.Set("limit", strconv.Itoa(page.Limit))
+        params.Set("sort", spec.SortParam())
+        if cursor != "" {
+            params.Set("cursor", cursor)
+        } else if offset > 0 {
+            params.Set("offset", strconv.Itoa(offset))
+        }
+        return ctx.Request().URL.Path + "?" + params.Encode()
+    }
+
+    if spec.Cursor != nil {
+        if page.NextCursor != "" {
+            pagination.Next = link(page.NextCursor, 0)
+        }
+        if page.PrevCursor != "" {
+            pagination.Prev = link(page.PrevCursor, 0)
+        }
+        return pagination
+    }
+
+    if page.HasNext {
+        pagination.Next = link("", page.Offset+page.Limit)
+    }
+    if page.HasPrev {
+        prev := page.Offset - page.Limit
+        if prev < 0 {
+            prev = 0
+        }
+        pagination.Prev = link("", prev)
+    }
+    return pagination
+}
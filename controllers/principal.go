@@ -0,0 +1,20 @@
+// controllers/principal.go
+package controllers
+
+import (
+    "github.com/google/uuid"
+    "github.com/labstack/echo/v4"
+)
+
+// currentUserID returns the authenticated user's ID set by JWTMiddleware
+func currentUserID(ctx echo.Context) (uuid.UUID, error) {
+    userID, _ := ctx.Get("user_id").(string)
+    return uuid.Parse(userID)
+}
+
+// hasPermission reports whether the authenticated user's role grants the permission.
+// Permissions are loaded into context by RBACMiddleware.Require.
+func hasPermission(ctx echo.Context, permission string) bool {
+    permissions, _ := ctx.Get("permissions").(map[string]bool)
+    return permissions[permission]
+}
@@ -5,6 +5,8 @@ import (
     "net/http"
     "strconv"
     "auth-user-api/models"
+    "auth-user-api/query"
+    "auth-user-api/repository"
     "auth-user-api/services"
     "auth-user-api/domains"
     "github.com/labstack/echo/v4"
@@ -38,6 +40,7 @@ func (c *PublisherController) CreatePublisher(ctx echo.Context) error {
         UpdatedAt: publisher.UpdatedAt.String(),
         DeletedAt: nil,
     }
+    recordAudit(ctx, "publisher.create", "publisher", publisher.ID, nil, data)
     response := domains.NewSuccessResponseWithData("200", "Publisher created successfully", data)
     return ctx.JSON(http.StatusOK, response)
 }
@@ -62,9 +65,14 @@ func (c *PublisherController) GetPublisherByID(ctx echo.Context) error {
     return ctx.JSON(http.StatusOK, response)
 }
 
-// GetAllPublishers retrieves all publishers
+// GetAllPublishers retrieves a page of publishers
 func (c *PublisherController) GetAllPublishers(ctx echo.Context) error {
-    publishers, err := c.service.GetAllPublishers()
+    spec, err := query.Parse(ctx.QueryParams(), repository.PublisherQuerySchema)
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid list query", err.Error()))
+    }
+
+    publishers, page, err := c.service.GetAllPublishers(spec)
     if err != nil {
         response := domains.NewErrorResponse("500", "Failed to retrieve publishers", err.Error())
         return ctx.JSON(http.StatusInternalServerError, response)
@@ -80,7 +88,7 @@ func (c *PublisherController) GetAllPublishers(ctx echo.Context) error {
             DeletedAt: nil,
         }
     }
-    response := domains.NewSuccessResponseWithData("200", "Publishers retrieved successfully", publisherData)
+    response := domains.NewPaginatedResponse("200", "Publishers retrieved successfully", publisherData, buildPagination(ctx, spec, page))
     return ctx.JSON(http.StatusOK, response)
 }
 
@@ -92,6 +100,12 @@ func (c *PublisherController) UpdatePublisher(ctx echo.Context) error {
         response := domains.NewErrorResponse("404", "Publisher not found", err.Error())
         return ctx.JSON(http.StatusNotFound, response)
     }
+    before := domains.PublisherResponse{
+        ID:        publisher.ID,
+        Name:      publisher.Name,
+        CreatedAt: publisher.CreatedAt.String(),
+        UpdatedAt: publisher.UpdatedAt.String(),
+    }
 
     if err := ctx.Bind(publisher); err != nil {
         response := domains.NewErrorResponse("400", "Invalid input", err.Error())
@@ -110,6 +124,7 @@ func (c *PublisherController) UpdatePublisher(ctx echo.Context) error {
         UpdatedAt: publisher.UpdatedAt.String(),
         DeletedAt: nil,
     }
+    recordAudit(ctx, "publisher.update", "publisher", publisher.ID, before, data)
     response := domains.NewSuccessResponseWithData("200", "Publisher updated successfully", data)
     return ctx.JSON(http.StatusOK, response)
 }
@@ -117,10 +132,20 @@ func (c *PublisherController) UpdatePublisher(ctx echo.Context) error {
 // DeletePublisher handles deleting a publisher by ID
 func (c *PublisherController) DeletePublisher(ctx echo.Context) error {
     id, _ := strconv.Atoi(ctx.Param("id"))
+    var before interface{}
+    if publisher, err := c.service.GetPublisherByID(id); err == nil {
+        before = domains.PublisherResponse{
+            ID:        publisher.ID,
+            Name:      publisher.Name,
+            CreatedAt: publisher.CreatedAt.String(),
+            UpdatedAt: publisher.UpdatedAt.String(),
+        }
+    }
     if err := c.service.DeletePublisher(id); err != nil {
         response := domains.NewErrorResponse("404", "Failed to delete publisher", err.Error())
         return ctx.JSON(http.StatusNotFound, response)
     }
+    recordAudit(ctx, "publisher.delete", "publisher", id, before, nil)
 
     data := domains.DeleteResponse{UserID: strconv.Itoa(id)}
     response := domains.NewSuccessResponseWithData("200", "Publisher deleted successfully", data)
@@ -0,0 +1,89 @@
+// controllers/role_controller.go
+package controllers
+
+import (
+    "errors"
+    "net/http"
+    "sort"
+    "strconv"
+    "auth-user-api/domains"
+    "auth-user-api/models"
+    "auth-user-api/services"
+    "github.com/labstack/echo/v4"
+    "gorm.io/gorm"
+)
+
+type RoleController struct {
+    service services.RoleService
+}
+
+func NewRoleController(service services.RoleService) *RoleController {
+    return &RoleController{service}
+}
+
+// GetAllRoles lists every role with its permissions
+func (c *RoleController) GetAllRoles(ctx echo.Context) error {
+    roles, err := c.service.GetAllRoles()
+    if err != nil {
+        response := domains.NewErrorResponse("500", "Failed to retrieve roles", err.Error())
+        return ctx.JSON(http.StatusInternalServerError, response)
+    }
+
+    response := domains.NewSuccessResponseWithData("200", "Roles retrieved successfully", roles)
+    return ctx.JSON(http.StatusOK, response)
+}
+
+// UpdateRolePermissions replaces the permission set of a role
+func (c *RoleController) UpdateRolePermissions(ctx echo.Context) error {
+    id, err := strconv.Atoi(ctx.Param("id"))
+    if err != nil {
+        response := domains.NewErrorResponse("400", "Invalid role ID", err.Error())
+        return ctx.JSON(http.StatusBadRequest, response)
+    }
+
+    var body struct {
+        Permissions []string `json:"permissions"`
+    }
+    if err := ctx.Bind(&body); err != nil {
+        response := domains.NewErrorResponse("400", "Invalid input", err.Error())
+        return ctx.JSON(http.StatusBadRequest, response)
+    }
+
+    // Permission lama untuk audit log; role yang tidak ada ditangani oleh UpdateRolePermissions
+    var before []string
+    if roles, err := c.service.GetAllRoles(); err == nil {
+        for _, role := range roles {
+            if role.ID == id {
+                before = rolePermissionNames(role)
+            }
+        }
+    }
+
+    role, err := c.service.UpdateRolePermissions(id, body.Permissions)
+    if err != nil {
+        switch {
+        case errors.Is(err, gorm.ErrRecordNotFound):
+            return ctx.JSON(http.StatusNotFound, domains.NewErrorResponse("404", "Role not found", err.Error()))
+        case errors.Is(err, services.ErrUnknownPermission):
+            return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid permission", err.Error()))
+        }
+        response := domains.NewErrorResponse("500", "Failed to update role permissions", err.Error())
+        return ctx.JSON(http.StatusInternalServerError, response)
+    }
+
+    recordAudit(ctx, "role.update_permissions", "role", role.ID,
+        map[string]interface{}{"permissions": before},
+        map[string]interface{}{"permissions": rolePermissionNames(role)})
+    response := domains.NewSuccessResponseWithData("200", "Role permissions updated successfully", role)
+    return ctx.JSON(http.StatusOK, response)
+}
+
+// rolePermissionNames returns the sorted permission names of a role
+func rolePermissionNames(role *models.Role) []string {
+    names := make([]string, len(role.Permissions))
+    for i, permission := range role.Permissions {
+        names[i] = permission.Permission
+    }
+    sort.Strings(names)
+    return names
+}
@@ -0,0 +1,185 @@
+// controllers/trash_controller.go
+package controllers
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+    "time"
+    "auth-user-api/domains"
+    "auth-user-api/query"
+    "auth-user-api/repository"
+    "auth-user-api/services"
+    "github.com/labstack/echo/v4"
+)
+
+type TrashController struct {
+    service *services.TrashService
+}
+
+func NewTrashController(service *services.TrashService) *TrashController {
+    return &TrashController{service}
+}
+
+func (c *TrashController) buildTrashItem(id, name string, deletedAt time.Time) domains.TrashItemResponse {
+    return domains.TrashItemResponse{
+        ID:        id,
+        Name:      name,
+        DeletedAt: deletedAt.Format(time.RFC3339),
+        PurgeAt:   c.service.PurgeAt(deletedAt).Format(time.RFC3339),
+    }
+}
+
+// GetTrashedBooks lists soft-deleted books, most recently deleted first
+func (c *TrashController) GetTrashedBooks(ctx echo.Context) error {
+    spec, err := query.Parse(ctx.QueryParams(), repository.TrashedBookQuerySchema)
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid list query", err.Error()))
+    }
+
+    books, page, err := c.service.GetTrashedBooks(spec)
+    if err != nil {
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to retrieve deleted books", err.Error()))
+    }
+
+    items := make([]domains.TrashItemResponse, len(books))
+    for i, book := range books {
+        items[i] = c.buildTrashItem(strconv.Itoa(book.ID), book.Title, book.DeletedAt.Time)
+    }
+    response := domains.NewPaginatedResponse("200", "Deleted books retrieved successfully", items, buildPagination(ctx, spec, page))
+    return ctx.JSON(http.StatusOK, response)
+}
+
+// GetTrashedAuthors lists soft-deleted authors, most recently deleted first
+func (c *TrashController) GetTrashedAuthors(ctx echo.Context) error {
+    spec, err := query.Parse(ctx.QueryParams(), repository.TrashedAuthorQuerySchema)
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid list query", err.Error()))
+    }
+
+    authors, page, err := c.service.GetTrashedAuthors(spec)
+    if err != nil {
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to retrieve deleted authors", err.Error()))
+    }
+
+    items := make([]domains.TrashItemResponse, len(authors))
+    for i, author := range authors {
+        items[i] = c.buildTrashItem(strconv.Itoa(author.ID), author.Name, author.DeletedAt.Time)
+    }
+    response := domains.NewPaginatedResponse("200", "Deleted authors retrieved successfully", items, buildPagination(ctx, spec, page))
+    return ctx.JSON(http.StatusOK, response)
+}
+
+// GetTrashedPublishers lists soft-deleted publishers, most recently deleted first
+func (c *TrashController) GetTrashedPublishers(ctx echo.Context) error {
+    spec, err := query.Parse(ctx.QueryParams(), repository.TrashedPublisherQuerySchema)
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid list query", err.Error()))
+    }
+
+    publishers, page, err := c.service.GetTrashedPublishers(spec)
+    if err != nil {
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to retrieve deleted publishers", err.Error()))
+    }
+
+    items := make([]domains.TrashItemResponse, len(publishers))
+    for i, publisher := range publishers {
+        items[i] = c.buildTrashItem(strconv.Itoa(publisher.ID), publisher.Name, publisher.DeletedAt.Time)
+    }
+    response := domains.NewPaginatedResponse("200", "Deleted publishers retrieved successfully", items, buildPagination(ctx, spec, page))
+    return ctx.JSON(http.StatusOK, response)
+}
+
+// GetTrashedUsers lists soft-deleted users, most recently deleted first
+func (c *TrashController) GetTrashedUsers(ctx echo.Context) error {
+    spec, err := query.Parse(ctx.QueryParams(), repository.TrashedUserQuerySchema)
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid list query", err.Error()))
+    }
+
+    users, page, err := c.service.GetTrashedUsers(spec)
+    if err != nil {
+        return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to retrieve deleted users", err.Error()))
+    }
+
+    items := make([]domains.TrashItemResponse, len(users))
+    for i, user := range users {
+        items[i] = c.buildTrashItem(user.ID, user.Username, user.DeletedAt.Time)
+    }
+    response := domains.NewPaginatedResponse("200", "Deleted users retrieved successfully", items, buildPagination(ctx, spec, page))
+    return ctx.JSON(http.StatusOK, response)
+}
+
+// RestoreBook moves a book out of the trash
+func (c *TrashController) RestoreBook(ctx echo.Context) error {
+    id, err := strconv.Atoi(ctx.Param("id"))
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid book ID", err.Error()))
+    }
+
+    book, err := c.service.RestoreBook(id)
+    if err != nil {
+        return trashErrorResponse(ctx, "Failed to restore book", err)
+    }
+
+    recordAudit(ctx, "book.restore", "book", book.ID, map[string]interface{}{"deleted": true}, map[string]interface{}{"deleted": false})
+    response := domains.NewSuccessResponseWithData("200", "Book restored successfully", map[string]interface{}{"id": book.ID})
+    return ctx.JSON(http.StatusOK, response)
+}
+
+// RestoreAuthor moves an author out of the trash
+func (c *TrashController) RestoreAuthor(ctx echo.Context) error {
+    id, err := strconv.Atoi(ctx.Param("id"))
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid author ID", err.Error()))
+    }
+
+    author, err := c.service.RestoreAuthor(id)
+    if err != nil {
+        return trashErrorResponse(ctx, "Failed to restore author", err)
+    }
+
+    recordAudit(ctx, "author.restore", "author", author.ID, map[string]interface{}{"deleted": true}, map[string]interface{}{"deleted": false})
+    response := domains.NewSuccessResponseWithData("200", "Author restored successfully", map[string]interface{}{"id": author.ID})
+    return ctx.JSON(http.StatusOK, response)
+}
+
+// RestorePublisher moves a publisher out of the trash
+func (c *TrashController) RestorePublisher(ctx echo.Context) error {
+    id, err := strconv.Atoi(ctx.Param("id"))
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid publisher ID", err.Error()))
+    }
+
+    publisher, err := c.service.RestorePublisher(id)
+    if err != nil {
+        return trashErrorResponse(ctx, "Failed to restore publisher", err)
+    }
+
+    recordAudit(ctx, "publisher.restore", "publisher", publisher.ID, map[string]interface{}{"deleted": true}, map[string]interface{}{"deleted": false})
+    response := domains.NewSuccessResponseWithData("200", "Publisher restored successfully", map[string]interface{}{"id": publisher.ID})
+    return ctx.JSON(http.StatusOK, response)
+}
+
+// RestoreUser moves a user out of the trash if their username and email are still free
+func (c *TrashController) RestoreUser(ctx echo.Context) error {
+    user, err := c.service.RestoreUser(ctx.Param("id"))
+    if err != nil {
+        return trashErrorResponse(ctx, "Failed to restore user", err)
+    }
+
+    recordAudit(ctx, "user.restore", "user", user.ID, map[string]interface{}{"deleted": true}, map[string]interface{}{"deleted": false})
+    response := domains.NewSuccessResponseWithData("200", "User restored successfully", domains.DeleteResponse{UserID: user.ID})
+    return ctx.JSON(http.StatusOK, response)
+}
+
+func trashErrorResponse(ctx echo.Context, message string, err error) error {
+    var conflict *services.ConflictError
+    switch {
+    case errors.Is(err, services.ErrNotInTrash):
+        return ctx.JSON(http.StatusNotFound, domains.NewErrorResponse("404", message, err.Error()))
+    case errors.As(err, &conflict):
+        return ctx.JSON(http.StatusConflict, domains.NewErrorResponse("409", message, err.Error()))
+    }
+    return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", message, err.Error()))
+}
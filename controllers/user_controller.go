@@ -3,9 +3,11 @@
 package controllers
 
 import (
+    "errors"
     "net/http"
-    "time"
-    "github.com/golang-jwt/jwt/v4"
+    "auth-user-api/models"
+    "auth-user-api/query"
+    "auth-user-api/repository"
     "auth-user-api/services"
     "auth-user-api/domains"
     "github.com/labstack/echo/v4"
@@ -13,20 +15,31 @@ import (
 
 type UserController struct {
     service services.UserService
+    invites services.InviteService
 }
 
-func NewUserController(service services.UserService) *UserController {
-    return &UserController{service}
+func NewUserController(service services.UserService, invites services.InviteService) *UserController {
+    return &UserController{service, invites}
+}
+
+// roleName converts a role ID to the label used in responses
+func roleName(role int) string {
+    if role == 1 {
+        return "admin"
+    }
+    return "member"
 }
 
 // Register User godoc
+// Registrasi publik selalu membuat member; role lain hanya lewat invite_code dari admin.
 func (c *UserController) RegisterUser(ctx echo.Context) error {
     type RegisterRequest struct {
-        Username  string `json:"username" validate:"required"`
-        Email     string `json:"email" validate:"required,email"`
-        Password1 string `json:"password_1" validate:"required"`
-        Password2 string `json:"password_2" validate:"required"`
-        Role      int    `json:"role" validate:"required,oneof=1 2"` // 1 for admin, 2 for member
+        Username   string `json:"username" validate:"required"`
+        Email      string `json:"email" validate:"required,email"`
+        Password1  string `json:"password_1" validate:"required"`
+        Password2  string `json:"password_2" validate:"required"`
+        Role       int    `json:"role"`        // Deprecated: hanya 2 (member) yang diterima tanpa undangan
+        InviteCode string `json:"invite_code"` // Kode undangan dari admin atau bootstrap token
     }
 
     var req RegisterRequest
@@ -39,14 +52,13 @@ func (c *UserController) RegisterUser(ctx echo.Context) error {
         return ctx.JSON(http.StatusBadRequest, response)
     }
 
-    // Perform additional validation if necessary
-    if req.Role != 1 && req.Role != 2 {
+    if req.InviteCode == "" && req.Role != 0 && req.Role != 2 {
         response := domains.BaseResponse{
-            Code:    "400",
-            Message: "Role must be 1 (admin) or 2 (member)",
-            Error:   "Validation error",
+            Code:    "403",
+            Message: "Self-registration always creates a member account; use an invite code for other roles",
+            Error:   "Role not allowed",
         }
-        return ctx.JSON(http.StatusBadRequest, response)
+        return ctx.JSON(http.StatusForbidden, response)
     }
 
     if err := ctx.Validate(req); err != nil {
@@ -58,8 +70,24 @@ func (c *UserController) RegisterUser(ctx echo.Context) error {
         return ctx.JSON(http.StatusBadRequest, response)
     }
 
-    // Register the user with the role
-    if err := c.service.Register(req.Username, req.Email, req.Password1, req.Password2, req.Role); err != nil {
+    role := 2
+    if req.InviteCode != "" {
+        user, err := c.invites.RegisterWithInvite(req.InviteCode, req.Username, req.Email, req.Password1, req.Password2)
+        if err != nil {
+            switch {
+            case errors.Is(err, services.ErrInvalidInvite), errors.Is(err, services.ErrInviteUnavailable),
+                errors.Is(err, services.ErrInviteEmailMismatch), errors.Is(err, services.ErrBootstrapClosed):
+                return ctx.JSON(http.StatusForbidden, domains.NewErrorResponse("403", "Invite code rejected", err.Error()))
+            }
+            response := domains.BaseResponse{
+                Code:    "400",
+                Message: "Registration failed. Error: " + err.Error(),
+                Error:   "Service error: " + err.Error(),
+            }
+            return ctx.JSON(http.StatusBadRequest, response)
+        }
+        role = user.Role
+    } else if err := c.service.Register(req.Username, req.Email, req.Password1, req.Password2, role); err != nil {
         response := domains.BaseResponse{
             Code:    "400",
             Message: "Registration failed. Error: " + err.Error(),
@@ -71,15 +99,9 @@ func (c *UserController) RegisterUser(ctx echo.Context) error {
     userResponse := domains.RegisterResponse{
         Username: req.Username,
         Email:    req.Email,
-        Role:     "", // Temporary empty, will assign below
-    }
-    
-    if req.Role == 1 {
-        userResponse.Role = "admin"
-    } else if req.Role == 2 {
-        userResponse.Role = "member"
+        Role:     roleName(role),
     }
-    
+
     response := domains.BaseResponse{
         Code:      "200",
         Message:   "User successfully registered",
@@ -89,9 +111,50 @@ func (c *UserController) RegisterUser(ctx echo.Context) error {
     return ctx.JSON(http.StatusOK, response)
 }
 
-// GetAllUsers retrieves all users with roles in string format (admin/member)
+// CreateUser lets an admin create an account with any role
+func (c *UserController) CreateUser(ctx echo.Context) error {
+    type CreateUserRequest struct {
+        Username  string `json:"username" validate:"required"`
+        Email     string `json:"email" validate:"required,email"`
+        Password1 string `json:"password_1" validate:"required"`
+        Password2 string `json:"password_2" validate:"required"`
+        Role      int    `json:"role" validate:"required,oneof=1 2"` // 1 for admin, 2 for member
+    }
+
+    var req CreateUserRequest
+    if err := ctx.Bind(&req); err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid input", err.Error()))
+    }
+    if err := ctx.Validate(req); err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Validation error", err.Error()))
+    }
+
+    if err := c.service.Register(req.Username, req.Email, req.Password1, req.Password2, req.Role); err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Failed to create user", err.Error()))
+    }
+
+    userResponse := domains.RegisterResponse{
+        Username: req.Username,
+        Email:    req.Email,
+        Role:     roleName(req.Role),
+    }
+    var createdID string
+    if user, err := c.service.GetUserByUsername(req.Username); err == nil {
+        createdID = user.ID
+    }
+    recordAudit(ctx, "user.create", "user", createdID, nil, userResponse)
+    response := domains.NewSuccessResponseWithData("201", "User created successfully", userResponse)
+    return ctx.JSON(http.StatusCreated, response)
+}
+
+// GetAllUsers retrieves a page of users with roles in string format (admin/member)
 func (c *UserController) GetAllUsers(ctx echo.Context) error {
-    users, err := c.service.GetAllUsers()
+    spec, err := query.Parse(ctx.QueryParams(), repository.UserQuerySchema)
+    if err != nil {
+        return ctx.JSON(http.StatusBadRequest, domains.NewErrorResponse("400", "Invalid list query", err.Error()))
+    }
+
+    users, page, err := c.service.GetAllUsers(spec)
     if err != nil {
         response := domains.BaseResponse{
             Code:    "500",
@@ -119,9 +182,10 @@ func (c *UserController) GetAllUsers(ctx echo.Context) error {
     }
 
     response := domains.BaseResponse{
-        Code:    "200",
-        Message: "Users retrieved successfully",
-        Data:    userResponses,
+        Code:       "200",
+        Message:    "Users retrieved successfully",
+        Data:       userResponses,
+        Pagination: buildPagination(ctx, spec, page),
     }
     return ctx.JSON(http.StatusOK, response)
 }
@@ -145,6 +209,17 @@ func (c *UserController) UpdateUser(ctx echo.Context) error {
         return ctx.JSON(http.StatusBadRequest, response)
     }
 
+    // Anggota hanya boleh mengubah datanya sendiri kecuali punya permission users:admin
+    currentID, _ := ctx.Get("user_id").(string)
+    if currentID != userID && !hasPermission(ctx, models.PermUsersAdmin) {
+        response := domains.BaseResponse{
+            Code:    "403",
+            Message: "Access denied. UserID: " + userID,
+            Error:   "Missing permission: " + models.PermUsersAdmin,
+        }
+        return ctx.JSON(http.StatusForbidden, response)
+    }
+
     existingUser, err := c.service.GetUserByID(userID)
     if err != nil {
         response := domains.BaseResponse{
@@ -175,6 +250,13 @@ func (c *UserController) UpdateUser(ctx echo.Context) error {
         return ctx.JSON(http.StatusBadRequest, response)
     }
 
+    // Password tidak pernah disimpan di audit log; hanya fakta bahwa ia diganti
+    after := map[string]interface{}{"username": req.Username, "email": req.Email}
+    if req.Password1 != "" {
+        after["password"] = "changed"
+    }
+    recordAudit(ctx, "user.update", "user", userID, map[string]interface{}{"username": existingUser.Username, "email": existingUser.Email}, after)
+
     userResponse := domains.UserResponse{
         UserID:   existingUser.ID,
         Username: req.Username,
@@ -242,6 +324,11 @@ func (c *UserController) DeleteUser(ctx echo.Context) error {
         }
         return ctx.JSON(http.StatusBadRequest, response)
     }
+    recordAudit(ctx, "user.delete", "user", req.UserID, domains.RegisterResponse{
+        Username: user.Username,
+        Email:    user.Email,
+        Role:     roleName(user.Role),
+    }, nil)
 
     response := domains.BaseResponse{
         Code:      "200",
@@ -252,75 +339,6 @@ func (c *UserController) DeleteUser(ctx echo.Context) error {
     return ctx.JSON(http.StatusOK, response)
 }
 
-var jwtKey = []byte("my_secret_key")  // Pastikan menggunakan secret key yang sama
-
-type JWTClaims struct {
-    Username string `json:"username"`
-    Role     int    `json:"role"`
-    jwt.RegisteredClaims
-}
-
-// Login User
-func (c *UserController) LoginUser(ctx echo.Context) error {
-    type LoginRequest struct {
-        Username string `json:"username" validate:"required"`
-        Password string `json:"password" validate:"required"`
-    }
-
-    var req LoginRequest
-    if err := ctx.Bind(&req); err != nil {
-        return ctx.JSON(http.StatusBadRequest, domains.BaseResponse{
-            Code:    "400",
-            Message: "Invalid input",
-            Error:   err.Error(),
-        })
-    }
-
-    if err := ctx.Validate(req); err != nil {
-        return ctx.JSON(http.StatusBadRequest, domains.BaseResponse{
-            Code:    "400",
-            Message: "Validation error",
-            Error:   err.Error(),
-        })
-    }
-
-    user, err := c.service.Authenticate(req.Username, req.Password)
-    if err != nil {
-        return ctx.JSON(http.StatusUnauthorized, domains.BaseResponse{
-            Code:    "401",
-            Message: "Invalid username or password",
-            Error:   "AuthenticationError",
-        })
-    }
-    
-    expirationTime := time.Now().Add(24 * time.Hour)
-    claims := &JWTClaims{
-        Username: user.Username,
-        Role:     user.Role, // Ambil role dari user yang berhasil diotentikasi
-        RegisteredClaims: jwt.RegisteredClaims{
-            ExpiresAt: jwt.NewNumericDate(expirationTime),
-        },
-    }
-    
-    token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-    tokenString, err := token.SignedString(jwtKey)
-    if err != nil {
-        return ctx.JSON(http.StatusInternalServerError, domains.BaseResponse{
-            Code:    "500",
-            Message: "Failed to generate token",
-            Error:   err.Error(),
-        })
-    }
-    
-    return ctx.JSON(http.StatusOK, domains.BaseResponse{
-        Code:    "200",
-        Message: "Successful login",
-        Data: map[string]interface{}{
-            "token": tokenString,
-        },
-    })
-}    
-
 // Route yang diproteksi
 func (c *UserController) HelloProtected(ctx echo.Context) error {
     username := ctx.Get("username").(string)
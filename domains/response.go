@@ -3,11 +3,23 @@ package domains
 
 // BaseResponse is the general structure for all API responses
 type BaseResponse struct {
-    Code      string      `json:"code"`                 // HTTP response code
-    Message   string      `json:"message"`              // Response message
-    Data      interface{} `json:"data,omitempty"`       // Data payload (optional)
-    Error     string      `json:"error,omitempty"`      // Error details (optional)
-    Parameter string      `json:"parameter,omitempty"`  // Related parameter (optional)
+    Code       string      `json:"code"`                 // HTTP response code
+    Message    string      `json:"message"`              // Response message
+    Data       interface{} `json:"data,omitempty"`       // Data payload (optional)
+    Error      string      `json:"error,omitempty"`      // Error details (optional)
+    Parameter  string      `json:"parameter,omitempty"`  // Related parameter (optional)
+    Pagination *Pagination `json:"pagination,omitempty"` // Page info for list endpoints (optional)
+}
+
+// Pagination describes which part of a filtered list a response holds
+type Pagination struct {
+    Total      int64  `json:"total"`                 // Number of rows matching the filters
+    Limit      int    `json:"limit"`
+    Offset     int    `json:"offset"`
+    NextCursor string `json:"next_cursor,omitempty"` // Opaque cursor for the following page
+    PrevCursor string `json:"prev_cursor,omitempty"` // Opaque cursor for the preceding page
+    Next       string `json:"next,omitempty"`        // Link to the following page
+    Prev       string `json:"prev,omitempty"`        // Link to the preceding page
 }
 
 // FormatError sets Error to "null" if it's an empty string
@@ -17,9 +29,12 @@ func (r *BaseResponse) FormatError() {
     }
 }
 
-// TokenResponse represents a response with a token
+// TokenResponse represents a response with an access and refresh token pair
 type TokenResponse struct {
-    Token string `json:"token"`  // JWT token string
+    Token            string `json:"token"`              // JWT access token string
+    ExpiresAt        string `json:"expires_at"`         // Access token expiry (RFC3339)
+    RefreshToken     string `json:"refresh_token"`      // Opaque refresh token, rotated on every use
+    RefreshExpiresAt string `json:"refresh_expires_at"` // Refresh token expiry (RFC3339)
 }
 
 // UserResponse represents the user details in the response
@@ -54,18 +69,38 @@ type ErrorResponse struct {
 }
 
 type BookResponse struct {
-	ID          int               `json:"id"`
-	Title       string            `json:"title"`
-	Summary     string            `json:"summary"`
-	AuthorID    int               `json:"author_id"`
-	Author      BookAuthorResponse    `json:"Author"`
-	PublisherID int               `json:"publisher_id"`
-	Publisher   BookPublisherResponse `json:"Publisher"`
-	Stock       int               `json:"stock"`
-	MaxStock    int               `json:"max_stock"`
-	CreatedAt   string            `json:"CreatedAt"`
-	UpdatedAt   string            `json:"UpdatedAt"`
-	DeletedAt   *string           `json:"DeletedAt,omitempty"`
+	ID              int                       `json:"id"`
+	Title           string                    `json:"title"`
+	Summary         string                    `json:"summary"`
+	Category        string                    `json:"category"`
+	ISBN            *string                   `json:"isbn"`
+	PublicationYear *int                      `json:"publication_year"`
+	Edition         string                    `json:"edition"`
+	Language        string                    `json:"language"`
+	PageCount       *int                      `json:"page_count"`
+	Subjects        []string                  `json:"subjects"`
+	CoverImageURL   string                    `json:"cover_image_url"`
+	AuthorID        int                       `json:"author_id"`
+	Author          BookAuthorResponse        `json:"Author"`
+	Contributors    []BookContributorResponse `json:"contributors"`
+	PublisherID     int                       `json:"publisher_id"`
+	Publisher       BookPublisherResponse     `json:"Publisher"`
+	Stock           int                       `json:"stock"`
+	MaxStock        int                       `json:"max_stock"`
+	CreatedAt       string                    `json:"CreatedAt"`
+	UpdatedAt       string                    `json:"UpdatedAt"`
+	DeletedAt       *string                   `json:"DeletedAt,omitempty"`
+}
+
+// BookCopyResponse represents a single physical copy of a book
+type BookCopyResponse struct {
+    ID              uint   `json:"id"`
+    BookID          int    `json:"book_id"`
+    Barcode         string `json:"barcode"`
+    AcquisitionDate string `json:"acquisition_date"`
+    Condition       string `json:"condition"`
+    ShelfLocation   string `json:"shelf_location"`
+    Status          string `json:"status"`
 }
 
 type BookAuthorResponse struct {
@@ -76,13 +111,29 @@ type BookPublisherResponse struct {
     Name string `json:"name"`
 }
 
+// BookContributorResponse is one author of a book together with their role
+type BookContributorResponse struct {
+    AuthorID int    `json:"author_id"`
+    Name     string `json:"name"`
+    Role     string `json:"role"` // author, editor, translator atau illustrator
+    Position int    `json:"position"`
+}
+
+// AuthorBookResponse is a book an author contributed to
+type AuthorBookResponse struct {
+    BookID int    `json:"book_id"`
+    Title  string `json:"title"`
+    Role   string `json:"role"`
+}
+
 // AuthorResponse is the struct for author data response.
 type AuthorResponse struct {
-    ID        int     `json:"id"`
-    Name      string  `json:"name"`
-    CreatedAt string  `json:"CreatedAt"`
-    UpdatedAt string  `json:"UpdatedAt"`
-    DeletedAt *string `json:"DeletedAt,omitempty"`
+    ID        int                  `json:"id"`
+    Name      string               `json:"name"`
+    Books     []AuthorBookResponse `json:"books"`
+    CreatedAt string               `json:"CreatedAt"`
+    UpdatedAt string               `json:"UpdatedAt"`
+    DeletedAt *string              `json:"DeletedAt,omitempty"`
 }
 
 // PublisherResponse struct for publisher data response
@@ -115,6 +166,15 @@ func NewSuccessResponseWithData(code, message string, data interface{}) BaseResp
     }
 }
 
+func NewPaginatedResponse(code, message string, data interface{}, pagination *Pagination) BaseResponse {
+    return BaseResponse{
+        Code:       code,
+        Message:    message,
+        Data:       data,
+        Pagination: pagination,
+    }
+}
+
 func NewSuccessResponse(code, message string) BaseResponse {
     return BaseResponse{
         Code:    code,
@@ -124,17 +184,19 @@ func NewSuccessResponse(code, message string) BaseResponse {
 
 // LoanRequestResponse represents the structure for loan request responses
 type LoanRequestResponse struct {
-    ID           uint   `json:"id"`
-    BookID       int    `json:"book_id"`
-    UserID       string `json:"user_id"`
-    BorrowerName string `json:"borrower_name"`
-    Status       string `json:"status"`
-    RequestDate  string `json:"request_date"`
+    ID           uint    `json:"id"`
+    BookID       int     `json:"book_id"`
+    UserID       string  `json:"user_id"`
+    BorrowerName string  `json:"borrower_name"`
+    Status       string  `json:"status"`
+    RequestDate  string  `json:"request_date"`
+    RequestedBy  *string `json:"requested_by,omitempty"` // Admin yang mengajukan atas nama peminjam
 }
 
 type LoanApprovalResponse struct {
     ID        uint   `json:"id"`
     BookID    int    `json:"book_id"`
+    CopyID    *uint  `json:"copy_id"`
     UserID    string `json:"user_id"`
     LoanDate  string `json:"loan_date"`
     DueDate   string `json:"due_date"`
@@ -157,6 +219,33 @@ type LoanReturnResponse struct {
     LateFee      int    `json:"late_fee"`
 }
 
+// LoanRenewalResponse represents the response when a loan is renewed
+type LoanRenewalResponse struct {
+    ID                uint                 `json:"id"`
+    BookID            int                  `json:"book_id"`
+    CopyID            *uint                `json:"copy_id"` // null untuk pinjaman lama tanpa eksemplar
+    DueDate           string               `json:"due_date"`
+    RenewalCount      int                  `json:"renewal_count"`
+    RemainingRenewals int                  `json:"remaining_renewals"`
+    History           []LoanRenewalHistory `json:"history"`
+}
+
+// LoanRenewalHistory is a single entry in a loan's renewal history
+type LoanRenewalHistory struct {
+    PreviousDueDate string `json:"previous_due_date"`
+    NewDueDate      string `json:"new_due_date"`
+    RenewedBy       string `json:"renewed_by"`
+    RenewedAt       string `json:"renewed_at"`
+}
+
+// LoanEligibilityResponse lists the reasons a member may not request a book
+type LoanEligibilityResponse struct {
+    BookID   int         `json:"book_id"`
+    UserID   string      `json:"user_id"`
+    Eligible bool        `json:"eligible"`
+    Reasons  interface{} `json:"reasons"`
+}
+
 // LoanSearchResponse represents the structure for searching loans by username
 type LoanSearchResponse struct {
     Username string      `json:"username"`
@@ -166,6 +255,7 @@ type LoanSearchResponse struct {
 type LoanRecordResponse struct {
     ID           uint   `json:"id"`
     BookID       int    `json:"book_id"`
+    CopyID       *uint  `json:"copy_id"` // null untuk pinjaman lama tanpa eksemplar
     UserID       string `json:"user_id"`
     BorrowerName string `json:"borrower_name"`
     LoanDate     string `json:"loan_date"`
@@ -210,4 +300,78 @@ type LoanCancellationResponse struct {
     ID     uint   `json:"id"`
     Status string `json:"status"`
     Reason string `json:"reason"`
-}
\ No newline at end of file
+}
+
+// HoldResponse represents a member's place in a book's reservation queue
+type HoldResponse struct {
+    ID        uint    `json:"id"`
+    BookID    int     `json:"book_id"`
+    UserID    string  `json:"user_id"`
+    Position  int     `json:"position"`
+    Status    string  `json:"status"`
+    CopyID    *uint   `json:"copy_id,omitempty"`
+    ExpiresAt *string `json:"expires_at,omitempty"`
+}
+
+// HoldQueueResponse represents the reservation queue of a book
+type HoldQueueResponse struct {
+    BookID      int         `json:"book_id"`
+    QueueLength int         `json:"queue_length"`
+    Holds       interface{} `json:"holds"`
+}
+
+// FineResponse represents a single fine on a member's account
+type FineResponse struct {
+    ID           uint    `json:"id"`
+    LoanRecordID *uint   `json:"loan_record_id,omitempty"`
+    Amount       int     `json:"amount"`
+    PaidAmount   int     `json:"paid_amount"`
+    WaivedAmount int     `json:"waived_amount"`
+    Balance      int     `json:"balance"`
+    Status       string  `json:"status"`
+    Reason       string  `json:"reason"`
+    WaiveReason  *string `json:"waive_reason,omitempty"`
+    CreatedAt    string  `json:"created_at"`
+}
+
+// FineAccountResponse represents a member's fines and outstanding balance
+type FineAccountResponse struct {
+    UserID  string         `json:"user_id"`
+    Balance int            `json:"balance"`
+    Fines   []FineResponse `json:"fines"`
+}
+
+// PaymentResponse represents the response after a payment is recorded
+type PaymentResponse struct {
+    ID         uint         `json:"id"`
+    Amount     int          `json:"amount"`
+    Method     string       `json:"method"`
+    ReceivedBy string       `json:"received_by"`
+    Fine       FineResponse `json:"fine"`
+}
+
+// NotificationResponse represents one message in a member's in-app inbox
+type NotificationResponse struct {
+    ID        uint                   `json:"id"`
+    Event     string                 `json:"event"`
+    Subject   string                 `json:"subject"`
+    Body      string                 `json:"body"`
+    Data      map[string]interface{} `json:"data"`
+    Read      bool                   `json:"read"`
+    ReadAt    *string                `json:"read_at,omitempty"`
+    CreatedAt string                 `json:"created_at"`
+}
+
+// NotificationInboxResponse represents one page of the inbox and the number of unread messages
+type NotificationInboxResponse struct {
+    Unread        int64                  `json:"unread"`
+    Notifications []NotificationResponse `json:"notifications"`
+}
+
+// TrashItemResponse represents a soft-deleted row that can still be restored
+type TrashItemResponse struct {
+    ID        string `json:"id"`
+    Name      string `json:"name"` // Judul buku, nama author/publisher atau username
+    DeletedAt string `json:"deleted_at"`
+    PurgeAt   string `json:"purge_at"` // Setelah waktu ini baris dihapus permanen jika tidak lagi dirujuk
+}
@@ -0,0 +1,73 @@
+// events/bus.go
+package events
+
+import (
+    "context"
+    "fmt"
+    "sync"
+)
+
+// Handler memproses satu event. Error berarti event dikirim ulang ke handler ini nanti
+// (at-least-once), jadi handler harus idempoten terhadap Envelope.ID.
+type Handler func(ctx context.Context, e *Envelope) error
+
+// Subscriber is a named handler for some or all event types
+type Subscriber struct {
+    Name    string
+    Types   map[string]bool // Kosong = semua tipe
+    Handler Handler
+}
+
+func (s *Subscriber) wants(eventType string) bool {
+    return len(s.Types) == 0 || s.Types[eventType]
+}
+
+// Bus menyimpan daftar subscriber in-process dan webhook. Bus tidak mengirim apa pun sendiri:
+// event dibaca dari outbox oleh dispatcher lalu diteruskan ke Subscribers.
+type Bus struct {
+    mu          sync.RWMutex
+    subscribers []*Subscriber
+}
+
+func NewBus() *Bus {
+    return &Bus{}
+}
+
+// Subscribe registers handler for the given event types (none = every type). Nama dicatat di
+// outbox untuk melacak subscriber mana yang sudah menerima event, jadi harus unik dan stabil.
+func (b *Bus) Subscribe(name string, handler Handler, types ...string) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    for _, s := range b.subscribers {
+        if s.Name == name {
+            panic(fmt.Sprintf("events: subscriber %q registered twice", name))
+        }
+    }
+    wanted := map[string]bool{}
+    for _, t := range types {
+        wanted[t] = true
+    }
+    b.subscribers = append(b.subscribers, &Subscriber{Name: name, Types: wanted, Handler: handler})
+}
+
+// Subscribers returns the subscribers interested in eventType, in registration order
+func (b *Bus) Subscribers(eventType string) []*Subscriber {
+    b.mu.RLock()
+    defer b.mu.RUnlock()
+
+    var matched []*Subscriber
+    for _, s := range b.subscribers {
+        if s.wants(eventType) {
+            matched = append(matched, s)
+        }
+    }
+    return matched
+}
+
+// Len returns the number of registered subscribers
+func (b *Bus) Len() int {
+    b.mu.RLock()
+    defer b.mu.RUnlock()
+    return len(b.subscribers)
+}
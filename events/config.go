@@ -0,0 +1,25 @@
+// events/config.go
+package events
+
+import (
+    "context"
+    "log"
+    "auth-user-api/config"
+)
+
+// NewBusFromConfig membuat Bus dengan subscriber bawaan dari konfigurasi: satu WebhookSink per
+// URL dan, jika diaktifkan, subscriber yang menulis event ke log. Subscriber in-process lain
+// (mis. indexer pencarian) didaftarkan pemanggil dengan Subscribe.
+func NewBusFromConfig(cfg config.EventsConfig) *Bus {
+    bus := NewBus()
+    if cfg.Log {
+        bus.Subscribe("log", func(ctx context.Context, e *Envelope) error {
+            log.Printf("Event %d %s %s/%s: %s", e.ID, e.Type, e.AggregateType, e.AggregateID, e.Payload)
+            return nil
+        })
+    }
+    for _, url := range cfg.WebhookURLList() {
+        bus.Subscribe("webhook:"+url, NewWebhookSink(url, cfg.WebhookSecret, cfg.HandlerTimeout).Handle)
+    }
+    return bus
+}
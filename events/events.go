@@ -0,0 +1,167 @@
+// events/events.go
+package events
+
+import (
+    "encoding/json"
+    "strconv"
+    "time"
+
+    "github.com/google/uuid"
+)
+
+// Tipe event domain. Nama memakai format "<aggregate>.<kejadian>" dan tidak boleh diubah
+// setelah dirilis karena dipakai subscriber dan penerima webhook.
+const (
+    TypeLoanRequested = "loan.requested"
+    TypeLoanApproved  = "loan.approved"
+    TypeLoanRejected  = "loan.rejected"
+    TypeLoanCancelled = "loan.cancelled"
+    TypeLoanReturned  = "loan.returned"
+
+    TypeBookCreated  = "book.created"
+    TypeBookUpdated  = "book.updated"
+    TypeBookDeleted  = "book.deleted"
+    TypeBookRestored = "book.restored"
+
+    TypeAuthorCreated  = "author.created"
+    TypeAuthorUpdated  = "author.updated"
+    TypeAuthorDeleted  = "author.deleted"
+    TypeAuthorRestored = "author.restored"
+
+    TypePublisherCreated  = "publisher.created"
+    TypePublisherUpdated  = "publisher.updated"
+    TypePublisherDeleted  = "publisher.deleted"
+    TypePublisherRestored = "publisher.restored"
+)
+
+// Event is a typed domain event. Payload-nya adalah struct itu sendiri yang di-encode ke JSON.
+type Event interface {
+    EventType() string
+    AggregateType() string
+    AggregateID() string
+}
+
+// Envelope adalah event yang sudah tersimpan di outbox, sebagaimana diterima subscriber dan
+// webhook. ID unik per event dan sama di setiap percobaan kirim, jadi bisa dipakai untuk dedup.
+type Envelope struct {
+    ID            uint            `json:"id"`
+    Type          string          `json:"type"`
+    AggregateType string          `json:"aggregate_type"`
+    AggregateID   string          `json:"aggregate_id"`
+    OccurredAt    time.Time       `json:"occurred_at"`
+    Payload       json.RawMessage `json:"payload"`
+}
+
+// Decode unmarshals the payload into the typed event, e.g. *LoanApproved
+func (e *Envelope) Decode(v interface{}) error {
+    return json.Unmarshal(e.Payload, v)
+}
+
+// LoanRequested: member mengajukan peminjaman
+type LoanRequested struct {
+    RequestID uint      `json:"request_id"`
+    BookID    int       `json:"book_id"`
+    UserID    uuid.UUID `json:"user_id"`
+}
+
+func (LoanRequested) EventType() string { return TypeLoanRequested }
+func (LoanRequested) AggregateType() string { return "loan_request" }
+func (e LoanRequested) AggregateID() string { return uintID(e.RequestID) }
+
+// LoanApproved: request disetujui dan pinjaman dibuat
+type LoanApproved struct {
+    RequestID uint      `json:"request_id"`
+    LoanID    uint      `json:"loan_id"`
+    BookID    int       `json:"book_id"`
+    CopyID    *uint     `json:"copy_id"`
+    UserID    uuid.UUID `json:"user_id"`
+    DueDate   time.Time `json:"due_date"`
+}
+
+func (LoanApproved) EventType() string { return TypeLoanApproved }
+func (LoanApproved) AggregateType() string { return "loan_request" }
+func (e LoanApproved) AggregateID() string { return uintID(e.RequestID) }
+
+type LoanRejected struct {
+    RequestID uint      `json:"request_id"`
+    BookID    int       `json:"book_id"`
+    UserID    uuid.UUID `json:"user_id"`
+    Reason    string    `json:"reason"`
+}
+
+func (LoanRejected) EventType() string { return TypeLoanRejected }
+func (LoanRejected) AggregateType() string { return "loan_request" }
+func (e LoanRejected) AggregateID() string { return uintID(e.RequestID) }
+
+// LoanCancelled: request dibatalkan oleh member sendiri atau staf (CancelledBy)
+type LoanCancelled struct {
+    RequestID   uint      `json:"request_id"`
+    BookID      int       `json:"book_id"`
+    UserID      uuid.UUID `json:"user_id"`
+    CancelledBy uuid.UUID `json:"cancelled_by"`
+    Reason      string    `json:"reason"`
+}
+
+func (LoanCancelled) EventType() string { return TypeLoanCancelled }
+func (LoanCancelled) AggregateType() string { return "loan_request" }
+func (e LoanCancelled) AggregateID() string { return uintID(e.RequestID) }
+
+// LoanReturned: pinjaman ditutup, termasuk lewat force-return (Forced)
+type LoanReturned struct {
+    LoanID     uint      `json:"loan_id"`
+    BookID     int       `json:"book_id"`
+    CopyID     *uint     `json:"copy_id"` // null untuk pinjaman lama tanpa eksemplar
+    UserID     uuid.UUID `json:"user_id"`
+    ReturnedAt time.Time `json:"returned_at"`
+    LateFee    int       `json:"late_fee"`
+    Forced     bool      `json:"forced"`
+}
+
+func (LoanReturned) EventType() string { return TypeLoanReturned }
+func (LoanReturned) AggregateType() string { return "loan" }
+func (e LoanReturned) AggregateID() string { return uintID(e.LoanID) }
+
+// BookChanged dipakai untuk book.created, book.updated, book.deleted dan book.restored.
+// Field selain BookID kosong pada book.deleted.
+type BookChanged struct {
+    Type        string  `json:"-"`
+    BookID      int     `json:"book_id"`
+    Title       string  `json:"title,omitempty"`
+    ISBN        *string `json:"isbn,omitempty"`
+    Category    string  `json:"category,omitempty"`
+    AuthorID    int     `json:"author_id,omitempty"`
+    PublisherID int     `json:"publisher_id,omitempty"`
+    Stock       int     `json:"stock"`
+    MaxStock    int     `json:"max_stock"`
+}
+
+func (e BookChanged) EventType() string { return e.Type }
+func (BookChanged) AggregateType() string { return "book" }
+func (e BookChanged) AggregateID() string { return strconv.Itoa(e.BookID) }
+
+// AuthorChanged dipakai untuk author.created, author.updated, author.deleted dan author.restored
+type AuthorChanged struct {
+    Type     string `json:"-"`
+    AuthorID int    `json:"author_id"`
+    Name     string `json:"name,omitempty"`
+}
+
+func (e AuthorChanged) EventType() string { return e.Type }
+func (AuthorChanged) AggregateType() string { return "author" }
+func (e AuthorChanged) AggregateID() string { return strconv.Itoa(e.AuthorID) }
+
+// PublisherChanged dipakai untuk publisher.created, publisher.updated, publisher.deleted dan
+// publisher.restored
+type PublisherChanged struct {
+    Type        string `json:"-"`
+    PublisherID int    `json:"publisher_id"`
+    Name        string `json:"name,omitempty"`
+}
+
+func (e PublisherChanged) EventType() string { return e.Type }
+func (PublisherChanged) AggregateType() string { return "publisher" }
+func (e PublisherChanged) AggregateID() string { return strconv.Itoa(e.PublisherID) }
+
+func uintID(id uint) string {
+    return strconv.FormatUint(uint64(id), 10)
+}
@@ -0,0 +1,85 @@
+// events/webhook.go
+package events
+
+import (
+    "bytes"
+    "context"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "strconv"
+    "time"
+)
+
+// Header yang dikirim bersama setiap webhook event, juga dipakai webhook notifikasi
+const (
+    SignatureHeader = "X-Library-Signature" // "sha256=<hex HMAC-SHA256 dari "<timestamp>.<body>">"
+    TimestampHeader = "X-Library-Timestamp" // Unix detik saat request ditandatangani
+    EventTypeHeader = "X-Library-Event"
+    EventIDHeader   = "X-Library-Event-ID"
+)
+
+// WebhookSink meneruskan event ke satu URL sebagai JSON Envelope lewat HTTP POST.
+// Status selain 2xx dianggap gagal sehingga event dikirim ulang nanti.
+type WebhookSink struct {
+    URL    string
+    Secret string
+    Client *http.Client
+}
+
+func NewWebhookSink(url, secret string, timeout time.Duration) *WebhookSink {
+    return &WebhookSink{URL: url, Secret: secret, Client: &http.Client{Timeout: timeout}}
+}
+
+// Handle implements Handler
+func (w *WebhookSink) Handle(ctx context.Context, e *Envelope) error {
+    body, err := json.Marshal(e)
+    if err != nil {
+        return err
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set(EventTypeHeader, e.Type)
+    req.Header.Set(EventIDHeader, strconv.FormatUint(uint64(e.ID), 10))
+    if w.Secret != "" {
+        SetSignature(req.Header, w.Secret, body)
+    }
+
+    resp, err := w.Client.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+    if resp.StatusCode < 200 || resp.StatusCode > 299 {
+        return fmt.Errorf("webhook responded with %s", resp.Status)
+    }
+    return nil
+}
+
+// SetSignature mengisi TimestampHeader dan SignatureHeader untuk body yang dikirim sekarang.
+// Timestamp ikut ditandatangani, jadi penerima bisa menolak request yang timestamp-nya terlalu
+// jauh dari jam mereka (mis. lebih dari 5 menit) agar request lama tidak bisa diputar ulang.
+func SetSignature(header http.Header, secret string, body []byte) {
+    timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+    header.Set(TimestampHeader, timestamp)
+    header.Set(SignatureHeader, Sign(secret, timestamp, body))
+}
+
+// Sign returns the signature header value for body sent at timestamp. Penerima menghitung ulang
+// HMAC dari "<timestamp>.<body mentah>" dan membandingkannya dengan hmac.Equal.
+func Sign(secret, timestamp string, body []byte) string {
+    mac := hmac.New(sha256.New, []byte(secret))
+    mac.Write([]byte(timestamp + "."))
+    mac.Write(body)
+    return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
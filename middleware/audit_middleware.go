@@ -0,0 +1,91 @@
+// middleware/audit_middleware.go
+package middleware
+
+import (
+    "auth-user-api/models"
+    "auth-user-api/services"
+    "log"
+    "net/http"
+    "strings"
+
+    "github.com/google/uuid"
+    "github.com/labstack/echo/v4"
+)
+
+type AuditMiddleware struct {
+    AuditService services.AuditService
+}
+
+func NewAuditMiddleware(auditService services.AuditService) *AuditMiddleware {
+    return &AuditMiddleware{AuditService: auditService}
+}
+
+// Audit records every successful mutating request (selain GET, HEAD dan OPTIONS). Handler
+// melaporkan perubahan beserta nilai lama/barunya lewat recordAudit di context
+// "audit_entries"; request yang tidak melaporkan apa pun tetap dicatat dengan route dan ID
+// dari URL. Dipasang global sebelum route sehingga actor dibaca setelah JWTMiddleware berjalan.
+// Request yang gagal (error atau status >= 400) tidak mengubah data, jadi tidak dicatat.
+//
+// Audit log bersifat best-effort: baris ditulis setelah transaksi service commit dan response
+// terkirim, sehingga perubahan tetap tersimpan walaupun penulisan audit gagal (hanya dicatat di
+// log server). Jejak yang harus atomik dengan perubahan data ada di domain events (outbox) yang
+// ditulis di transaksi yang sama. IP diambil lewat e.IPExtractor (lihat server.trusted_proxies).
+func (m *AuditMiddleware) Audit(next echo.HandlerFunc) echo.HandlerFunc {
+    return func(ctx echo.Context) error {
+        switch ctx.Request().Method {
+        case http.MethodGet, http.MethodHead, http.MethodOptions:
+            return next(ctx)
+        }
+
+        if err := next(ctx); err != nil {
+            return err
+        }
+        status := ctx.Response().Status
+        if status >= http.StatusBadRequest {
+            return nil
+        }
+
+        entries, _ := ctx.Get("audit_entries").([]*services.AuditEntry)
+        if len(entries) == 0 {
+            entries = []*services.AuditEntry{routeAuditEntry(ctx)}
+        }
+
+        username, _ := ctx.Get("username").(string)
+        var actorID *uuid.UUID
+        if id, err := uuid.Parse(stringValue(ctx.Get("user_id"))); err == nil {
+            actorID = &id
+        }
+        for _, entry := range entries {
+            entryLog := &models.AuditLog{
+                ActorID:   actorID,
+                ActorName: username,
+                IP:        ctx.RealIP(),
+                RequestID: ctx.Response().Header().Get(echo.HeaderXRequestID),
+                Method:    ctx.Request().Method,
+                Path:      ctx.Request().URL.Path,
+                Status:    status,
+            }
+            // Response sudah terkirim; kegagalan menulis audit hanya bisa dicatat di log server
+            if err := m.AuditService.Record(entryLog, entry); err != nil {
+                log.Printf("Failed to write audit log for %s %s: %v", entryLog.Method, entryLog.Path, err)
+            }
+        }
+        return nil
+    }
+}
+
+// routeAuditEntry membangun entry dari route, mis. "POST /holds/:id" dengan entity "holds"
+func routeAuditEntry(ctx echo.Context) *services.AuditEntry {
+    route := ctx.Path()
+    entityType := strings.SplitN(strings.Trim(route, "/"), "/", 2)[0]
+    return &services.AuditEntry{
+        Action:     ctx.Request().Method + " " + route,
+        EntityType: entityType,
+        EntityID:   ctx.Param("id"),
+    }
+}
+
+func stringValue(v interface{}) string {
+    s, _ := v.(string)
+    return s
+}
@@ -2,25 +2,26 @@
 package middleware
 
 import (
-    "auth-user-api/controllers"
     "auth-user-api/domains"
     "auth-user-api/services"
+    "errors"
     "net/http"
     "strings"
 
-    "github.com/golang-jwt/jwt/v4"
     "github.com/labstack/echo/v4"
 )
 
 type JWTMiddlewareConfig struct {
     UserService services.UserService // Inject UserService
+    AuthService services.AuthService
 }
 
-func NewJWTMiddleware(userService services.UserService) *JWTMiddlewareConfig {
-    return &JWTMiddlewareConfig{UserService: userService}
+func NewJWTMiddleware(userService services.UserService, authService services.AuthService) *JWTMiddlewareConfig {
+    return &JWTMiddlewareConfig{UserService: userService, AuthService: authService}
 }
 
-// JWTMiddleware verifies the token and sets role and username in context.
+// JWTMiddleware verifies the token, rejects revoked token IDs (jti) and sets user ID,
+// role, username and the parsed claims in context.
 func (mw *JWTMiddlewareConfig) JWTMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
     return func(ctx echo.Context) error {
         tokenString := ctx.Request().Header.Get("Authorization")
@@ -34,13 +35,18 @@ func (mw *JWTMiddlewareConfig) JWTMiddleware(next echo.HandlerFunc) echo.Handler
         }
 
         tokenString = strings.TrimPrefix(tokenString, "Bearer ")
-        claims := &controllers.JWTClaims{}
-
-        token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-            return []byte("my_secret_key"), nil
-        })
-
-        if err != nil || !token.Valid {
+        claims, err := mw.AuthService.ParseAccessToken(tokenString)
+        if err != nil {
+            if errors.Is(err, services.ErrTokenRevoked) {
+                return ctx.JSON(http.StatusUnauthorized, domains.BaseResponse{
+                    Code:    "401",
+                    Message: "Token has been revoked",
+                    Error:   "Token revoked",
+                })
+            }
+            if !errors.Is(err, services.ErrInvalidToken) {
+                return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to verify token", err.Error()))
+            }
             return ctx.JSON(http.StatusUnauthorized, domains.BaseResponse{
                 Code:    "401",
                 Message: "Invalid token",
@@ -49,7 +55,7 @@ func (mw *JWTMiddlewareConfig) JWTMiddleware(next echo.HandlerFunc) echo.Handler
         }
 
         user, err := mw.UserService.GetUserByUsername(claims.Username)
-        if err != nil || user == nil {
+        if err != nil || user == nil || claims.UserID != user.ID {
             return ctx.JSON(http.StatusUnauthorized, domains.BaseResponse{
                 Code:    "401",
                 Message: "Invalid token - user not found",
@@ -57,35 +63,13 @@ func (mw *JWTMiddlewareConfig) JWTMiddleware(next echo.HandlerFunc) echo.Handler
             })
         }
 
-        // Set username and role in context
-        ctx.Set("username", claims.Username)
-        ctx.Set("role", claims.Role)
+        // Role dan username diambil dari database, bukan dari token, agar perubahan role
+        // langsung berlaku tanpa menunggu token kedaluwarsa
+        ctx.Set("user_id", user.ID)
+        ctx.Set("username", user.Username)
+        ctx.Set("role", user.Role)
+        ctx.Set("claims", claims)
 
         return next(ctx)
     }
 }
-
-// LoanRequestSummary provides a summary structure for each loan request in the list response
-type LoanRequestSummary struct {
-    ID           uint   `json:"id"`
-    BookID       int    `json:"book_id"`
-    BorrowerName string `json:"borrower_name"`
-    Status       string `json:"status"`
-    RequestDate  string `json:"request_date"`
-}
-
-// LoanRecordListResponse represents the structure for retrieving all loan records
-type LoanRecordListResponse struct {
-    TotalRecords int                `json:"total_records"`
-    LoanRecords  []LoanRecordDetail `json:"loan_records"`
-}
-
-// LoanRecordDetail provides detailed information for each loan record
-type LoanRecordDetail struct {
-    ID           uint   `json:"id"`
-    BookID       int    `json:"book_id"`
-    BorrowerName string `json:"borrower_name"`
-    Status       string `json:"status"`
-    BorrowDate   string `json:"borrow_date"`
-    ReturnDate   *string `json:"return_date,omitempty"` // Nullable if not returned
-}
\ No newline at end of file
@@ -0,0 +1,60 @@
+// middleware/rbac_middleware.go
+package middleware
+
+import (
+    "auth-user-api/domains"
+    "auth-user-api/services"
+    "net/http"
+    "strings"
+
+    "github.com/labstack/echo/v4"
+)
+
+type RBACMiddleware struct {
+    RoleService services.RoleService
+}
+
+func NewRBACMiddleware(roleService services.RoleService) *RBACMiddleware {
+    return &RBACMiddleware{RoleService: roleService}
+}
+
+// Require returns a middleware that only lets through users whose role grants every
+// listed permission. It must run after JWTMiddleware. With no permissions it only
+// requires an authenticated user. The role's permission set is stored in context
+// under "permissions" so handlers can make ownership decisions.
+func (m *RBACMiddleware) Require(permissions ...string) echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(ctx echo.Context) error {
+            role, ok := ctx.Get("role").(int)
+            if !ok {
+                return ctx.JSON(http.StatusUnauthorized, domains.BaseResponse{
+                    Code:    "401",
+                    Message: "Authentication required",
+                    Error:   "Missing authenticated user",
+                })
+            }
+
+            granted, err := m.RoleService.GetPermissions(role)
+            if err != nil {
+                return ctx.JSON(http.StatusInternalServerError, domains.NewErrorResponse("500", "Failed to load permissions", err.Error()))
+            }
+
+            var missing []string
+            for _, permission := range permissions {
+                if !granted[permission] {
+                    missing = append(missing, permission)
+                }
+            }
+            if len(missing) > 0 {
+                return ctx.JSON(http.StatusForbidden, domains.BaseResponse{
+                    Code:    "403",
+                    Message: "Access denied",
+                    Error:   "Missing permission: " + strings.Join(missing, ", "),
+                })
+            }
+
+            ctx.Set("permissions", granted)
+            return next(ctx)
+        }
+    }
+}
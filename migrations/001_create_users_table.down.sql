@@ -0,0 +1,3 @@
+-- migrations/001_create_users_table.down.sql
+
+DROP TABLE IF EXISTS users;
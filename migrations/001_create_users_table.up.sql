@@ -1,4 +1,6 @@
--- migrations/001_create_users_table.sql
+-- migrations/001_create_users_table.up.sql
+
+CREATE EXTENSION IF NOT EXISTS "pgcrypto";
 
 CREATE TABLE IF NOT EXISTS users (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
@@ -0,0 +1,3 @@
+-- migrations/002_create_authors_table.down.sql
+
+DROP TABLE IF EXISTS authors;
@@ -0,0 +1,3 @@
+-- migrations/003_create_publishers_table.down.sql
+
+DROP TABLE IF EXISTS publishers;
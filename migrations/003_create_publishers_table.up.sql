@@ -1,7 +1,9 @@
+-- migrations/003_create_publishers_table.up.sql
+
 CREATE TABLE IF NOT EXISTS publishers (
     id SERIAL PRIMARY KEY,
     name VARCHAR(255) NOT NULL,
     created_at TIMESTAMPTZ DEFAULT NOW(),
     updated_at TIMESTAMPTZ DEFAULT NOW(),
     deleted_at TIMESTAMPTZ
-);
\ No newline at end of file
+);
@@ -0,0 +1,3 @@
+-- migrations/004_create_books_table.down.sql
+
+DROP TABLE IF EXISTS books;
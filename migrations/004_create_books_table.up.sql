@@ -1,3 +1,5 @@
+-- migrations/004_create_books_table.up.sql
+
 CREATE TABLE IF NOT EXISTS books (
     id SERIAL PRIMARY KEY,
     title VARCHAR(255) NOT NULL,
@@ -0,0 +1,3 @@
+-- migrations/005_create_loan_requests_table.down.sql
+
+DROP TABLE IF EXISTS loan_requests;
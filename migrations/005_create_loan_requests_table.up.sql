@@ -1,4 +1,4 @@
--- migrations/005_create_loan_requests_table.sql
+-- migrations/005_create_loan_requests_table.up.sql
 
 CREATE TABLE IF NOT EXISTS loan_requests (
     id SERIAL PRIMARY KEY,
@@ -9,4 +9,4 @@ CREATE TABLE IF NOT EXISTS loan_requests (
     reject_reason TEXT,
     created_at TIMESTAMPTZ DEFAULT NOW(),
     updated_at TIMESTAMPTZ DEFAULT NOW()
-);
\ No newline at end of file
+);
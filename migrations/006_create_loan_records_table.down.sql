@@ -0,0 +1,3 @@
+-- migrations/006_create_loan_records_table.down.sql
+
+DROP TABLE IF EXISTS loan_records;
@@ -1,4 +1,4 @@
--- migrations/006_create_loan_records_table.sql
+-- migrations/006_create_loan_records_table.up.sql
 
 CREATE TABLE IF NOT EXISTS loan_records (
     id SERIAL PRIMARY KEY,
@@ -10,4 +10,4 @@ CREATE TABLE IF NOT EXISTS loan_records (
     return_date TIMESTAMPTZ,
     created_at TIMESTAMPTZ DEFAULT NOW(),
     updated_at TIMESTAMPTZ DEFAULT NOW()
-);
\ No newline at end of file
+);
@@ -0,0 +1,4 @@
+-- migrations/007_create_book_copies_table.down.sql
+
+ALTER TABLE loan_records DROP COLUMN IF EXISTS copy_id;
+DROP TABLE IF EXISTS book_copies;
@@ -0,0 +1,19 @@
+-- migrations/007_create_book_copies_table.up.sql
+
+CREATE TABLE IF NOT EXISTS book_copies (
+    id SERIAL PRIMARY KEY,
+    book_id INT NOT NULL REFERENCES books(id),
+    barcode VARCHAR(64) NOT NULL UNIQUE,
+    acquisition_date TIMESTAMPTZ,
+    condition VARCHAR(20),
+    shelf_location VARCHAR(100),
+    status VARCHAR(20) NOT NULL DEFAULT 'AVAILABLE' CHECK (status IN ('AVAILABLE', 'ON_LOAN', 'LOST', 'DAMAGED', 'WITHDRAWN')),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_book_copies_book_id ON book_copies(book_id);
+CREATE INDEX IF NOT EXISTS idx_book_copies_status ON book_copies(status);
+
+ALTER TABLE loan_records ADD COLUMN IF NOT EXISTS copy_id INT REFERENCES book_copies(id);
+CREATE INDEX IF NOT EXISTS idx_loan_records_copy_id ON loan_records(copy_id);
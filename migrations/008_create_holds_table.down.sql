@@ -0,0 +1,7 @@
+-- migrations/008_create_holds_table.down.sql
+
+DROP TABLE IF EXISTS holds;
+
+ALTER TABLE book_copies DROP CONSTRAINT IF EXISTS book_copies_status_check;
+ALTER TABLE book_copies ADD CONSTRAINT book_copies_status_check
+    CHECK (status IN ('AVAILABLE', 'ON_LOAN', 'LOST', 'DAMAGED', 'WITHDRAWN'));
@@ -0,0 +1,22 @@
+-- migrations/008_create_holds_table.up.sql
+
+ALTER TABLE book_copies DROP CONSTRAINT IF EXISTS book_copies_status_check;
+ALTER TABLE book_copies ADD CONSTRAINT book_copies_status_check
+    CHECK (status IN ('AVAILABLE', 'ON_LOAN', 'ON_HOLD', 'LOST', 'DAMAGED', 'WITHDRAWN'));
+
+CREATE TABLE IF NOT EXISTS holds (
+    id SERIAL PRIMARY KEY,
+    book_id INT NOT NULL REFERENCES books(id),
+    user_id UUID NOT NULL REFERENCES users(id),
+    position INT NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('WAITING', 'READY', 'FULFILLED', 'EXPIRED', 'CANCELLED')),
+    copy_id INT REFERENCES book_copies(id),
+    ready_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_holds_book_id ON holds(book_id);
+CREATE INDEX IF NOT EXISTS idx_holds_user_id ON holds(user_id);
+CREATE INDEX IF NOT EXISTS idx_holds_status ON holds(status);
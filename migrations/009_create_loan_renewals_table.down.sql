@@ -0,0 +1,4 @@
+-- migrations/009_create_loan_renewals_table.down.sql
+
+DROP TABLE IF EXISTS loan_renewals;
+ALTER TABLE loan_records DROP COLUMN IF EXISTS renewal_count;
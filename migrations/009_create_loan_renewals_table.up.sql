@@ -0,0 +1,14 @@
+-- migrations/009_create_loan_renewals_table.up.sql
+
+ALTER TABLE loan_records ADD COLUMN IF NOT EXISTS renewal_count INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS loan_renewals (
+    id SERIAL PRIMARY KEY,
+    loan_record_id INT NOT NULL REFERENCES loan_records(id),
+    previous_due_date TIMESTAMPTZ NOT NULL,
+    new_due_date TIMESTAMPTZ NOT NULL,
+    renewed_by VARCHAR(50),
+    renewed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_loan_renewals_loan_record_id ON loan_renewals(loan_record_id);
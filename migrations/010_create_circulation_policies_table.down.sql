@@ -0,0 +1,5 @@
+-- migrations/010_create_circulation_policies_table.down.sql
+
+DROP TABLE IF EXISTS circulation_policies;
+DROP INDEX IF EXISTS idx_books_category;
+ALTER TABLE books DROP COLUMN IF EXISTS category;
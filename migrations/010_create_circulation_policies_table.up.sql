@@ -0,0 +1,25 @@
+-- migrations/010_create_circulation_policies_table.up.sql
+
+ALTER TABLE books ADD COLUMN IF NOT EXISTS category VARCHAR(100);
+CREATE INDEX IF NOT EXISTS idx_books_category ON books(category);
+
+CREATE TABLE IF NOT EXISTS circulation_policies (
+    id SERIAL PRIMARY KEY,
+    role INT NOT NULL DEFAULT 0,           -- 0 berlaku untuk semua role
+    category VARCHAR(100) NOT NULL DEFAULT '', -- '' berlaku untuk semua kategori
+    can_borrow BOOLEAN NOT NULL DEFAULT TRUE,
+    loan_period_days INT NOT NULL,
+    max_concurrent_loans INT NOT NULL DEFAULT 0,
+    max_renewals INT NOT NULL DEFAULT 0,
+    renewal_period_days INT NOT NULL,
+    daily_fine INT NOT NULL DEFAULT 0,
+    fine_cap INT NOT NULL DEFAULT 0,
+    grace_days INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    CONSTRAINT idx_policy_role_category UNIQUE (role, category)
+);
+
+INSERT INTO circulation_policies (role, category, can_borrow, loan_period_days, max_renewals, renewal_period_days, daily_fine)
+VALUES (0, '', TRUE, 3, 2, 3, 5000)
+ON CONFLICT (role, category) DO NOTHING;
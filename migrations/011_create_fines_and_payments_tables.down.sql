@@ -0,0 +1,4 @@
+-- migrations/011_create_fines_and_payments_tables.down.sql
+
+DROP TABLE IF EXISTS payments;
+DROP TABLE IF EXISTS fines;
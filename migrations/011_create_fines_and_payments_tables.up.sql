@@ -0,0 +1,33 @@
+-- migrations/011_create_fines_and_payments_tables.up.sql
+
+CREATE TABLE IF NOT EXISTS fines (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    loan_record_id INT REFERENCES loan_records(id),
+    amount INT NOT NULL CHECK (amount > 0),
+    paid_amount INT NOT NULL DEFAULT 0,
+    waived_amount INT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('OUTSTANDING', 'PAID', 'WAIVED')),
+    reason TEXT,
+    waive_reason TEXT,
+    waived_by VARCHAR(50),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    CHECK (paid_amount + waived_amount <= amount)
+);
+
+CREATE INDEX IF NOT EXISTS idx_fines_user_id ON fines(user_id);
+CREATE INDEX IF NOT EXISTS idx_fines_loan_record_id ON fines(loan_record_id);
+CREATE INDEX IF NOT EXISTS idx_fines_status ON fines(status);
+
+CREATE TABLE IF NOT EXISTS payments (
+    id SERIAL PRIMARY KEY,
+    fine_id INT NOT NULL REFERENCES fines(id),
+    user_id UUID NOT NULL REFERENCES users(id),
+    amount INT NOT NULL CHECK (amount > 0),
+    method VARCHAR(20),
+    received_by VARCHAR(50),
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payments_fine_id ON payments(fine_id);
@@ -0,0 +1,4 @@
+-- migrations/012_add_loan_request_actors.down.sql
+
+ALTER TABLE loan_requests DROP COLUMN IF EXISTS cancelled_by;
+ALTER TABLE loan_requests DROP COLUMN IF EXISTS requested_by;
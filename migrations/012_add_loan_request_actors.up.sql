@@ -0,0 +1,4 @@
+-- migrations/012_add_loan_request_actors.up.sql
+
+ALTER TABLE loan_requests ADD COLUMN IF NOT EXISTS requested_by UUID REFERENCES users(id);
+ALTER TABLE loan_requests ADD COLUMN IF NOT EXISTS cancelled_by UUID REFERENCES users(id);
@@ -0,0 +1,4 @@
+-- migrations/013_create_roles_tables.down.sql
+
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
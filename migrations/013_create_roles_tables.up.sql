@@ -0,0 +1,26 @@
+-- migrations/013_create_roles_tables.up.sql
+
+CREATE TABLE IF NOT EXISTS roles (
+    id INT PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id INT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission VARCHAR(50) NOT NULL,
+    PRIMARY KEY (role_id, permission)
+);
+
+-- Role bawaan: 1 = admin (semua permission), 2 = member
+INSERT INTO roles (id, name) VALUES (1, 'admin'), (2, 'member') ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission) VALUES
+    (1, 'catalog:read'), (1, 'catalog:write'),
+    (1, 'loans:request'), (1, 'loans:read'), (1, 'loans:approve'), (1, 'loans:return'), (1, 'loans:manage'),
+    (1, 'holds:request'), (1, 'holds:manage'),
+    (1, 'fines:read'), (1, 'fines:manage'),
+    (1, 'policies:read'), (1, 'policies:write'),
+    (1, 'users:self'), (1, 'users:admin'),
+    (2, 'catalog:read'), (2, 'loans:request'), (2, 'holds:request'),
+    (2, 'fines:read'), (2, 'policies:read'), (2, 'users:self')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,4 @@
+-- migrations/014_create_auth_tokens_tables.down.sql
+
+DROP TABLE IF EXISTS revoked_tokens;
+DROP TABLE IF EXISTS refresh_tokens;
@@ -0,0 +1,5 @@
+-- migrations/015_fix_loan_request_status_check.down.sql
+
+ALTER TABLE loan_requests DROP CONSTRAINT IF EXISTS loan_requests_status_check;
+ALTER TABLE loan_requests ADD CONSTRAINT loan_requests_status_check
+    CHECK (status IN ('PENDING', 'APPROVED', 'REJECTED'));
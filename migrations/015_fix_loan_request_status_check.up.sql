@@ -0,0 +1,6 @@
+-- migrations/015_fix_loan_request_status_check.up.sql
+
+-- 005 tidak mengizinkan status CANCELLED yang dipakai CancelLoanRequest
+ALTER TABLE loan_requests DROP CONSTRAINT IF EXISTS loan_requests_status_check;
+ALTER TABLE loan_requests ADD CONSTRAINT loan_requests_status_check
+    CHECK (status IN ('PENDING', 'APPROVED', 'REJECTED', 'CANCELLED'));
@@ -0,0 +1,3 @@
+-- migrations/016_create_invites_table.down.sql
+
+DROP TABLE IF EXISTS invites;
@@ -0,0 +1,13 @@
+-- migrations/016_create_invites_table.up.sql
+
+CREATE TABLE IF NOT EXISTS invites (
+    id UUID PRIMARY KEY,
+    role INT NOT NULL REFERENCES roles(id),
+    email VARCHAR(100),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_by UUID REFERENCES users(id),
+    used_at TIMESTAMPTZ,
+    used_by UUID REFERENCES users(id),
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
@@ -0,0 +1,10 @@
+-- migrations/017_add_books_search_vector.down.sql
+
+DROP TRIGGER IF EXISTS publishers_search_vector ON publishers;
+DROP TRIGGER IF EXISTS authors_search_vector ON authors;
+DROP TRIGGER IF EXISTS books_search_vector ON books;
+DROP FUNCTION IF EXISTS books_search_vector_refresh_publisher();
+DROP FUNCTION IF EXISTS books_search_vector_refresh_author();
+DROP FUNCTION IF EXISTS books_search_vector_update();
+DROP INDEX IF EXISTS idx_books_search_vector;
+ALTER TABLE books DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,53 @@
+-- migrations/017_add_books_search_vector.up.sql
+
+-- Dokumen full-text buku: judul (A), nama author (B), ringkasan (C), nama publisher (D).
+-- Konfigurasi 'simple' dipakai karena katalog bercampur bahasa Indonesia dan Inggris.
+ALTER TABLE books ADD COLUMN IF NOT EXISTS search_vector TSVECTOR;
+
+CREATE OR REPLACE FUNCTION books_search_vector_update() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('simple', coalesce(NEW.title, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce((SELECT name FROM authors WHERE id = NEW.author_id), '')), 'B') ||
+        setweight(to_tsvector('simple', coalesce(NEW.summary, '')), 'C') ||
+        setweight(to_tsvector('simple', coalesce((SELECT name FROM publishers WHERE id = NEW.publisher_id), '')), 'D');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS books_search_vector ON books;
+CREATE TRIGGER books_search_vector
+    BEFORE INSERT OR UPDATE OF title, summary, author_id, publisher_id ON books
+    FOR EACH ROW EXECUTE FUNCTION books_search_vector_update();
+
+-- Nama author/publisher yang berubah ikut memperbarui dokumen buku-bukunya
+CREATE OR REPLACE FUNCTION books_search_vector_refresh_author() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE books SET title = title WHERE author_id = NEW.id;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION books_search_vector_refresh_publisher() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE books SET title = title WHERE publisher_id = NEW.id;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS authors_search_vector ON authors;
+CREATE TRIGGER authors_search_vector
+    AFTER UPDATE OF name ON authors
+    FOR EACH ROW WHEN (OLD.name IS DISTINCT FROM NEW.name)
+    EXECUTE FUNCTION books_search_vector_refresh_author();
+
+DROP TRIGGER IF EXISTS publishers_search_vector ON publishers;
+CREATE TRIGGER publishers_search_vector
+    AFTER UPDATE OF name ON publishers
+    FOR EACH ROW WHEN (OLD.name IS DISTINCT FROM NEW.name)
+    EXECUTE FUNCTION books_search_vector_refresh_publisher();
+
+-- Isi dokumen untuk buku yang sudah ada
+UPDATE books SET title = title;
+
+CREATE INDEX IF NOT EXISTS idx_books_search_vector ON books USING GIN (search_vector);
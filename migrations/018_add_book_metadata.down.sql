@@ -0,0 +1,28 @@
+-- migrations/018_add_book_metadata.down.sql
+
+CREATE OR REPLACE FUNCTION books_search_vector_update() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('simple', coalesce(NEW.title, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce((SELECT name FROM authors WHERE id = NEW.author_id), '')), 'B') ||
+        setweight(to_tsvector('simple', coalesce(NEW.summary, '')), 'C') ||
+        setweight(to_tsvector('simple', coalesce((SELECT name FROM publishers WHERE id = NEW.publisher_id), '')), 'D');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS books_search_vector ON books;
+CREATE TRIGGER books_search_vector
+    BEFORE INSERT OR UPDATE OF title, summary, author_id, publisher_id ON books
+    FOR EACH ROW EXECUTE FUNCTION books_search_vector_update();
+
+DROP INDEX IF EXISTS idx_books_isbn;
+ALTER TABLE books DROP CONSTRAINT IF EXISTS books_page_count_check;
+ALTER TABLE books DROP CONSTRAINT IF EXISTS books_isbn_check;
+ALTER TABLE books DROP COLUMN IF EXISTS cover_image_url;
+ALTER TABLE books DROP COLUMN IF EXISTS subjects;
+ALTER TABLE books DROP COLUMN IF EXISTS page_count;
+ALTER TABLE books DROP COLUMN IF EXISTS language;
+ALTER TABLE books DROP COLUMN IF EXISTS edition;
+ALTER TABLE books DROP COLUMN IF EXISTS publication_year;
+ALTER TABLE books DROP COLUMN IF EXISTS isbn;
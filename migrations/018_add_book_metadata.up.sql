@@ -0,0 +1,35 @@
+-- migrations/018_add_book_metadata.up.sql
+
+ALTER TABLE books ADD COLUMN IF NOT EXISTS isbn VARCHAR(13);
+ALTER TABLE books ADD COLUMN IF NOT EXISTS publication_year INT;
+ALTER TABLE books ADD COLUMN IF NOT EXISTS edition VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE books ADD COLUMN IF NOT EXISTS language VARCHAR(3) NOT NULL DEFAULT '';
+ALTER TABLE books ADD COLUMN IF NOT EXISTS page_count INT;
+ALTER TABLE books ADD COLUMN IF NOT EXISTS subjects JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE books ADD COLUMN IF NOT EXISTS cover_image_url TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE books DROP CONSTRAINT IF EXISTS books_isbn_check;
+ALTER TABLE books ADD CONSTRAINT books_isbn_check CHECK (isbn ~ '^97[89][0-9]{10}$');
+ALTER TABLE books DROP CONSTRAINT IF EXISTS books_page_count_check;
+ALTER TABLE books ADD CONSTRAINT books_page_count_check CHECK (page_count > 0);
+
+-- ISBN unik di antara buku yang belum dihapus
+CREATE UNIQUE INDEX IF NOT EXISTS idx_books_isbn ON books (isbn) WHERE deleted_at IS NULL;
+
+-- ISBN ikut bobot judul dan subjek ikut bobot author di dokumen full-text (lihat 017)
+CREATE OR REPLACE FUNCTION books_search_vector_update() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('simple', coalesce(NEW.title, '') || ' ' || coalesce(NEW.isbn, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce((SELECT name FROM authors WHERE id = NEW.author_id), '')), 'B') ||
+        setweight(jsonb_to_tsvector('simple', coalesce(NEW.subjects, '[]'::jsonb), '["string"]'), 'B') ||
+        setweight(to_tsvector('simple', coalesce(NEW.summary, '')), 'C') ||
+        setweight(to_tsvector('simple', coalesce((SELECT name FROM publishers WHERE id = NEW.publisher_id), '')), 'D');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS books_search_vector ON books;
+CREATE TRIGGER books_search_vector
+    BEFORE INSERT OR UPDATE OF title, summary, author_id, publisher_id, isbn, subjects ON books
+    FOR EACH ROW EXECUTE FUNCTION books_search_vector_update();
@@ -0,0 +1,27 @@
+-- migrations/019_create_book_contributors_table.down.sql
+
+DROP TRIGGER IF EXISTS book_contributors_search_vector ON book_contributors;
+DROP FUNCTION IF EXISTS books_search_vector_refresh_contributors();
+
+CREATE OR REPLACE FUNCTION books_search_vector_refresh_author() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE books SET title = title WHERE author_id = NEW.id;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION books_search_vector_update() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('simple', coalesce(NEW.title, '') || ' ' || coalesce(NEW.isbn, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce((SELECT name FROM authors WHERE id = NEW.author_id), '')), 'B') ||
+        setweight(jsonb_to_tsvector('simple', coalesce(NEW.subjects, '[]'::jsonb), '["string"]'), 'B') ||
+        setweight(to_tsvector('simple', coalesce(NEW.summary, '')), 'C') ||
+        setweight(to_tsvector('simple', coalesce((SELECT name FROM publishers WHERE id = NEW.publisher_id), '')), 'D');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TABLE IF EXISTS book_contributors;
+
+UPDATE books SET title = title;
@@ -0,0 +1,62 @@
+-- migrations/019_create_book_contributors_table.up.sql
+
+CREATE TABLE IF NOT EXISTS book_contributors (
+    id SERIAL PRIMARY KEY,
+    book_id INT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    author_id INT NOT NULL REFERENCES authors(id),
+    role VARCHAR(20) NOT NULL DEFAULT 'author' CHECK (role IN ('author', 'editor', 'translator', 'illustrator')),
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_book_contributor ON book_contributors (book_id, author_id, role);
+CREATE INDEX IF NOT EXISTS idx_book_contributors_author_id ON book_contributors (author_id);
+
+-- Penulis tunggal yang sudah ada menjadi kontributor pertama
+INSERT INTO book_contributors (book_id, author_id, role, position)
+SELECT id, author_id, 'author', 0 FROM books WHERE author_id IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+-- Dokumen full-text memakai semua kontributor, bukan hanya author_id
+CREATE OR REPLACE FUNCTION books_search_vector_update() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('simple', coalesce(NEW.title, '') || ' ' || coalesce(NEW.isbn, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce((
+            SELECT string_agg(a.name, ' ')
+            FROM authors a
+            WHERE a.id = NEW.author_id
+               OR a.id IN (SELECT author_id FROM book_contributors WHERE book_id = NEW.id)
+        ), '')), 'B') ||
+        setweight(jsonb_to_tsvector('simple', coalesce(NEW.subjects, '[]'::jsonb), '["string"]'), 'B') ||
+        setweight(to_tsvector('simple', coalesce(NEW.summary, '')), 'C') ||
+        setweight(to_tsvector('simple', coalesce((SELECT name FROM publishers WHERE id = NEW.publisher_id), '')), 'D');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION books_search_vector_refresh_author() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE books SET title = title
+    WHERE author_id = NEW.id
+       OR id IN (SELECT book_id FROM book_contributors WHERE author_id = NEW.id);
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION books_search_vector_refresh_contributors() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE books SET title = title WHERE id = OLD.book_id;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        UPDATE books SET title = title WHERE id = NEW.book_id;
+    END IF;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS book_contributors_search_vector ON book_contributors;
+CREATE TRIGGER book_contributors_search_vector
+    AFTER INSERT OR UPDATE OR DELETE ON book_contributors
+    FOR EACH ROW EXECUTE FUNCTION books_search_vector_refresh_contributors();
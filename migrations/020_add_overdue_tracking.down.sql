@@ -0,0 +1,6 @@
+-- migrations/020_add_overdue_tracking.down.sql
+
+DROP TABLE IF EXISTS loan_reminders;
+DROP INDEX IF EXISTS idx_fines_late_fee;
+DROP INDEX IF EXISTS idx_loan_records_open_due_date;
+ALTER TABLE loan_records DROP COLUMN IF EXISTS overdue_at;
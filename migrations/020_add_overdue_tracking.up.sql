@@ -0,0 +1,27 @@
+-- migrations/020_add_overdue_tracking.up.sql
+
+-- Diisi job overdue saat pertama kali melihat pinjaman melewati jatuh tempo
+ALTER TABLE loan_records ADD COLUMN IF NOT EXISTS overdue_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_loan_records_open_due_date ON loan_records(due_date) WHERE returned = false;
+
+-- Denda keterlambatan per pinjaman yang terus diperbarui selama buku belum kembali
+CREATE INDEX IF NOT EXISTS idx_fines_late_fee ON fines(loan_record_id) WHERE reason = 'Late return';
+
+-- Pengingat jatuh tempo (DUE_SOON) dan eskalasi keterlambatan (OVERDUE, level 1..n).
+-- Kunci unik membuat job aman dijalankan ulang; due_date ikut di kunci sehingga
+-- perpanjangan pinjaman mendapat pengingat baru.
+CREATE TABLE IF NOT EXISTS loan_reminders (
+    id SERIAL PRIMARY KEY,
+    loan_record_id INT NOT NULL REFERENCES loan_records(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id),
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('DUE_SOON', 'OVERDUE')),
+    level INT NOT NULL DEFAULT 0,
+    due_date TIMESTAMPTZ NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'SENT', 'CANCELLED')),
+    sent_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_loan_reminders_unique ON loan_reminders(loan_record_id, kind, level, due_date);
+CREATE INDEX IF NOT EXISTS idx_loan_reminders_status ON loan_reminders(status);
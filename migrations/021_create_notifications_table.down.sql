@@ -0,0 +1,5 @@
+-- migrations/021_create_notifications_table.down.sql
+
+DROP TABLE IF EXISTS notifications;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_locale_check;
+ALTER TABLE users DROP COLUMN IF EXISTS locale;
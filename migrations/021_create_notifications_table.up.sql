@@ -0,0 +1,30 @@
+-- migrations/021_create_notifications_table.up.sql
+
+-- Bahasa yang dipakai untuk notifikasi user
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale VARCHAR(5) NOT NULL DEFAULT 'id';
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_locale_check;
+ALTER TABLE users ADD CONSTRAINT users_locale_check CHECK (locale IN ('id', 'en'));
+
+-- Outbox notifikasi: satu baris per user per channel, dikirim ulang dengan backoff sampai
+-- berhasil atau batas percobaan habis. Baris channel inapp sekaligus menjadi inbox user.
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    channel VARCHAR(20) NOT NULL CHECK (channel IN ('inapp', 'email', 'webhook')),
+    event VARCHAR(50) NOT NULL,
+    locale VARCHAR(5) NOT NULL,
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    data JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'SENT', 'FAILED')),
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    sent_at TIMESTAMPTZ,
+    read_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications(user_id);
+CREATE INDEX IF NOT EXISTS idx_notifications_pending ON notifications(next_attempt_at) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_notifications_inbox ON notifications(user_id, created_at) WHERE channel = 'inapp';
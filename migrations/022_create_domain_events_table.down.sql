@@ -0,0 +1,3 @@
+-- migrations/022_create_domain_events_table.down.sql
+
+DROP TABLE IF EXISTS domain_events;
@@ -0,0 +1,23 @@
+-- migrations/022_create_domain_events_table.up.sql
+
+-- Outbox event domain (perubahan pinjaman dan katalog). Dispatcher mengirim setiap event ke
+-- subscriber in-process dan webhook; delivered_to mencatat subscriber yang sudah berhasil
+-- agar percobaan ulang hanya mengenai yang gagal.
+CREATE TABLE IF NOT EXISTS domain_events (
+    id BIGSERIAL PRIMARY KEY,
+    type VARCHAR(50) NOT NULL,
+    aggregate_type VARCHAR(30) NOT NULL,
+    aggregate_id VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'DISPATCHED', 'FAILED')),
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_to JSONB NOT NULL DEFAULT '[]',
+    last_error TEXT,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    dispatched_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_domain_events_pending ON domain_events(next_attempt_at, id) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_domain_events_aggregate ON domain_events(aggregate_type, aggregate_id);
+CREATE INDEX IF NOT EXISTS idx_domain_events_type ON domain_events(type);
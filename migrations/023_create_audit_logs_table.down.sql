@@ -0,0 +1,5 @@
+-- migrations/023_create_audit_logs_table.down.sql
+
+DELETE FROM role_permissions WHERE permission = 'audit:read';
+DROP TABLE IF EXISTS audit_logs;
+DROP FUNCTION IF EXISTS audit_logs_append_only();
@@ -0,0 +1,13 @@
+-- migrations/024_scope_user_uniqueness_to_active_rows.down.sql
+
+DROP INDEX IF EXISTS idx_publishers_deleted_at;
+DROP INDEX IF EXISTS idx_authors_deleted_at;
+DROP INDEX IF EXISTS idx_books_deleted_at;
+DROP INDEX IF EXISTS idx_users_deleted_at;
+
+DROP INDEX IF EXISTS idx_users_email;
+DROP INDEX IF EXISTS idx_users_username;
+
+-- Gagal jika user aktif dan user di trash memakai username/email yang sama; purge dulu
+ALTER TABLE users ADD CONSTRAINT users_username_key UNIQUE (username);
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
@@ -0,0 +1,15 @@
+-- migrations/024_scope_user_uniqueness_to_active_rows.up.sql
+
+-- Username dan email cukup unik di antara user yang belum dihapus, sehingga user di trash
+-- tidak mengunci keduanya. Pemulihan dari trash memeriksa ulang keunikannya.
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_username_key;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users (username) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email) WHERE deleted_at IS NULL;
+
+-- Daftar trash dan job purge memfilter baris yang sudah dihapus
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_books_deleted_at ON books (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_authors_deleted_at ON authors (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_publishers_deleted_at ON publishers (deleted_at);
@@ -0,0 +1,3 @@
+-- migrations/025_unique_pending_loan_request.down.sql
+
+DROP INDEX IF EXISTS idx_loan_requests_pending;
@@ -0,0 +1,13 @@
+-- migrations/025_unique_pending_loan_request.up.sql
+
+-- Request PENDING ganda yang lolos sebelum pemeriksaan kelayakan dikunci: sisakan yang paling awal
+UPDATE loan_requests r
+SET status = 'CANCELLED'
+WHERE r.status = 'PENDING'
+  AND EXISTS (
+      SELECT 1 FROM loan_requests o
+      WHERE o.user_id = r.user_id AND o.book_id = r.book_id AND o.status = 'PENDING' AND o.id < r.id
+  );
+
+-- Satu request PENDING per anggota per buku, juga jika dua request masuk bersamaan
+CREATE UNIQUE INDEX IF NOT EXISTS idx_loan_requests_pending ON loan_requests (user_id, book_id) WHERE status = 'PENDING';
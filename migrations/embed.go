@@ -0,0 +1,9 @@
+// migrations/embed.go
+package migrations
+
+import "embed"
+
+// Files berisi seluruh file migrasi SQL sehingga binary bisa menjalankan migrasi tanpa source tree
+//
+//go:embed *.sql
+var Files embed.FS
@@ -0,0 +1,82 @@
+// migrator/command.go
+package migrator
+
+import (
+    "errors"
+    "fmt"
+    "io"
+    "strconv"
+)
+
+// Usage describes the migrate subcommands shared by the server binary and libctl
+const Usage = `usage:
+  migrate up               apply all pending migrations
+  migrate down [n]         revert the last n applied migrations (default 1)
+  migrate status           list migrations and whether they are applied
+  migrate create <name>    create an empty NNN_<name>.up.sql / .down.sql pair in migrations/`
+
+var ErrUsage = errors.New(Usage)
+
+// RunCommand menjalankan subcommand up|down|status dan menulis hasilnya ke out
+func RunCommand(m *Migrator, args []string, out io.Writer) error {
+    if len(args) == 0 {
+        return ErrUsage
+    }
+
+    switch args[0] {
+    case "up":
+        applied, err := m.Up()
+        for _, migration := range applied {
+            fmt.Fprintf(out, "Applied %03d_%s\n", migration.Version, migration.Name)
+        }
+        if err != nil {
+            return fmt.Errorf("migration failed: %w", err)
+        }
+        if len(applied) == 0 {
+            fmt.Fprintln(out, "No pending migrations")
+        }
+        return nil
+
+    case "down":
+        steps := 1
+        if len(args) > 1 {
+            n, err := strconv.Atoi(args[1])
+            if err != nil || n < 1 {
+                return fmt.Errorf("invalid number of steps: %s", args[1])
+            }
+            steps = n
+        }
+        reverted, err := m.Down(steps)
+        for _, migration := range reverted {
+            fmt.Fprintf(out, "Reverted %03d_%s\n", migration.Version, migration.Name)
+        }
+        if err != nil {
+            return fmt.Errorf("rollback failed: %w", err)
+        }
+        return nil
+
+    case "status":
+        statuses, err := m.Status()
+        if err != nil {
+            return fmt.Errorf("failed to read migration status: %w", err)
+        }
+        for _, status := range statuses {
+            fmt.Fprintf(out, "%03d_%-45s %s\n", status.Version, status.Name, status.State())
+        }
+        return nil
+    }
+    return ErrUsage
+}
+
+// State returns a short human readable description of the migration status
+func (s Status) State() string {
+    switch {
+    case s.Missing:
+        return "applied, file missing"
+    case s.Drifted:
+        return "applied, CHECKSUM DRIFT"
+    case s.Applied:
+        return "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+    }
+    return "pending"
+}
@@ -0,0 +1,335 @@
+// migrator/migrator.go
+package migrator
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "io/fs"
+    "os"
+    "path/filepath"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "gorm.io/gorm"
+)
+
+// advisoryLockKey serialises migrators running at the same time (mis. beberapa instance yang start bersamaan)
+const advisoryLockKey = 72511
+
+var (
+    ErrChecksumDrift   = errors.New("applied migration was modified after it ran")
+    ErrNoDownMigration = errors.New("migration has no down file")
+    ErrNothingToRevert = errors.New("no applied migrations to revert")
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is one numbered pair of up/down SQL files
+type Migration struct {
+    Version  int64
+    Name     string
+    Up       string
+    Down     string
+    Checksum string // SHA-256 dari isi file up
+}
+
+// SchemaMigration is a row of the schema_migrations table
+type SchemaMigration struct {
+    Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+    Name      string    `gorm:"not null"`
+    Checksum  string    `gorm:"not null"`
+    AppliedAt time.Time `gorm:"not null"`
+}
+
+// Status describes a migration as known by the files and/or the database
+type Status struct {
+    Version   int64
+    Name      string
+    Applied   bool
+    AppliedAt *time.Time
+    Drifted   bool // Checksum file berbeda dengan yang tercatat saat diterapkan
+    Missing   bool // Tercatat di database tapi file-nya tidak ada
+}
+
+type Migrator struct {
+    db         *gorm.DB
+    migrations []Migration
+}
+
+// New reads every NNN_name.up.sql / NNN_name.down.sql file from source
+func New(db *gorm.DB, source fs.FS) (*Migrator, error) {
+    migrations, err := Load(source)
+    if err != nil {
+        return nil, err
+    }
+    return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Load parses the migration files in source, sorted by version
+func Load(source fs.FS) ([]Migration, error) {
+    entries, err := fs.ReadDir(source, ".")
+    if err != nil {
+        return nil, err
+    }
+
+    byVersion := map[int64]*Migration{}
+    for _, entry := range entries {
+        if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+            continue
+        }
+        match := fileName.FindStringSubmatch(entry.Name())
+        if match == nil {
+            return nil, fmt.Errorf("invalid migration file name %q (expected NNN_name.up.sql or NNN_name.down.sql)", entry.Name())
+        }
+
+        version, _ := strconv.ParseInt(match[1], 10, 64)
+        content, err := fs.ReadFile(source, entry.Name())
+        if err != nil {
+            return nil, err
+        }
+
+        migration, ok := byVersion[version]
+        if !ok {
+            migration = &Migration{Version: version, Name: match[2]}
+            byVersion[version] = migration
+        } else if migration.Name != match[2] {
+            return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, migration.Name, match[2])
+        }
+
+        if match[3] == "up" {
+            migration.Up = string(content)
+            sum := sha256.Sum256(content)
+            migration.Checksum = hex.EncodeToString(sum[:])
+        } else {
+            migration.Down = string(content)
+        }
+    }
+
+    migrations := make([]Migration, 0, len(byVersion))
+    for _, migration := range byVersion {
+        if migration.Up == "" {
+            return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+        }
+        migrations = append(migrations, *migration)
+    }
+    sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+    return migrations, nil
+}
+
+func (m *Migrator) ensureTable() error {
+    return m.db.AutoMigrate(&SchemaMigration{})
+}
+
+func (m *Migrator) applied(db *gorm.DB) (map[int64]SchemaMigration, error) {
+    var rows []SchemaMigration
+    if err := db.Order("version").Find(&rows).Error; err != nil {
+        return nil, err
+    }
+    applied := make(map[int64]SchemaMigration, len(rows))
+    for _, row := range rows {
+        applied[row.Version] = row
+    }
+    return applied, nil
+}
+
+// Status returns every known migration, including rows whose file no longer exists
+func (m *Migrator) Status() ([]Status, error) {
+    if err := m.ensureTable(); err != nil {
+        return nil, err
+    }
+    applied, err := m.applied(m.db)
+    if err != nil {
+        return nil, err
+    }
+
+    var statuses []Status
+    for _, migration := range m.migrations {
+        status := Status{Version: migration.Version, Name: migration.Name}
+        if row, ok := applied[migration.Version]; ok {
+            appliedAt := row.AppliedAt
+            status.Applied = true
+            status.AppliedAt = &appliedAt
+            status.Drifted = row.Checksum != migration.Checksum
+            delete(applied, migration.Version)
+        }
+        statuses = append(statuses, status)
+    }
+    for _, row := range applied {
+        appliedAt := row.AppliedAt
+        statuses = append(statuses, Status{Version: row.Version, Name: row.Name, Applied: true, AppliedAt: &appliedAt, Missing: true})
+    }
+    sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+    return statuses, nil
+}
+
+// Verify returns an error when migrations are pending or applied files have drifted.
+// Dipakai saat server start tanpa menjalankan migrasi otomatis.
+func (m *Migrator) Verify() error {
+    statuses, err := m.Status()
+    if err != nil {
+        return err
+    }
+
+    pending := 0
+    for _, status := range statuses {
+        if status.Drifted {
+            return fmt.Errorf("%w: %03d_%s", ErrChecksumDrift, status.Version, status.Name)
+        }
+        if !status.Applied {
+            pending++
+        }
+    }
+    if pending > 0 {
+        return fmt.Errorf("%d pending migration(s), run `migrate up`", pending)
+    }
+    return nil
+}
+
+// Up applies every pending migration in version order, each in its own transaction.
+// Tidak ada yang dijalankan jika ada migrasi terapan yang checksum-nya berubah.
+func (m *Migrator) Up() ([]Migration, error) {
+    if err := m.ensureTable(); err != nil {
+        return nil, err
+    }
+
+    var done []Migration
+    for _, migration := range m.migrations {
+        ran := false
+        err := m.db.Transaction(func(tx *gorm.DB) error {
+            if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", advisoryLockKey).Error; err != nil {
+                return err
+            }
+
+            // Dibaca ulang di dalam lock karena instance lain mungkin baru saja menerapkannya
+            applied, err := m.applied(tx)
+            if err != nil {
+                return err
+            }
+            if err := checkDrift(m.migrations, applied); err != nil {
+                return err
+            }
+            if _, ok := applied[migration.Version]; ok {
+                return nil
+            }
+
+            if err := tx.Exec(migration.Up).Error; err != nil {
+                return fmt.Errorf("apply %03d_%s: %w", migration.Version, migration.Name, err)
+            }
+            ran = true
+            return tx.Create(&SchemaMigration{
+                Version:   migration.Version,
+                Name:      migration.Name,
+                Checksum:  migration.Checksum,
+                AppliedAt: time.Now(),
+            }).Error
+        })
+        if err != nil {
+            return done, err
+        }
+        if ran {
+            done = append(done, migration)
+        }
+    }
+    return done, nil
+}
+
+// Down reverts the latest steps applied migrations using their down files
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+    if err := m.ensureTable(); err != nil {
+        return nil, err
+    }
+
+    var done []Migration
+    for i := 0; i < steps; i++ {
+        var reverted *Migration
+        err := m.db.Transaction(func(tx *gorm.DB) error {
+            if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", advisoryLockKey).Error; err != nil {
+                return err
+            }
+
+            var last SchemaMigration
+            if err := tx.Order("version DESC").First(&last).Error; err != nil {
+                if errors.Is(err, gorm.ErrRecordNotFound) {
+                    return ErrNothingToRevert
+                }
+                return err
+            }
+
+            migration := m.find(last.Version)
+            if migration == nil || strings.TrimSpace(migration.Down) == "" {
+                return fmt.Errorf("%w: %03d_%s", ErrNoDownMigration, last.Version, last.Name)
+            }
+            if migration.Checksum != last.Checksum {
+                return fmt.Errorf("%w: %03d_%s", ErrChecksumDrift, last.Version, last.Name)
+            }
+
+            if err := tx.Exec(migration.Down).Error; err != nil {
+                return fmt.Errorf("revert %03d_%s: %w", migration.Version, migration.Name, err)
+            }
+            reverted = migration
+            return tx.Delete(&SchemaMigration{}, "version = ?", last.Version).Error
+        })
+        if err != nil {
+            if errors.Is(err, ErrNothingToRevert) && len(done) > 0 {
+                return done, nil
+            }
+            return done, err
+        }
+        done = append(done, *reverted)
+    }
+    return done, nil
+}
+
+func (m *Migrator) find(version int64) *Migration {
+    for i := range m.migrations {
+        if m.migrations[i].Version == version {
+            return &m.migrations[i]
+        }
+    }
+    return nil
+}
+
+func checkDrift(migrations []Migration, applied map[int64]SchemaMigration) error {
+    for _, migration := range migrations {
+        if row, ok := applied[migration.Version]; ok && row.Checksum != migration.Checksum {
+            return fmt.Errorf("%w: %03d_%s", ErrChecksumDrift, migration.Version, migration.Name)
+        }
+    }
+    return nil
+}
+
+// Create writes an empty up/down pair for name in dir, numbered after the highest existing version
+func Create(dir, name string) ([]string, error) {
+    name = strings.ToLower(strings.TrimSpace(name))
+    name = regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(name, "_")
+    name = strings.Trim(name, "_")
+    if name == "" {
+        return nil, errors.New("migration name is required")
+    }
+
+    migrations, err := Load(os.DirFS(dir))
+    if err != nil {
+        return nil, err
+    }
+    var next int64 = 1
+    if len(migrations) > 0 {
+        next = migrations[len(migrations)-1].Version + 1
+    }
+
+    var paths []string
+    for _, direction := range []string{"up", "down"} {
+        file := fmt.Sprintf("%03d_%s.%s.sql", next, name, direction)
+        path := filepath.Join(dir, file)
+        content := fmt.Sprintf("-- migrations/%s\n\n", file)
+        if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+            return paths, err
+        }
+        paths = append(paths, path)
+    }
+    return paths, nil
+}
@@ -0,0 +1,27 @@
+// models/audit_log.go
+package models
+
+import (
+    "time"
+    "github.com/google/uuid"
+)
+
+// AuditLog records one change made through the API. Before dan After hanya berisi field yang
+// berubah (nilai lama dan baru); pada create Before kosong, pada delete After kosong.
+// Tabelnya append-only: trigger di migrasi 023 menolak UPDATE, DELETE dan TRUNCATE.
+type AuditLog struct {
+    ID         uint       `gorm:"primaryKey" json:"id"`
+    ActorID    *uuid.UUID `gorm:"type:uuid;index" json:"actor_id"` // nil untuk request tanpa login, mis. register
+    ActorName  string     `json:"actor_name"`
+    Action     string     `gorm:"not null;index" json:"action"` // mis. "book.update" atau "POST /holds/:id"
+    EntityType string     `gorm:"not null" json:"entity_type"`
+    EntityID   string     `json:"entity_id"`
+    Before     JSONMap    `gorm:"not null;default:'{}'" json:"before"`
+    After      JSONMap    `gorm:"not null;default:'{}'" json:"after"`
+    IP         string     `json:"ip"`
+    RequestID  string     `json:"request_id"`
+    Method     string     `gorm:"not null" json:"method"`
+    Path       string     `gorm:"not null" json:"path"`
+    Status     int        `gorm:"not null" json:"status"`
+    CreatedAt  time.Time  `json:"created_at"`
+}
@@ -0,0 +1,31 @@
+// models/auth_token.go
+package models
+
+import (
+    "time"
+    "github.com/google/uuid"
+)
+
+// RefreshToken is one server-side session. The token itself is only stored as a SHA-256 hash.
+// Setiap rotasi membuat baris baru dengan FamilyID yang sama sehingga pemakaian ulang token lama
+// bisa mencabut seluruh rantai sesi.
+type RefreshToken struct {
+    ID              uint       `gorm:"primaryKey" json:"id"`
+    UserID          uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+    FamilyID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"family_id"`
+    TokenHash       string     `gorm:"not null;uniqueIndex" json:"-"`
+    AccessJTI       string     `gorm:"not null;index" json:"-"` // jti access token terakhir yang diterbitkan untuk sesi ini
+    AccessExpiresAt time.Time  `gorm:"not null" json:"-"`
+    ExpiresAt       time.Time  `gorm:"not null" json:"expires_at"`
+    RevokedAt       *time.Time `json:"revoked_at,omitempty"`
+    ReplacedByID    *uint      `json:"replaced_by_id,omitempty"`
+    CreatedAt       time.Time  `json:"created_at"`
+}
+
+// RevokedToken is a denylisted access token ID (jti). Baris boleh dihapus setelah ExpiresAt lewat.
+type RevokedToken struct {
+    JTI       string    `gorm:"primaryKey" json:"jti"`
+    UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+    ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+    CreatedAt time.Time `json:"created_at"`
+}
@@ -4,14 +4,23 @@ package models
 import "gorm.io/gorm"
 
 type Book struct {
-    ID          int     `gorm:"primaryKey" json:"id"`
-    Title       string  `json:"title"`
-    AuthorID    int     `json:"author_id"`
-    PublisherID int     `json:"publisher_id"`
-    Summary     string  `json:"summary"`
-    Stock       int     `json:"stock"`
-    MaxStock    int     `json:"max_stock"`
-    Author      Author  `gorm:"foreignKey:AuthorID"`
-    Publisher   Publisher `gorm:"foreignKey:PublisherID"`
+    ID              int               `gorm:"primaryKey" json:"id"`
+    Title           string            `json:"title"`
+    AuthorID        int               `json:"author_id"` // Penulis utama, disamakan dengan kontributor pertama berperan author
+    PublisherID     int               `json:"publisher_id"`
+    Summary         string            `json:"summary"`
+    Category        string            `gorm:"index" json:"category"` // Dipakai untuk aturan CirculationPolicy
+    ISBN            *string           `gorm:"column:isbn;size:13;uniqueIndex:idx_books_isbn,where:deleted_at IS NULL" json:"isbn"` // Selalu disimpan sebagai ISBN-13
+    PublicationYear *int              `json:"publication_year"`
+    Edition         string            `json:"edition"`
+    Language        string            `gorm:"size:3" json:"language"` // Kode ISO 639-1/639-2, huruf kecil
+    PageCount       *int              `json:"page_count"`
+    Subjects        StringList        `gorm:"not null;default:'[]'" json:"subjects"`
+    CoverImageURL   string            `json:"cover_image_url"`
+    Stock           int               `json:"stock"`     // Jumlah eksemplar AVAILABLE, dihitung dari BookCopy
+    MaxStock        int               `json:"max_stock"` // Jumlah eksemplar yang masih beredar, dihitung dari BookCopy
+    Author          Author            `gorm:"foreignKey:AuthorID"`
+    Contributors    []BookContributor `gorm:"foreignKey:BookID" json:"contributors"`
+    Publisher       Publisher         `gorm:"foreignKey:PublisherID"`
     gorm.Model
 }
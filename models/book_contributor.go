@@ -0,0 +1,33 @@
+// models/book_contributor.go
+package models
+
+import "time"
+
+// Peran kontributor sebuah buku
+const (
+    ContributorRoleAuthor      = "author"
+    ContributorRoleEditor      = "editor"
+    ContributorRoleTranslator  = "translator"
+    ContributorRoleIllustrator = "illustrator"
+)
+
+// BookContributor links a book to an author with a role. Position menentukan urutan
+// tampilan kontributor (0 = pertama).
+type BookContributor struct {
+    ID        uint      `gorm:"primaryKey" json:"id"`
+    BookID    int       `gorm:"not null;uniqueIndex:idx_book_contributor" json:"book_id"`
+    AuthorID  int       `gorm:"not null;index;uniqueIndex:idx_book_contributor" json:"author_id"`
+    Role      string    `gorm:"not null;default:author;uniqueIndex:idx_book_contributor" json:"role"`
+    Position  int       `gorm:"not null;default:0" json:"position"`
+    Author    Author    `gorm:"foreignKey:AuthorID" json:"-"`
+    CreatedAt time.Time `json:"created_at"`
+}
+
+// IsValidContributorRole checks whether the given role is a known contributor role
+func IsValidContributorRole(role string) bool {
+    switch role {
+    case ContributorRoleAuthor, ContributorRoleEditor, ContributorRoleTranslator, ContributorRoleIllustrator:
+        return true
+    }
+    return false
+}
@@ -0,0 +1,36 @@
+// models/book_copy.go
+package models
+
+import "time"
+
+// Status eksemplar buku
+const (
+    CopyStatusAvailable = "AVAILABLE"
+    CopyStatusOnLoan    = "ON_LOAN"
+    CopyStatusOnHold    = "ON_HOLD" // Disisihkan untuk anggota antrean reservasi
+    CopyStatusLost      = "LOST"
+    CopyStatusDamaged   = "DAMAGED"
+    CopyStatusWithdrawn = "WITHDRAWN"
+)
+
+// BookCopy represents a single physical item of a book
+type BookCopy struct {
+    ID              uint      `gorm:"primaryKey" json:"id"`
+    BookID          int       `gorm:"not null;index" json:"book_id"`
+    Barcode         string    `gorm:"unique;not null" json:"barcode"`
+    AcquisitionDate time.Time `json:"acquisition_date"`
+    Condition       string    `json:"condition"` // "NEW", "GOOD", "FAIR", "POOR"
+    ShelfLocation   string    `json:"shelf_location"`
+    Status          string    `gorm:"not null;default:AVAILABLE;index" json:"status"` // "AVAILABLE", "ON_LOAN", "ON_HOLD", "LOST", "DAMAGED", "WITHDRAWN"
+    CreatedAt       time.Time `json:"created_at"`
+    UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// IsValidCopyStatus checks whether the given status is a known copy status
+func IsValidCopyStatus(status string) bool {
+    switch status {
+    case CopyStatusAvailable, CopyStatusOnLoan, CopyStatusOnHold, CopyStatusLost, CopyStatusDamaged, CopyStatusWithdrawn:
+        return true
+    }
+    return false
+}
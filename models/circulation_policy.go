@@ -0,0 +1,22 @@
+// models/circulation_policy.go
+package models
+
+import "time"
+
+// CirculationPolicy holds the borrowing rules for a user role and book category.
+// Role 0 berarti berlaku untuk semua role, Category "" berarti semua kategori.
+type CirculationPolicy struct {
+    ID                 uint      `gorm:"primaryKey" json:"id"`
+    Role               int       `gorm:"not null;default:0;uniqueIndex:idx_policy_role_category" json:"role"`
+    Category           string    `gorm:"not null;default:'';uniqueIndex:idx_policy_role_category" json:"category"`
+    CanBorrow          bool      `gorm:"not null" json:"can_borrow"` // default true ada di migrasi dan DefaultCirculationPolicy
+    LoanPeriodDays     int       `gorm:"not null" json:"loan_period_days"`
+    MaxConcurrentLoans int       `gorm:"not null;default:0" json:"max_concurrent_loans"` // 0 = tanpa batas
+    MaxRenewals        int       `gorm:"not null;default:0" json:"max_renewals"`
+    RenewalPeriodDays  int       `gorm:"not null" json:"renewal_period_days"`
+    DailyFine          int       `gorm:"not null;default:0" json:"daily_fine"`
+    FineCap            int       `gorm:"not null;default:0" json:"fine_cap"` // 0 = tanpa batas
+    GraceDays          int       `gorm:"not null;default:0" json:"grace_days"`
+    CreatedAt          time.Time `json:"created_at"`
+    UpdatedAt          time.Time `json:"updated_at"`
+}
@@ -0,0 +1,29 @@
+// models/domain_event.go
+package models
+
+import "time"
+
+// Status event di outbox
+const (
+    DomainEventStatusPending    = "PENDING"
+    DomainEventStatusDispatched = "DISPATCHED" // Semua subscriber sudah menerima
+    DomainEventStatusFailed     = "FAILED"     // Menyerah setelah MaxAttempts percobaan
+)
+
+// DomainEvent is an event in the outbox. Baris ditulis di transaksi yang sama dengan perubahan
+// yang memicunya dan dikirim dispatcher setelah commit, sehingga event tidak pernah hilang atau
+// terkirim untuk perubahan yang dibatalkan.
+type DomainEvent struct {
+    ID            uint       `gorm:"primaryKey" json:"id"`
+    Type          string     `gorm:"not null;index" json:"type"` // mis. "loan.approved"
+    AggregateType string     `gorm:"not null" json:"aggregate_type"`
+    AggregateID   string     `gorm:"not null" json:"aggregate_id"`
+    Payload       string     `gorm:"type:jsonb;not null" json:"payload"`
+    Status        string     `gorm:"not null;default:PENDING" json:"status"`
+    Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+    NextAttemptAt time.Time  `gorm:"not null" json:"next_attempt_at"`
+    DeliveredTo   StringList `gorm:"not null;default:'[]'" json:"delivered_to"` // Nama subscriber yang sudah berhasil
+    LastError     *string    `json:"last_error,omitempty"`
+    OccurredAt    time.Time  `gorm:"not null" json:"occurred_at"`
+    DispatchedAt  *time.Time `json:"dispatched_at,omitempty"`
+}
@@ -0,0 +1,50 @@
+// models/fine.go
+package models
+
+import (
+    "time"
+    "github.com/google/uuid"
+)
+
+// Status denda
+const (
+    FineStatusOutstanding = "OUTSTANDING"
+    FineStatusPaid        = "PAID"
+    FineStatusWaived      = "WAIVED"
+)
+
+// FineReasonLateReturn menandai denda keterlambatan; satu per pinjaman, diperbarui selama
+// buku belum dikembalikan
+const FineReasonLateReturn = "Late return"
+
+// Fine is a charge on a member's account, usually a late fee for a loan
+type Fine struct {
+    ID           uint      `gorm:"primaryKey" json:"id"`
+    UserID       uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+    LoanRecordID *uint     `gorm:"index" json:"loan_record_id,omitempty"`
+    Amount       int       `gorm:"not null" json:"amount"`
+    PaidAmount   int       `gorm:"not null;default:0" json:"paid_amount"`
+    WaivedAmount int       `gorm:"not null;default:0" json:"waived_amount"`
+    Status       string    `gorm:"not null;index" json:"status"` // "OUTSTANDING", "PAID", "WAIVED"
+    Reason       string    `json:"reason"`
+    WaiveReason  *string   `json:"waive_reason,omitempty"`
+    WaivedBy     *string   `json:"waived_by,omitempty"`
+    CreatedAt    time.Time `json:"created_at"`
+    UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Balance returns the amount still owed on the fine
+func (f *Fine) Balance() int {
+    return f.Amount - f.PaidAmount - f.WaivedAmount
+}
+
+// Payment records money received against a fine
+type Payment struct {
+    ID         uint      `gorm:"primaryKey" json:"id"`
+    FineID     uint      `gorm:"not null;index" json:"fine_id"`
+    UserID     uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+    Amount     int       `gorm:"not null" json:"amount"`
+    Method     string    `json:"method"`      // "CASH", "TRANSFER", dll.
+    ReceivedBy string    `json:"received_by"` // Username admin yang mencatat pembayaran
+    CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,30 @@
+// models/hold.go
+package models
+
+import (
+    "time"
+    "github.com/google/uuid"
+)
+
+// Status antrean reservasi
+const (
+    HoldStatusWaiting   = "WAITING"
+    HoldStatusReady     = "READY"
+    HoldStatusFulfilled = "FULFILLED"
+    HoldStatusExpired   = "EXPIRED"
+    HoldStatusCancelled = "CANCELLED"
+)
+
+// Hold is a member's place in the reservation queue of a book
+type Hold struct {
+    ID        uint       `gorm:"primaryKey" json:"id"`
+    BookID    int        `gorm:"not null;index" json:"book_id"`
+    UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+    Position  int        `gorm:"not null" json:"position"`
+    Status    string     `gorm:"not null;index" json:"status"` // "WAITING", "READY", "FULFILLED", "EXPIRED", "CANCELLED"
+    CopyID    *uint      `json:"copy_id,omitempty"`            // Eksemplar yang disisihkan saat status READY
+    ReadyAt   *time.Time `json:"ready_at,omitempty"`
+    ExpiresAt *time.Time `json:"expires_at,omitempty"`
+    CreatedAt time.Time  `json:"created_at"`
+    UpdatedAt time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,21 @@
+// models/invite.go
+package models
+
+import (
+    "time"
+    "github.com/google/uuid"
+)
+
+// Invite is a single-use invitation to register with a given role.
+// Kode undangan yang diberikan ke invitee adalah token bertanda tangan berisi ID ini.
+type Invite struct {
+    ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+    Role      int        `gorm:"not null" json:"role"`
+    Email     string     `json:"email,omitempty"` // Jika diisi, hanya email ini yang boleh memakai undangan
+    ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+    CreatedBy *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"` // Kosong jika dibuat lewat libctl
+    UsedAt    *time.Time `json:"used_at,omitempty"`
+    UsedBy    *uuid.UUID `gorm:"type:uuid" json:"used_by,omitempty"`
+    RevokedAt *time.Time `json:"revoked_at,omitempty"`
+    CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,39 @@
+// models/json_map.go
+package models
+
+import (
+    "database/sql/driver"
+    "encoding/json"
+    "fmt"
+)
+
+// JSONMap is a JSON object stored as JSONB
+type JSONMap map[string]interface{}
+
+func (m JSONMap) Value() (driver.Value, error) {
+    if m == nil {
+        return "{}", nil
+    }
+    data, err := json.Marshal(map[string]interface{}(m))
+    if err != nil {
+        return nil, err
+    }
+    return string(data), nil
+}
+
+func (m *JSONMap) Scan(value interface{}) error {
+    switch v := value.(type) {
+    case nil:
+        *m = nil
+        return nil
+    case []byte:
+        return json.Unmarshal(v, m)
+    case string:
+        return json.Unmarshal([]byte(v), m)
+    }
+    return fmt.Errorf("cannot scan %T into JSONMap", value)
+}
+
+func (JSONMap) GormDataType() string {
+    return "jsonb"
+}
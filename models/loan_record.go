@@ -7,11 +7,15 @@ import (
 )
 
 type LoanRecord struct {
-    ID         uint       `gorm:"primaryKey" json:"id"`
-    BookID     int        `gorm:"not null" json:"book_id"`
-    UserID     uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
-    LoanDate   time.Time  `json:"loan_date"`
-    DueDate    time.Time  `json:"due_date"`
-    Returned   bool       `json:"returned"`
-    ReturnDate *time.Time `json:"return_date,omitempty"`
+    ID           uint          `gorm:"primaryKey" json:"id"`
+    BookID       int           `gorm:"not null" json:"book_id"`
+    CopyID       *uint         `gorm:"index" json:"copy_id"` // NULL untuk pinjaman lama yang dibuat sebelum ada BookCopy
+    UserID       uuid.UUID     `gorm:"type:uuid;not null" json:"user_id"`
+    LoanDate     time.Time     `json:"loan_date"`
+    DueDate      time.Time     `json:"due_date"`
+    Returned     bool          `json:"returned"`
+    ReturnDate   *time.Time    `json:"return_date,omitempty"`
+    OverdueAt    *time.Time    `json:"overdue_at,omitempty"` // Saat job overdue pertama kali menandai pinjaman ini
+    RenewalCount int           `gorm:"not null;default:0" json:"renewal_count"`
+    Renewals     []LoanRenewal `gorm:"foreignKey:LoanRecordID" json:"renewals,omitempty"`
 }
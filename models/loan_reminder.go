@@ -0,0 +1,31 @@
+// models/loan_reminder.go
+package models
+
+import (
+    "time"
+    "github.com/google/uuid"
+)
+
+// Jenis dan status pengingat pinjaman
+const (
+    ReminderKindDueSoon = "DUE_SOON" // Dikirim beberapa hari sebelum jatuh tempo
+    ReminderKindOverdue = "OVERDUE"  // Eskalasi setelah jatuh tempo, Level 1, 2, ...
+
+    ReminderStatusPending   = "PENDING"
+    ReminderStatusSent      = "SENT" // Sudah diserahkan ke outbox notifikasi
+    ReminderStatusCancelled = "CANCELLED"
+)
+
+// LoanReminder is a queued notice about a loan's due date. Satu pengingat unik per
+// pinjaman, jenis, level dan due date sehingga job boleh dijalankan berulang kali.
+type LoanReminder struct {
+    ID           uint       `gorm:"primaryKey" json:"id"`
+    LoanRecordID uint       `gorm:"not null;uniqueIndex:idx_loan_reminders_unique" json:"loan_record_id"`
+    UserID       uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
+    Kind         string     `gorm:"not null;uniqueIndex:idx_loan_reminders_unique" json:"kind"`
+    Level        int        `gorm:"not null;default:0;uniqueIndex:idx_loan_reminders_unique" json:"level"` // 0 untuk DUE_SOON
+    DueDate      time.Time  `gorm:"not null;uniqueIndex:idx_loan_reminders_unique" json:"due_date"`
+    Status       string     `gorm:"not null;default:PENDING;index" json:"status"`
+    SentAt       *time.Time `json:"sent_at,omitempty"`
+    CreatedAt    time.Time  `json:"created_at"`
+}
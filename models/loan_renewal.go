@@ -0,0 +1,14 @@
+// models/loan_renewal.go
+package models
+
+import "time"
+
+// LoanRenewal records a single due-date extension of a loan
+type LoanRenewal struct {
+    ID              uint      `gorm:"primaryKey" json:"id"`
+    LoanRecordID    uint      `gorm:"not null;index" json:"loan_record_id"`
+    PreviousDueDate time.Time `json:"previous_due_date"`
+    NewDueDate      time.Time `json:"new_due_date"`
+    RenewedBy       string    `json:"renewed_by"` // Username yang melakukan perpanjangan
+    RenewedAt       time.Time `json:"renewed_at"`
+}
@@ -7,10 +7,12 @@ import (
 )
 
 type LoanRequest struct {
-    ID           uint      `gorm:"primaryKey" json:"id"`
-    BookID       int       `gorm:"not null" json:"book_id"`
-    UserID       uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
-    RequestTime  time.Time `json:"request_time"`
-    Status       string    `json:"status"` // "PENDING", "APPROVED", "REJECTED"
-    RejectReason *string   `json:"reject_reason,omitempty"`
+    ID           uint       `gorm:"primaryKey" json:"id"`
+    BookID       int        `gorm:"not null;uniqueIndex:idx_loan_requests_pending,priority:2,where:status = 'PENDING'" json:"book_id"`
+    UserID       uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_loan_requests_pending,priority:1,where:status = 'PENDING'" json:"user_id"` // Satu request PENDING per anggota per buku
+    RequestTime  time.Time  `json:"request_time"`
+    Status       string     `json:"status"` // "PENDING", "APPROVED", "REJECTED", "CANCELLED"
+    RejectReason *string    `json:"reject_reason,omitempty"`
+    RequestedBy  *uuid.UUID `gorm:"type:uuid" json:"requested_by,omitempty"` // Admin yang mengajukan atas nama anggota
+    CancelledBy  *uuid.UUID `gorm:"type:uuid" json:"cancelled_by,omitempty"` // User yang membatalkan request
 }
@@ -0,0 +1,62 @@
+// models/notification.go
+package models
+
+import (
+    "time"
+    "github.com/google/uuid"
+)
+
+// Channel pengiriman notifikasi
+const (
+    NotificationChannelInApp   = "inapp"
+    NotificationChannelEmail   = "email"
+    NotificationChannelWebhook = "webhook"
+)
+
+// Status notifikasi di outbox
+const (
+    NotificationStatusPending = "PENDING"
+    NotificationStatusSent    = "SENT"
+    NotificationStatusFailed  = "FAILED" // Menyerah setelah MaxAttempts percobaan
+)
+
+// Locale yang punya template pesan
+const (
+    LocaleIndonesian = "id"
+    LocaleEnglish    = "en"
+)
+
+// Notification is one message for one user on one channel. Baris dibuat di transaksi yang
+// sama dengan perubahan yang memicunya (outbox), lalu dikirim oleh worker terpisah sehingga
+// kegagalan SMTP atau webhook tidak membatalkan perubahan tersebut.
+type Notification struct {
+    ID            uint       `gorm:"primaryKey" json:"id"`
+    UserID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+    Channel       string     `gorm:"not null" json:"channel"`
+    Event         string     `gorm:"not null" json:"event"` // Nama template, mis. "loan_request_approved"
+    Locale        string     `gorm:"not null" json:"locale"`
+    Subject       string     `gorm:"not null" json:"subject"`
+    Body          string     `gorm:"not null" json:"body"`
+    Data          JSONMap    `gorm:"not null;default:'{}'" json:"data"`
+    Status        string     `gorm:"not null;default:PENDING" json:"status"`
+    Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+    NextAttemptAt time.Time  `gorm:"not null" json:"next_attempt_at"`
+    LastError     *string    `json:"last_error,omitempty"`
+    SentAt        *time.Time `json:"sent_at,omitempty"`
+    ReadAt        *time.Time `json:"read_at,omitempty"` // Hanya untuk channel inapp
+    CreatedAt     time.Time  `json:"created_at"`
+}
+
+// IsValidNotificationChannel checks whether the given channel is known
+func IsValidNotificationChannel(channel string) bool {
+    switch channel {
+    case NotificationChannelInApp, NotificationChannelEmail, NotificationChannelWebhook:
+        return true
+    }
+    return false
+}
+
+// IsSupportedLocale checks whether messages can be rendered in the given locale
+func IsSupportedLocale(locale string) bool {
+    return locale == LocaleIndonesian || locale == LocaleEnglish
+}
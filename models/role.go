@@ -0,0 +1,56 @@
+// models/role.go
+package models
+
+// Permission yang dipakai oleh route
+const (
+    PermCatalogRead   = "catalog:read"
+    PermCatalogWrite  = "catalog:write"
+    PermLoansRequest  = "loans:request" // Mengajukan, membatalkan dan memperpanjang pinjaman sendiri
+    PermLoansRead     = "loans:read"    // Melihat seluruh request dan catatan pinjaman
+    PermLoansApprove  = "loans:approve"
+    PermLoansReturn   = "loans:return"
+    PermLoansManage   = "loans:manage" // Bertindak atas nama anggota lain
+    PermHoldsRequest  = "holds:request"
+    PermHoldsManage   = "holds:manage"
+    PermFinesRead     = "fines:read"
+    PermFinesManage   = "fines:manage"
+    PermPoliciesRead  = "policies:read"
+    PermPoliciesWrite = "policies:write"
+    PermUsersSelf     = "users:self"
+    PermUsersAdmin    = "users:admin"
+    PermAuditRead     = "audit:read" // Membaca audit log
+)
+
+// AllPermissions lists every permission known to the application
+var AllPermissions = []string{
+    PermCatalogRead, PermCatalogWrite,
+    PermLoansRequest, PermLoansRead, PermLoansApprove, PermLoansReturn, PermLoansManage,
+    PermHoldsRequest, PermHoldsManage,
+    PermFinesRead, PermFinesManage,
+    PermPoliciesRead, PermPoliciesWrite,
+    PermUsersSelf, PermUsersAdmin,
+    PermAuditRead,
+}
+
+// Role maps the numeric User.Role to a named set of permissions
+type Role struct {
+    ID          int              `gorm:"primaryKey;autoIncrement:false" json:"id"` // 1 untuk admin, 2 untuk member
+    Name        string           `gorm:"unique;not null" json:"name"`
+    Permissions []RolePermission `gorm:"foreignKey:RoleID" json:"permissions"`
+}
+
+// RolePermission grants a single permission to a role
+type RolePermission struct {
+    RoleID     int    `gorm:"primaryKey" json:"role_id"`
+    Permission string `gorm:"primaryKey" json:"permission"`
+}
+
+// IsValidPermission checks whether the given permission is known
+func IsValidPermission(permission string) bool {
+    for _, p := range AllPermissions {
+        if p == permission {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,39 @@
+// models/string_list.go
+package models
+
+import (
+    "database/sql/driver"
+    "encoding/json"
+    "fmt"
+)
+
+// StringList is a list of strings stored as a JSONB array
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+    if l == nil {
+        return "[]", nil
+    }
+    data, err := json.Marshal([]string(l))
+    if err != nil {
+        return nil, err
+    }
+    return string(data), nil
+}
+
+func (l *StringList) Scan(value interface{}) error {
+    switch v := value.(type) {
+    case nil:
+        *l = nil
+        return nil
+    case []byte:
+        return json.Unmarshal(v, l)
+    case string:
+        return json.Unmarshal([]byte(v), l)
+    }
+    return fmt.Errorf("cannot scan %T into StringList", value)
+}
+
+func (StringList) GormDataType() string {
+    return "jsonb"
+}
@@ -10,10 +10,11 @@ import (
 
 type User struct {
     ID        string         `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-    Username  string         `gorm:"unique;not null" json:"username"`
-    Email     string         `gorm:"unique;not null" json:"email"`
+    Username  string         `gorm:"uniqueIndex:idx_users_username,where:deleted_at IS NULL;not null" json:"username"` // Unik di antara user aktif
+    Email     string         `gorm:"uniqueIndex:idx_users_email,where:deleted_at IS NULL;not null" json:"email"`
     Password  string         `gorm:"not null" json:"-"`
     Role      int            `gorm:"not null;default:2" json:"role"` // 1 untuk admin, 2 untuk member
+    Locale    string         `gorm:"size:5;not null;default:id" json:"locale"` // Bahasa notifikasi: "id" atau "en"
     CreatedAt time.Time      `json:"created_at"`
     UpdatedAt time.Time      `json:"updated_at"`
     DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
@@ -0,0 +1,55 @@
+// notify/notifier.go
+package notify
+
+import (
+    "context"
+    "time"
+    "auth-user-api/config"
+    "auth-user-api/models"
+
+    "github.com/google/uuid"
+)
+
+// Message adalah notifikasi yang sudah dirender dan siap dikirim ke satu user
+type Message struct {
+    ID        uint                   `json:"id"`
+    Event     string                 `json:"event"`
+    Locale    string                 `json:"locale"`
+    Subject   string                 `json:"subject"`
+    Body      string                 `json:"body"`
+    Data      map[string]interface{} `json:"data"`
+    UserID    uuid.UUID              `json:"user_id"`
+    Username  string                 `json:"username"`
+    Email     string                 `json:"email"`
+    CreatedAt time.Time              `json:"created_at"`
+}
+
+// Notifier delivers a message over one channel. Error berarti pengiriman dicoba lagi nanti,
+// jadi implementasi tidak perlu melakukan retry sendiri.
+type Notifier interface {
+    Send(ctx context.Context, msg *Message) error
+}
+
+// InAppNotifier tidak mengirim apa pun: baris notifikasi di database sudah menjadi isi inbox
+// yang dibaca lewat /me/notifications.
+type InAppNotifier struct{}
+
+func (InAppNotifier) Send(ctx context.Context, msg *Message) error {
+    return nil
+}
+
+// NewNotifiers builds a notifier for every channel enabled in cfg
+func NewNotifiers(cfg config.NotificationsConfig) map[string]Notifier {
+    notifiers := map[string]Notifier{}
+    for _, channel := range cfg.ChannelList() {
+        switch channel {
+        case models.NotificationChannelInApp:
+            notifiers[channel] = InAppNotifier{}
+        case models.NotificationChannelEmail:
+            notifiers[channel] = NewSMTPNotifier(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom, cfg.DeliveryTimeout)
+        case models.NotificationChannelWebhook:
+            notifiers[channel] = NewWebhookNotifier(cfg.WebhookURL, cfg.WebhookSecret, cfg.DeliveryTimeout)
+        }
+    }
+    return notifiers
+}
@@ -0,0 +1,105 @@
+// notify/smtp.go
+package notify
+
+import (
+    "context"
+    "crypto/tls"
+    "errors"
+    "fmt"
+    "mime"
+    "mime/quotedprintable"
+    "net"
+    "net/smtp"
+    "strconv"
+    "strings"
+    "time"
+)
+
+var ErrNoRecipient = errors.New("user has no email address")
+
+// SMTPNotifier mengirim notifikasi sebagai email teks UTF-8. STARTTLS dipakai jika server
+// menawarkannya; tanpa Username tidak ada AUTH, sehingga bisa diuji dengan server SMTP
+// palsu lokal (mis. MailHog atau smtp4dev).
+type SMTPNotifier struct {
+    Host     string
+    Port     int
+    Username string
+    Password string
+    From     string
+    Timeout  time.Duration
+}
+
+func NewSMTPNotifier(host string, port int, username, password, from string, timeout time.Duration) *SMTPNotifier {
+    return &SMTPNotifier{Host: host, Port: port, Username: username, Password: password, From: from, Timeout: timeout}
+}
+
+func (n *SMTPNotifier) Send(ctx context.Context, msg *Message) error {
+    if msg.Email == "" {
+        return ErrNoRecipient
+    }
+
+    dialer := net.Dialer{Timeout: n.Timeout}
+    conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(n.Host, strconv.Itoa(n.Port)))
+    if err != nil {
+        return err
+    }
+    if n.Timeout > 0 {
+        conn.SetDeadline(time.Now().Add(n.Timeout))
+    }
+
+    client, err := smtp.NewClient(conn, n.Host)
+    if err != nil {
+        conn.Close()
+        return err
+    }
+    defer client.Close()
+
+    if ok, _ := client.Extension("STARTTLS"); ok {
+        if err := client.StartTLS(&tls.Config{ServerName: n.Host}); err != nil {
+            return err
+        }
+    }
+    if n.Username != "" {
+        if err := client.Auth(smtp.PlainAuth("", n.Username, n.Password, n.Host)); err != nil {
+            return err
+        }
+    }
+
+    if err := client.Mail(n.From); err != nil {
+        return err
+    }
+    if err := client.Rcpt(msg.Email); err != nil {
+        return err
+    }
+    w, err := client.Data()
+    if err != nil {
+        return err
+    }
+    if _, err := w.Write(n.buildEmail(msg)); err != nil {
+        w.Close()
+        return err
+    }
+    if err := w.Close(); err != nil {
+        return err
+    }
+    return client.Quit()
+}
+
+func (n *SMTPNotifier) buildEmail(msg *Message) []byte {
+    var b strings.Builder
+    fmt.Fprintf(&b, "From: %s\r\n", n.From)
+    fmt.Fprintf(&b, "To: %s\r\n", msg.Email)
+    fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+    fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+    fmt.Fprintf(&b, "Message-ID: <notification-%d.%d@%s>\r\n", msg.ID, msg.CreatedAt.Unix(), n.Host)
+    fmt.Fprintf(&b, "Content-Language: %s\r\n", msg.Locale)
+    b.WriteString("MIME-Version: 1.0\r\n")
+    b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+    b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+    b.WriteString("\r\n")
+
+    qp := quotedprintable.NewWriter(&b)
+    qp.Write([]byte(strings.ReplaceAll(msg.Body, "\n", "\r\n")))
+    qp.Close()
+    return []byte(b.String())
+}
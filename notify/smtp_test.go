@@ -0,0 +1,194 @@
+// notify/smtp_test.go
+package notify
+
+import (
+    "bufio"
+    "context"
+    "errors"
+    "io"
+    "mime"
+    "mime/quotedprintable"
+    "net"
+    "net/mail"
+    "net/textproto"
+    "strconv"
+    "strings"
+    "testing"
+    "time"
+)
+
+// receivedMail adalah satu email yang diterima server SMTP palsu
+type receivedMail struct {
+    From string
+    To   []string
+    Data string
+}
+
+// fakeSMTPServer adalah server SMTP minimal di proses yang sama: tanpa STARTTLS dan AUTH,
+// menerima satu email per koneksi. rejectRcpt membuat RCPT TO dibalas 550.
+type fakeSMTPServer struct {
+    listener   net.Listener
+    rejectRcpt bool
+    mails      chan receivedMail
+}
+
+func startFakeSMTPServer(t *testing.T, rejectRcpt bool) *fakeSMTPServer {
+    t.Helper()
+    listener, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatalf("listen: %v", err)
+    }
+    s := &fakeSMTPServer{listener: listener, rejectRcpt: rejectRcpt, mails: make(chan receivedMail, 1)}
+    t.Cleanup(func() { listener.Close() })
+
+    go func() {
+        for {
+            conn, err := listener.Accept()
+            if err != nil {
+                return
+            }
+            go s.serve(conn)
+        }
+    }()
+    return s
+}
+
+func (s *fakeSMTPServer) notifier() *SMTPNotifier {
+    addr := s.listener.Addr().(*net.TCPAddr)
+    return NewSMTPNotifier("127.0.0.1", addr.Port, "", "", "library@example.com", 5*time.Second)
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+    defer conn.Close()
+    conn.SetDeadline(time.Now().Add(5 * time.Second))
+    tp := textproto.NewConn(conn)
+    var mail receivedMail
+
+    tp.PrintfLine("220 localhost fake SMTP ready")
+    for {
+        line, err := tp.ReadLine()
+        if err != nil {
+            return
+        }
+        verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+        switch verb {
+        case "EHLO", "HELO":
+            tp.PrintfLine("250 localhost")
+        case "MAIL":
+            mail.From = addressOf(line)
+            tp.PrintfLine("250 OK")
+        case "RCPT":
+            if s.rejectRcpt {
+                tp.PrintfLine("550 mailbox unavailable")
+                continue
+            }
+            mail.To = append(mail.To, addressOf(line))
+            tp.PrintfLine("250 OK")
+        case "DATA":
+            tp.PrintfLine("354 end data with <CR><LF>.<CR><LF>")
+            data, err := tp.ReadDotBytes()
+            if err != nil {
+                return
+            }
+            mail.Data = string(data)
+            s.mails <- mail
+            tp.PrintfLine("250 OK")
+        case "QUIT":
+            tp.PrintfLine("221 bye")
+            return
+        default:
+            tp.PrintfLine("502 command not implemented")
+        }
+    }
+}
+
+// addressOf mengambil alamat dari "MAIL FROM:<a@b>" atau "RCPT TO:<a@b>"
+func addressOf(line string) string {
+    start, end := strings.Index(line, "<"), strings.LastIndex(line, ">")
+    if start < 0 || end < start {
+        return ""
+    }
+    return line[start+1 : end]
+}
+
+func TestSMTPNotifierSend(t *testing.T) {
+    server := startFakeSMTPServer(t, false)
+
+    msg := &Message{
+        ID:        42,
+        Event:     EventLoanRequestApproved,
+        Locale:    "id",
+        Subject:   "Peminjaman disetujui: Laskar Pelangi",
+        Body:      "Halo budi,\nPinjaman Anda disetujui. Jatuh tempo 2026-10-31.",
+        Username:  "budi",
+        Email:     "budi@example.com",
+        CreatedAt: time.Now(),
+    }
+    if err := server.notifier().Send(context.Background(), msg); err != nil {
+        t.Fatalf("Send: %v", err)
+    }
+
+    var got receivedMail
+    select {
+    case got = <-server.mails:
+    case <-time.After(5 * time.Second):
+        t.Fatal("server did not receive the email")
+    }
+
+    if got.From != "library@example.com" {
+        t.Errorf("MAIL FROM = %q, want library@example.com", got.From)
+    }
+    if len(got.To) != 1 || got.To[0] != "budi@example.com" {
+        t.Errorf("RCPT TO = %v, want [budi@example.com]", got.To)
+    }
+
+    parsed, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(got.Data)))
+    if err != nil {
+        t.Fatalf("parse email: %v", err)
+    }
+    subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+    if err != nil {
+        t.Fatalf("decode subject: %v", err)
+    }
+    if subject != msg.Subject {
+        t.Errorf("Subject = %q, want %q", subject, msg.Subject)
+    }
+    if lang := parsed.Header.Get("Content-Language"); lang != "id" {
+        t.Errorf("Content-Language = %q, want id", lang)
+    }
+    if id := parsed.Header.Get("Message-ID"); !strings.HasPrefix(id, "<notification-"+strconv.Itoa(int(msg.ID))+".") {
+        t.Errorf("Message-ID = %q, want it to name notification %d", id, msg.ID)
+    }
+
+    body, err := io.ReadAll(quotedprintable.NewReader(parsed.Body))
+    if err != nil {
+        t.Fatalf("decode body: %v", err)
+    }
+    // ReadDotBytes sudah mengubah CRLF menjadi LF
+    if got := strings.TrimRight(string(body), "\n"); got != msg.Body {
+        t.Errorf("body = %q, want %q", got, msg.Body)
+    }
+}
+
+func TestSMTPNotifierNoRecipient(t *testing.T) {
+    server := startFakeSMTPServer(t, false)
+
+    err := server.notifier().Send(context.Background(), &Message{ID: 1, Subject: "x", Body: "x"})
+    if !errors.Is(err, ErrNoRecipient) {
+        t.Fatalf("Send without email = %v, want ErrNoRecipient", err)
+    }
+}
+
+func TestSMTPNotifierRejectedRecipient(t *testing.T) {
+    server := startFakeSMTPServer(t, true)
+
+    err := server.notifier().Send(context.Background(), &Message{ID: 1, Subject: "x", Body: "x", Email: "nobody@example.com"})
+    if err == nil {
+        t.Fatal("Send succeeded, want an error for the rejected recipient")
+    }
+    select {
+    case <-server.mails:
+        t.Fatal("server received an email for a rejected recipient")
+    default:
+    }
+}
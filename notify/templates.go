@@ -0,0 +1,116 @@
+// notify/templates.go
+package notify
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+    "text/template"
+)
+
+// Event notifikasi yang punya template
+const (
+    EventLoanRequestApproved = "loan_request_approved"
+    EventLoanRequestRejected = "loan_request_rejected"
+    EventLoanDueSoon         = "loan_due_soon"
+    EventLoanOverdue         = "loan_overdue"
+    EventTest                = "test"
+)
+
+var ErrUnknownTemplate = errors.New("no notification template for this event")
+
+type messageTemplate struct {
+    Subject string
+    Body    string
+}
+
+// templates[event][locale]. Data yang tersedia: username ditambah field event (title,
+// due_date, reason, days_overdue, level).
+var templates = map[string]map[string]messageTemplate{
+    EventLoanRequestApproved: {
+        "id": {
+            Subject: "Peminjaman disetujui: {{.title}}",
+            Body:    "Halo {{.username}},\n\nPermintaan peminjaman \"{{.title}}\" telah disetujui. Silakan ambil bukunya di perpustakaan dan kembalikan paling lambat {{.due_date}}.\n\nSalam,\nPerpustakaan",
+        },
+        "en": {
+            Subject: "Loan approved: {{.title}}",
+            Body:    "Hello {{.username}},\n\nYour request to borrow \"{{.title}}\" has been approved. Please pick up the book at the library and return it by {{.due_date}}.\n\nRegards,\nThe Library",
+        },
+    },
+    EventLoanRequestRejected: {
+        "id": {
+            Subject: "Peminjaman ditolak: {{.title}}",
+            Body:    "Halo {{.username}},\n\nMaaf, permintaan peminjaman \"{{.title}}\" ditolak.{{if .reason}}\nAlasan: {{.reason}}{{end}}\n\nSalam,\nPerpustakaan",
+        },
+        "en": {
+            Subject: "Loan request rejected: {{.title}}",
+            Body:    "Hello {{.username}},\n\nUnfortunately your request to borrow \"{{.title}}\" was rejected.{{if .reason}}\nReason: {{.reason}}{{end}}\n\nRegards,\nThe Library",
+        },
+    },
+    EventLoanDueSoon: {
+        "id": {
+            Subject: "Pengingat: \"{{.title}}\" jatuh tempo {{.due_date}}",
+            Body:    "Halo {{.username}},\n\nBuku \"{{.title}}\" harus dikembalikan paling lambat {{.due_date}}. Kembalikan atau perpanjang sebelum tanggal tersebut agar tidak terkena denda.\n\nSalam,\nPerpustakaan",
+        },
+        "en": {
+            Subject: "Reminder: \"{{.title}}\" is due on {{.due_date}}",
+            Body:    "Hello {{.username}},\n\n\"{{.title}}\" is due on {{.due_date}}. Please return or renew it before then to avoid a late fee.\n\nRegards,\nThe Library",
+        },
+    },
+    EventLoanOverdue: {
+        "id": {
+            Subject: "Terlambat {{.days_overdue}} hari: {{.title}}",
+            Body:    "Halo {{.username}},\n\nBuku \"{{.title}}\" sudah melewati jatuh tempo {{.due_date}} ({{.days_overdue}} hari). Denda keterlambatan terus bertambah sampai buku dikembalikan.{{if gt .level 1}}\nIni adalah peringatan ke-{{.level}}.{{end}}\n\nSalam,\nPerpustakaan",
+        },
+        "en": {
+            Subject: "{{.days_overdue}} days overdue: {{.title}}",
+            Body:    "Hello {{.username}},\n\n\"{{.title}}\" was due on {{.due_date}} and is now {{.days_overdue}} days overdue. Late fees keep accruing until the book is returned.{{if gt .level 1}}\nThis is reminder number {{.level}}.{{end}}\n\nRegards,\nThe Library",
+        },
+    },
+    EventTest: {
+        "id": {
+            Subject: "Notifikasi percobaan",
+            Body:    "Halo {{.username}},\n\nIni adalah notifikasi percobaan dari perpustakaan. Jika pesan ini sampai, channel notifikasi sudah berfungsi.",
+        },
+        "en": {
+            Subject: "Test notification",
+            Body:    "Hello {{.username}},\n\nThis is a test notification from the library. If you received it, the notification channel works.",
+        },
+    },
+}
+
+var parsed = map[string]*template.Template{}
+
+func init() {
+    for event, locales := range templates {
+        for locale, tmpl := range locales {
+            name := event + "." + locale
+            parsed[name+".subject"] = template.Must(template.New(name + ".subject").Option("missingkey=zero").Parse(tmpl.Subject))
+            parsed[name+".body"] = template.Must(template.New(name + ".body").Option("missingkey=zero").Parse(tmpl.Body))
+        }
+    }
+}
+
+// Render renders the subject and body of an event in the given locale
+func Render(event, locale string, data map[string]interface{}) (string, string, error) {
+    subject, ok := parsed[event+"."+locale+".subject"]
+    if !ok {
+        return "", "", fmt.Errorf("%w: %s (%s)", ErrUnknownTemplate, event, locale)
+    }
+    body := parsed[event+"."+locale+".body"]
+
+    var s, b strings.Builder
+    if err := subject.Execute(&s, data); err != nil {
+        return "", "", err
+    }
+    if err := body.Execute(&b, data); err != nil {
+        return "", "", err
+    }
+    return s.String(), b.String(), nil
+}
+
+// HasTemplate reports whether event can be rendered
+func HasTemplate(event string) bool {
+    _, ok := templates[event]
+    return ok
+}
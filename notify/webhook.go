@@ -0,0 +1,56 @@
+// notify/webhook.go
+package notify
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "time"
+    "auth-user-api/events"
+)
+
+// WebhookNotifier mengirim notifikasi sebagai JSON lewat HTTP POST. Status selain 2xx
+// dianggap gagal sehingga dicoba lagi nanti; penerima sebaiknya memakai field id untuk dedup.
+// Jika Secret diisi, request ditandatangani dengan skema yang sama seperti webhook event
+// (events.SetSignature).
+type WebhookNotifier struct {
+    URL    string
+    Secret string
+    Client *http.Client
+}
+
+func NewWebhookNotifier(url, secret string, timeout time.Duration) *WebhookNotifier {
+    return &WebhookNotifier{URL: url, Secret: secret, Client: &http.Client{Timeout: timeout}}
+}
+
+func (n *WebhookNotifier) Send(ctx context.Context, msg *Message) error {
+    payload, err := json.Marshal(msg)
+    if err != nil {
+        return err
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(payload))
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set(events.EventTypeHeader, msg.Event)
+    if n.Secret != "" {
+        events.SetSignature(req.Header, n.Secret, payload)
+    }
+
+    resp, err := n.Client.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+    if resp.StatusCode < 200 || resp.StatusCode > 299 {
+        return fmt.Errorf("webhook responded with %s", resp.Status)
+    }
+    return nil
+}
@@ -0,0 +1,184 @@
+// query/page.go
+package query
+
+import (
+    "encoding/base64"
+    "encoding/json"
+    "fmt"
+    "reflect"
+    "strings"
+
+    "gorm.io/gorm"
+)
+
+// Page describes where a result sits within the full filtered list
+type Page struct {
+    Total      int64
+    Limit      int
+    Offset     int
+    HasNext    bool
+    HasPrev    bool
+    NextCursor string
+    PrevCursor string
+}
+
+// Where applies the filters only. Dipakai juga untuk menghitung total sebelum paginasi.
+func (s *Spec) Where(db *gorm.DB) *gorm.DB {
+    for _, filter := range s.Filters {
+        field := s.schema.Fields[filter.Field]
+        if field.Condition != "" {
+            if filter.Value.(bool) {
+                db = db.Where(field.Condition)
+            } else {
+                db = db.Where("NOT (" + field.Condition + ")")
+            }
+            continue
+        }
+
+        switch filter.Op {
+        case In:
+            db = db.Where(field.Column+" IN ?", filter.Value)
+        case Like:
+            db = db.Where(field.Column+" ILIKE ?", "%"+escapeLike(filter.Value.(string))+"%")
+        default:
+            db = db.Where(field.Column+" "+sqlOps[filter.Op]+" ?", filter.Value)
+        }
+    }
+    return db
+}
+
+var sqlOps = map[Op]string{Eq: "=", Ne: "<>", Gt: ">", Gte: ">=", Lt: "<", Lte: "<="}
+
+func escapeLike(value string) string {
+    return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// Paginate applies the cursor condition, ORDER BY and LIMIT/OFFSET. Satu baris ekstra
+// diambil untuk mengetahui apakah masih ada halaman berikutnya; Finish membuangnya.
+func (s *Spec) Paginate(db *gorm.DB) *gorm.DB {
+    before := s.Cursor != nil && s.Cursor.Before
+
+    if s.Cursor != nil {
+        // Keyset: (a > va) OR (a = va AND b > vb) OR ... dengan arah sesuai urutan
+        var clauses []string
+        var args []interface{}
+        for i, sort := range s.Sort {
+            var parts []string
+            for j := 0; j < i; j++ {
+                parts = append(parts, s.column(s.Sort[j].Field)+" = ?")
+                args = append(args, s.Cursor.Values[j])
+            }
+            op := ">"
+            if sort.Desc != before {
+                op = "<"
+            }
+            parts = append(parts, s.column(sort.Field)+" "+op+" ?")
+            args = append(args, s.Cursor.Values[i])
+            clauses = append(clauses, "("+strings.Join(parts, " AND ")+")")
+        }
+        db = db.Where("("+strings.Join(clauses, " OR ")+")", args...)
+    }
+
+    for _, sort := range s.Sort {
+        direction := " ASC"
+        if sort.Desc != before {
+            direction = " DESC"
+        }
+        db = db.Order(s.column(sort.Field) + direction)
+    }
+    return db.Limit(s.Limit + 1).Offset(s.Offset)
+}
+
+func (s *Spec) column(name string) string {
+    return s.schema.Fields[name].Column
+}
+
+// Finish trims the extra row fetched by Paginate from *rows (pointer to a slice),
+// restores the order of a backwards cursor page and fills in the Page.
+func (s *Spec) Finish(rows interface{}, total int64) (*Page, error) {
+    slice := reflect.ValueOf(rows).Elem()
+    page := &Page{Total: total, Limit: s.Limit, Offset: s.Offset}
+
+    more := slice.Len() > s.Limit
+    if more {
+        slice.Set(slice.Slice(0, s.Limit))
+    }
+    before := s.Cursor != nil && s.Cursor.Before
+    if before {
+        swap := reflect.Swapper(slice.Interface())
+        for i, j := 0, slice.Len()-1; i < j; i, j = i+1, j-1 {
+            swap(i, j)
+        }
+    }
+
+    switch {
+    case s.Cursor == nil:
+        page.HasNext = more
+        page.HasPrev = s.Offset > 0
+    case before:
+        page.HasNext = true
+        page.HasPrev = more
+    default:
+        page.HasNext = more
+        page.HasPrev = true
+    }
+
+    if slice.Len() == 0 {
+        return page, nil
+    }
+    var err error
+    if page.HasNext {
+        if page.NextCursor, err = s.encodeCursor(slice.Index(slice.Len()-1).Interface(), false); err != nil {
+            return nil, err
+        }
+    }
+    if page.HasPrev && s.Cursor != nil {
+        if page.PrevCursor, err = s.encodeCursor(slice.Index(0).Interface(), true); err != nil {
+            return nil, err
+        }
+    }
+    return page, nil
+}
+
+// encodeCursor mengambil nilai field urutan dari representasi JSON baris
+func (s *Spec) encodeCursor(row interface{}, before bool) (string, error) {
+    raw, err := json.Marshal(row)
+    if err != nil {
+        return "", err
+    }
+    var fields map[string]interface{}
+    if err := json.Unmarshal(raw, &fields); err != nil {
+        return "", err
+    }
+
+    cursor := Cursor{Before: before}
+    for _, sort := range s.Sort {
+        key := s.schema.Fields[sort.Field].Key
+        if key == "" {
+            key = sort.Field
+        }
+        value, ok := fields[key]
+        if !ok || value == nil {
+            return "", fmt.Errorf("cannot build cursor: row has no value for %q", key)
+        }
+        cursor.Values = append(cursor.Values, value)
+    }
+
+    encoded, err := json.Marshal(cursor)
+    if err != nil {
+        return "", err
+    }
+    return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+func decodeCursor(value string) (*Cursor, error) {
+    raw, err := base64.RawURLEncoding.DecodeString(value)
+    if err != nil {
+        return nil, ErrInvalidCursor
+    }
+    cursor := &Cursor{}
+    if err := json.Unmarshal(raw, cursor); err != nil || len(cursor.Values) == 0 {
+        return nil, ErrInvalidCursor
+    }
+    return cursor, nil
+}
@@ -0,0 +1,325 @@
+// query/query.go
+package query
+
+import (
+    "errors"
+    "fmt"
+    "net/url"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/google/uuid"
+)
+
+const (
+    DefaultLimit = 20
+    MaxLimit     = 100
+)
+
+var (
+    ErrInvalidQuery  = errors.New("invalid query parameter")
+    ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// FieldType menentukan bagaimana nilai filter/cursor di-parse sebelum dikirim ke database
+type FieldType int
+
+const (
+    String FieldType = iota
+    Int
+    Float
+    Bool
+    Time
+    UUID
+)
+
+// Op is a filter comparison, written as field[op]=value in the query string (default eq)
+type Op string
+
+const (
+    Eq   Op = "eq"
+    Ne   Op = "ne"
+    Gt   Op = "gt"
+    Gte  Op = "gte"
+    Lt   Op = "lt"
+    Lte  Op = "lte"
+    In   Op = "in"
+    Like Op = "like"
+)
+
+var typeOps = map[FieldType][]Op{
+    String: {Eq, Ne, In, Like},
+    Int:    {Eq, Ne, Gt, Gte, Lt, Lte, In},
+    Float:  {Gt, Gte, Lt, Lte},
+    Bool:   {Eq},
+    Time:   {Eq, Gt, Gte, Lt, Lte},
+    UUID:   {Eq, Ne, In},
+}
+
+// Field describes one filterable and/or sortable field of a list endpoint
+type Field struct {
+    Column     string    // Kolom atau ekspresi SQL, mis. "books.author_id"
+    Type       FieldType
+    Sortable   bool
+    Filterable bool
+    // Condition membuat field Bool menjadi flag turunan: true memakai kondisi ini,
+    // false memakai negasinya (mis. in_stock -> "books.stock > 0")
+    Condition string
+    // Normalize (opsional) menormalkan nilai filter String sebelum dipakai, mis. ISBN
+    Normalize func(string) (string, error)
+    // Key adalah nama field di JSON baris hasil, dipakai untuk membangun cursor.
+    // Kosong berarti sama dengan nama parameter.
+    Key string
+}
+
+// Schema is the whitelist of fields a list endpoint accepts. Parameter yang tidak
+// dikenal ditolak agar salah ketik tidak diam-diam mengembalikan semua data.
+type Schema struct {
+    Fields      map[string]Field
+    DefaultSort []Sort
+    Tiebreaker  string // Field unik yang selalu ditambahkan ke urutan agar cursor stabil
+}
+
+type Sort struct {
+    Field string
+    Desc  bool
+}
+
+type Filter struct {
+    Field string
+    Op    Op
+    Value interface{} // Sudah di-parse sesuai FieldType; []interface{} untuk In
+}
+
+// Spec is a parsed list request that repositories apply to their queries
+type Spec struct {
+    Limit   int
+    Offset  int
+    Cursor  *Cursor
+    Sort    []Sort
+    Filters []Filter
+    schema  *Schema
+}
+
+// Cursor menunjuk posisi baris terakhir (atau pertama jika Before) dari halaman sebelumnya
+type Cursor struct {
+    Values []interface{} `json:"v"`
+    Before bool          `json:"b,omitempty"`
+}
+
+var filterParam = regexp.MustCompile(`^([a-z_]+)(?:\[([a-z]+)\])?$`)
+
+// Parse builds a Spec from query parameters:
+//
+//    limit=20&offset=40            halaman berbasis offset
+//    cursor=<opaque>               halaman berbasis cursor (offset diabaikan)
+//    sort=-due_date,id             urutan multi-field, "-" untuk descending
+//    author_id=3                   filter eq
+//    due_date[gte]=2024-01-01      filter dengan operator
+//    status[in]=PENDING,APPROVED   filter in, dipisah koma
+func Parse(values url.Values, schema *Schema) (*Spec, error) {
+    spec := &Spec{Limit: DefaultLimit, schema: schema}
+
+    for key, vals := range values {
+        value := vals[len(vals)-1]
+        switch key {
+        case "limit":
+            limit, err := strconv.Atoi(value)
+            if err != nil || limit < 1 || limit > MaxLimit {
+                return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, MaxLimit)
+            }
+            spec.Limit = limit
+        case "offset":
+            offset, err := strconv.Atoi(value)
+            if err != nil || offset < 0 {
+                return nil, fmt.Errorf("%w: offset must be a non-negative integer", ErrInvalidQuery)
+            }
+            spec.Offset = offset
+        case "cursor":
+            if value == "" {
+                continue
+            }
+            cursor, err := decodeCursor(value)
+            if err != nil {
+                return nil, err
+            }
+            spec.Cursor = cursor
+        case "sort":
+            sorts, err := parseSort(value, schema)
+            if err != nil {
+                return nil, err
+            }
+            spec.Sort = sorts
+        default:
+            filter, err := parseFilter(key, value, schema)
+            if err != nil {
+                return nil, err
+            }
+            spec.Filters = append(spec.Filters, filter)
+        }
+    }
+
+    if len(spec.Sort) == 0 {
+        spec.Sort = append(spec.Sort, schema.DefaultSort...)
+    }
+    if !spec.sortsBy(schema.Tiebreaker) {
+        spec.Sort = append(spec.Sort, Sort{Field: schema.Tiebreaker})
+    }
+    if spec.Cursor != nil {
+        spec.Offset = 0
+        if len(spec.Cursor.Values) != len(spec.Sort) {
+            return nil, fmt.Errorf("%w: cursor does not match sort", ErrInvalidCursor)
+        }
+        // Nilai cursor dari JSON di-parse ulang sesuai tipe field urutannya
+        for i, sort := range spec.Sort {
+            value, err := convert(schema.Fields[sort.Field].Type, spec.Cursor.Values[i])
+            if err != nil {
+                return nil, ErrInvalidCursor
+            }
+            spec.Cursor.Values[i] = value
+        }
+    }
+    return spec, nil
+}
+
+func (s *Spec) sortsBy(field string) bool {
+    for _, sort := range s.Sort {
+        if sort.Field == field {
+            return true
+        }
+    }
+    return false
+}
+
+// SortParam renders the effective sort back into sort= syntax, dipakai saat membangun link
+func (s *Spec) SortParam() string {
+    parts := make([]string, len(s.Sort))
+    for i, sort := range s.Sort {
+        parts[i] = sort.Field
+        if sort.Desc {
+            parts[i] = "-" + sort.Field
+        }
+    }
+    return strings.Join(parts, ",")
+}
+
+func parseSort(value string, schema *Schema) ([]Sort, error) {
+    var sorts []Sort
+    seen := map[string]bool{}
+    for _, part := range strings.Split(value, ",") {
+        part = strings.TrimSpace(part)
+        if part == "" {
+            continue
+        }
+        sort := Sort{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+        field, ok := schema.Fields[sort.Field]
+        if !ok || !field.Sortable {
+            return nil, fmt.Errorf("%w: cannot sort by %q", ErrInvalidQuery, sort.Field)
+        }
+        if seen[sort.Field] {
+            return nil, fmt.Errorf("%w: %q appears twice in sort", ErrInvalidQuery, sort.Field)
+        }
+        seen[sort.Field] = true
+        sorts = append(sorts, sort)
+    }
+    return sorts, nil
+}
+
+func parseFilter(key, value string, schema *Schema) (Filter, error) {
+    match := filterParam.FindStringSubmatch(key)
+    if match == nil {
+        return Filter{}, fmt.Errorf("%w: unknown parameter %q", ErrInvalidQuery, key)
+    }
+    name, op := match[1], Op(match[2])
+    if op == "" {
+        op = Eq
+    }
+
+    field, ok := schema.Fields[name]
+    if !ok || !field.Filterable {
+        return Filter{}, fmt.Errorf("%w: cannot filter by %q", ErrInvalidQuery, name)
+    }
+    if !allowed(field, op) {
+        return Filter{}, fmt.Errorf("%w: operator %q is not supported for %q", ErrInvalidQuery, op, name)
+    }
+
+    if op == In {
+        var list []interface{}
+        for _, item := range strings.Split(value, ",") {
+            parsed, err := convertField(field, strings.TrimSpace(item))
+            if err != nil {
+                return Filter{}, fmt.Errorf("%w: %s: %v", ErrInvalidQuery, name, err)
+            }
+            list = append(list, parsed)
+        }
+        return Filter{Field: name, Op: op, Value: list}, nil
+    }
+
+    parsed, err := convertField(field, value)
+    if err != nil {
+        return Filter{}, fmt.Errorf("%w: %s: %v", ErrInvalidQuery, name, err)
+    }
+    return Filter{Field: name, Op: op, Value: parsed}, nil
+}
+
+func convertField(field Field, value string) (interface{}, error) {
+    if field.Normalize != nil {
+        normalized, err := field.Normalize(value)
+        if err != nil {
+            return nil, err
+        }
+        value = normalized
+    }
+    return convert(field.Type, value)
+}
+
+func allowed(field Field, op Op) bool {
+    for _, candidate := range typeOps[field.Type] {
+        if candidate == op {
+            return true
+        }
+    }
+    return false
+}
+
+// convert mem-parse nilai dari query string atau cursor JSON ke tipe Go yang sesuai
+func convert(t FieldType, raw interface{}) (interface{}, error) {
+    var value string
+    switch v := raw.(type) {
+    case string:
+        value = v
+    case float64:
+        value = strconv.FormatFloat(v, 'f', -1, 64)
+    case bool:
+        value = strconv.FormatBool(v)
+    default:
+        return nil, fmt.Errorf("unsupported value %v", raw)
+    }
+
+    switch t {
+    case Int:
+        return strconv.ParseInt(value, 10, 64)
+    case Float:
+        return strconv.ParseFloat(value, 64)
+    case Bool:
+        return strconv.ParseBool(value)
+    case Time:
+        if parsed, err := time.Parse(time.RFC3339Nano, value); err == nil {
+            return parsed, nil
+        }
+        parsed, err := time.Parse("2006-01-02", value)
+        if err != nil {
+            return nil, errors.New("expected RFC3339 timestamp or YYYY-MM-DD date")
+        }
+        return parsed, nil
+    case UUID:
+        parsed, err := uuid.Parse(value)
+        if err != nil {
+            return nil, err
+        }
+        return parsed.String(), nil
+    }
+    return value, nil
+}
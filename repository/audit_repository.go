@@ -0,0 +1,36 @@
+// repository/audit_repository.go
+package repository
+
+import (
+    "auth-user-api/models"
+    "auth-user-api/query"
+
+    "gorm.io/gorm"
+)
+
+// AuditRepository hanya bisa menambah dan membaca; audit log tidak pernah diubah atau dihapus
+type AuditRepository interface {
+    CreateAuditLog(entry *models.AuditLog) error
+    GetAuditLogs(spec *query.Spec) ([]*models.AuditLog, *query.Page, error)
+}
+
+type auditRepository struct {
+    db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+    return &auditRepository{db}
+}
+
+func (r *auditRepository) CreateAuditLog(entry *models.AuditLog) error {
+    return r.db.Create(entry).Error
+}
+
+func (r *auditRepository) GetAuditLogs(spec *query.Spec) ([]*models.AuditLog, *query.Page, error) {
+    var entries []*models.AuditLog
+    page, err := findPage(r.db.Model(&models.AuditLog{}), spec, &entries)
+    if err != nil {
+        return nil, nil, err
+    }
+    return entries, page, nil
+}
@@ -0,0 +1,127 @@
+// repository/auth_token_repository.go
+package repository
+
+import (
+    "time"
+    "auth-user-api/models"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+type AuthTokenRepository interface {
+    CreateRefreshToken(token *models.RefreshToken) error
+    GetRefreshTokenByHash(hash string) (*models.RefreshToken, error)
+    GetRefreshTokenByAccessJTI(jti string) (*models.RefreshToken, error)
+    RotateRefreshToken(old *models.RefreshToken, next *models.RefreshToken) error
+    RevokeFamily(familyID uuid.UUID) error
+    RevokeAllForUser(userID uuid.UUID) error
+    RevokeJTI(token *models.RevokedToken) error
+    IsJTIRevoked(jti string) (bool, error)
+    PurgeExpired(now time.Time) error
+}
+
+type authTokenRepository struct {
+    db *gorm.DB
+}
+
+func NewAuthTokenRepository(db *gorm.DB) AuthTokenRepository {
+    return &authTokenRepository{db}
+}
+
+func (r *authTokenRepository) CreateRefreshToken(token *models.RefreshToken) error {
+    return r.db.Create(token).Error
+}
+
+func (r *authTokenRepository) GetRefreshTokenByHash(hash string) (*models.RefreshToken, error) {
+    var token models.RefreshToken
+    if err := r.db.Where("token_hash = ?", hash).First(&token).Error; err != nil {
+        return nil, err
+    }
+    return &token, nil
+}
+
+func (r *authTokenRepository) GetRefreshTokenByAccessJTI(jti string) (*models.RefreshToken, error) {
+    var token models.RefreshToken
+    if err := r.db.Where("access_jti = ?", jti).First(&token).Error; err != nil {
+        return nil, err
+    }
+    return &token, nil
+}
+
+// RotateRefreshToken mencabut token lama dan menyimpan penggantinya dalam satu transaksi.
+// Jika token lama sudah dicabut oleh request lain, ErrRowConflict dikembalikan.
+func (r *authTokenRepository) RotateRefreshToken(old *models.RefreshToken, next *models.RefreshToken) error {
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Create(next).Error; err != nil {
+            return err
+        }
+
+        result := tx.Model(&models.RefreshToken{}).
+            Where("id = ? AND revoked_at IS NULL", old.ID).
+            Updates(map[string]interface{}{"revoked_at": time.Now(), "replaced_by_id": next.ID})
+        if result.Error != nil {
+            return result.Error
+        }
+        if result.RowsAffected == 0 {
+            return ErrRowConflict
+        }
+        return nil
+    })
+}
+
+// RevokeFamily mencabut semua refresh token dalam satu rantai rotasi beserta access token terakhirnya
+func (r *authTokenRepository) RevokeFamily(familyID uuid.UUID) error {
+    return r.revokeWhere("family_id", familyID)
+}
+
+// RevokeAllForUser mencabut semua sesi milik user ("log out all sessions")
+func (r *authTokenRepository) RevokeAllForUser(userID uuid.UUID) error {
+    return r.revokeWhere("user_id", userID)
+}
+
+// revokeWhere mencabut sesi yang cocok dan memasukkan access token yang masih berlaku ke denylist,
+// termasuk access token dari rotasi sebelumnya yang belum kedaluwarsa
+func (r *authTokenRepository) revokeWhere(column string, value interface{}) error {
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        now := time.Now()
+        var sessions []models.RefreshToken
+        if err := tx.Where(column+" = ? AND (revoked_at IS NULL OR access_expires_at > ?)", value, now).Find(&sessions).Error; err != nil {
+            return err
+        }
+
+        for _, session := range sessions {
+            if session.AccessExpiresAt.After(now) {
+                denied := models.RevokedToken{JTI: session.AccessJTI, UserID: session.UserID, ExpiresAt: session.AccessExpiresAt}
+                if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&denied).Error; err != nil {
+                    return err
+                }
+            }
+        }
+
+        return tx.Model(&models.RefreshToken{}).
+            Where(column+" = ? AND revoked_at IS NULL", value).
+            Update("revoked_at", now).Error
+    })
+}
+
+func (r *authTokenRepository) RevokeJTI(token *models.RevokedToken) error {
+    return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(token).Error
+}
+
+func (r *authTokenRepository) IsJTIRevoked(jti string) (bool, error) {
+    var count int64
+    if err := r.db.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+        return false, err
+    }
+    return count > 0, nil
+}
+
+// PurgeExpired menghapus jti dan refresh token yang sudah kedaluwarsa
+func (r *authTokenRepository) PurgeExpired(now time.Time) error {
+    if err := r.db.Where("expires_at < ?", now).Delete(&models.RevokedToken{}).Error; err != nil {
+        return err
+    }
+    return r.db.Where("expires_at < ?", now).Delete(&models.RefreshToken{}).Error
+}
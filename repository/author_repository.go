@@ -4,15 +4,28 @@ package repository
 import (
     "errors"
     "auth-user-api/models"
+    "auth-user-api/query"
     "gorm.io/gorm"
 )
 
 type AuthorRepository interface {
     CreateAuthor(author *models.Author) error
     GetAuthorByID(id int) (*models.Author, error)
-    GetAllAuthors() ([]*models.Author, error)
+    GetAllAuthors(spec *query.Spec) ([]*models.Author, *query.Page, error)
     UpdateAuthor(author *models.Author) error
     DeleteAuthor(id int) error
+    CountBooks(authorID int) (int64, error)
+    GetContributions(authorIDs []int) (map[int][]AuthorContribution, error)
+    AppendEvent(event *models.DomainEvent) error
+    Transaction(fn func(tx AuthorRepository) error) error
+}
+
+// AuthorContribution is a book an author contributed to, with their role on it
+type AuthorContribution struct {
+    AuthorID int    `json:"-"`
+    BookID   int    `json:"book_id"`
+    Title    string `json:"title"`
+    Role     string `json:"role"`
 }
 
 type authorRepository struct {
@@ -23,6 +36,12 @@ func NewAuthorRepository(db *gorm.DB) AuthorRepository {
     return &authorRepository{db}
 }
 
+func (r *authorRepository) Transaction(fn func(tx AuthorRepository) error) error {
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        return fn(&authorRepository{tx})
+    })
+}
+
 func (r *authorRepository) CreateAuthor(author *models.Author) error {
     return r.db.Create(author).Error
 }
@@ -35,24 +54,28 @@ func (r *authorRepository) GetAuthorByID(id int) (*models.Author, error) {
     return &author, nil
 }
 
-func (r *authorRepository) GetAllAuthors() ([]*models.Author, error) {
-    var authors []models.Author
-    if err := r.db.Find(&authors).Error; err != nil {
-        return nil, err
+func (r *authorRepository) GetAllAuthors(spec *query.Spec) ([]*models.Author, *query.Page, error) {
+    var authors []*models.Author
+    page, err := findPage(r.db.Model(&models.Author{}), spec, &authors)
+    if err != nil {
+        return nil, nil, err
     }
-
-    var authorPointers []*models.Author
-    for i := range authors {
-        authorPointers = append(authorPointers, &authors[i])
-    }
-
-    return authorPointers, nil
+    return authors, page, nil
 }
 
 func (r *authorRepository) UpdateAuthor(author *models.Author) error {
     return r.db.Save(author).Error
 }
 
+// CountBooks menghitung buku (yang belum dihapus) dengan author sebagai penulis utama atau kontributor
+func (r *authorRepository) CountBooks(authorID int) (int64, error) {
+    var count int64
+    err := r.db.Model(&models.Book{}).
+        Where("books.author_id = ? OR EXISTS (SELECT 1 FROM book_contributors bc WHERE bc.book_id = books.id AND bc.author_id = ?)", authorID, authorID).
+        Count(&count).Error
+    return count, err
+}
+
 func (r *authorRepository) DeleteAuthor(id int) error {
     result := r.db.Delete(&models.Author{}, id)
     if result.Error != nil {
@@ -63,3 +86,28 @@ func (r *authorRepository) DeleteAuthor(id int) error {
     }
     return nil
 }
+
+// GetContributions mengelompokkan buku (yang belum dihapus) per author untuk daftar authorIDs
+func (r *authorRepository) GetContributions(authorIDs []int) (map[int][]AuthorContribution, error) {
+    contributions := make(map[int][]AuthorContribution, len(authorIDs))
+    if len(authorIDs) == 0 {
+        return contributions, nil
+    }
+
+    var rows []AuthorContribution
+    err := r.db.Raw(`
+        SELECT bc.author_id, bc.book_id, b.title, bc.role
+        FROM book_contributors bc
+        JOIN books b ON b.id = bc.book_id AND b.deleted_at IS NULL
+        WHERE bc.author_id IN ?
+        ORDER BY b.title, bc.book_id, bc.role
+    `, authorIDs).Scan(&rows).Error
+    if err != nil {
+        return nil, err
+    }
+
+    for _, row := range rows {
+        contributions[row.AuthorID] = append(contributions[row.AuthorID], row)
+    }
+    return contributions, nil
+}
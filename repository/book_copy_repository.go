@@ -0,0 +1,290 @@
+// repository/book_copy_repository.go
+package repository
+
+import (
+    "errors"
+    "fmt"
+    "time"
+    "auth-user-api/models"
+
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+type BookCopyRepository interface {
+    CreateCopy(bookCopy *models.BookCopy) error
+    GetCopyByID(id uint) (*models.BookCopy, error)
+    GetCopiesByBookID(bookID int) ([]*models.BookCopy, error)
+    LockCopy(id uint) (*models.BookCopy, error)
+    UpdateCopy(bookCopy *models.BookCopy) error
+    GetLoanHistory(copyID uint) ([]*models.LoanRecord, error)
+    BackfillCopies() error
+    ReconcileStock(bookID int) (*StockReconciliation, error)
+    GetAllBookIDs() ([]int, error)
+    Transaction(fn func(tx BookCopyRepository) error) error
+}
+
+// StockReconciliation melaporkan perbaikan status eksemplar yang dilakukan ReconcileStock
+type StockReconciliation struct {
+    BookID       int `json:"book_id"`
+    MarkedOnLoan int `json:"marked_on_loan"` // Eksemplar dengan pinjaman aktif yang statusnya belum ON_LOAN
+    Released     int `json:"released"`       // Eksemplar ON_LOAN tanpa pinjaman aktif yang dikembalikan ke rak
+    Stock        int `json:"stock"`
+    MaxStock     int `json:"max_stock"`
+}
+
+type bookCopyRepository struct {
+    db *gorm.DB
+}
+
+func NewBookCopyRepository(db *gorm.DB) BookCopyRepository {
+    return &bookCopyRepository{db}
+}
+
+func (r *bookCopyRepository) Transaction(fn func(tx BookCopyRepository) error) error {
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        return fn(&bookCopyRepository{tx})
+    })
+}
+
+func (r *bookCopyRepository) CreateCopy(bookCopy *models.BookCopy) error {
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        // Kunci buku sebelum insert: foreign key sudah mengambil KEY SHARE pada baris buku,
+        // dan menaikkannya ke FOR UPDATE setelah itu bisa deadlock dengan insert lain
+        if err := lockBookRow(tx, bookCopy.BookID); err != nil {
+            return err
+        }
+        if err := tx.Create(bookCopy).Error; err != nil {
+            return err
+        }
+        return syncBookStock(tx, bookCopy.BookID)
+    })
+}
+
+func (r *bookCopyRepository) GetCopyByID(id uint) (*models.BookCopy, error) {
+    var bookCopy models.BookCopy
+    if err := r.db.First(&bookCopy, "id = ?", id).Error; err != nil {
+        return nil, err
+    }
+    return &bookCopy, nil
+}
+
+func (r *bookCopyRepository) GetCopiesByBookID(bookID int) ([]*models.BookCopy, error) {
+    var copies []*models.BookCopy
+    if err := r.db.Where("book_id = ?", bookID).Order("id").Find(&copies).Error; err != nil {
+        return nil, err
+    }
+    return copies, nil
+}
+
+// LockCopy mengunci baris buku lalu baris eksemplar (urutan yang sama dengan alur peminjaman)
+// sehingga status yang dibaca tidak berubah sampai transaksi selesai. Must be called inside Transaction.
+func (r *bookCopyRepository) LockCopy(id uint) (*models.BookCopy, error) {
+    var bookCopy models.BookCopy
+    if err := r.db.Select("id", "book_id").First(&bookCopy, "id = ?", id).Error; err != nil {
+        return nil, err
+    }
+    if err := lockBookRow(r.db, bookCopy.BookID); err != nil {
+        return nil, err
+    }
+    if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&bookCopy, "id = ?", id).Error; err != nil {
+        return nil, err
+    }
+    return &bookCopy, nil
+}
+
+// UpdateCopy menyimpan kolom yang boleh diubah admin (barcode, kondisi, lokasi rak dan status)
+// lalu menghitung ulang stok. book_id tidak pernah ditulis ulang. Panggil di dalam Transaction
+// setelah LockCopy, dengan status yang sudah diperiksa terhadap baris yang dikunci.
+func (r *bookCopyRepository) UpdateCopy(bookCopy *models.BookCopy) error {
+    err := r.db.Model(bookCopy).
+        Select("barcode", "condition", "shelf_location", "status", "updated_at").
+        Updates(bookCopy).Error
+    if err != nil {
+        return err
+    }
+    return syncBookStock(r.db, bookCopy.BookID)
+}
+
+// GetLoanHistory retrieves every loan record that used the given copy, newest first
+func (r *bookCopyRepository) GetLoanHistory(copyID uint) ([]*models.LoanRecord, error) {
+    var records []*models.LoanRecord
+    if err := r.db.Where("copy_id = ?", copyID).Order("loan_date DESC").Find(&records).Error; err != nil {
+        return nil, err
+    }
+    return records, nil
+}
+
+// BackfillCopies membuat eksemplar untuk buku lama yang belum punya BookCopy,
+// berdasarkan nilai stock dan max_stock yang tersimpan sebelumnya.
+func (r *bookCopyRepository) BackfillCopies() error {
+    var books []models.Book
+    query := `
+        SELECT b.* FROM books b
+        WHERE b.deleted_at IS NULL AND b.max_stock > 0
+          AND NOT EXISTS (SELECT 1 FROM book_copies bc WHERE bc.book_id = b.id);
+    `
+    if err := r.db.Raw(query).Scan(&books).Error; err != nil {
+        return err
+    }
+
+    for _, book := range books {
+        err := r.db.Transaction(func(tx *gorm.DB) error {
+            onLoan := book.MaxStock - book.Stock
+            if onLoan < 0 {
+                onLoan = 0
+            }
+            if err := createCopies(tx, book.ID, book.MaxStock); err != nil {
+                return err
+            }
+            // Tandai eksemplar yang sedang dipinjam sesuai selisih stok lama
+            if onLoan > 0 {
+                var ids []uint
+                if err := tx.Model(&models.BookCopy{}).Where("book_id = ?", book.ID).
+                    Order("id").Limit(onLoan).Pluck("id", &ids).Error; err != nil {
+                    return err
+                }
+                if err := tx.Model(&models.BookCopy{}).Where("id IN ?", ids).
+                    Update("status", models.CopyStatusOnLoan).Error; err != nil {
+                    return err
+                }
+            }
+            return syncBookStock(tx, book.ID)
+        })
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// ReconcileStock menyamakan status eksemplar dengan catatan pinjaman yang belum dikembalikan,
+// lalu menghitung ulang stock dan max_stock. Pinjaman lama tanpa copy_id tetap dihitung
+// dengan menyisakan eksemplar ON_LOAN yang tidak terhubung ke pinjaman mana pun.
+func (r *bookCopyRepository) ReconcileStock(bookID int) (*StockReconciliation, error) {
+    result := &StockReconciliation{BookID: bookID}
+
+    err := r.db.Transaction(func(tx *gorm.DB) error {
+        var book models.Book
+        if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&book, "id = ?", bookID).Error; err != nil {
+            return err
+        }
+
+        marked := tx.Model(&models.BookCopy{}).
+            Where("book_id = ? AND status NOT IN (?, ?)", bookID, models.CopyStatusOnLoan, models.CopyStatusLost).
+            Where("EXISTS (SELECT 1 FROM loan_records lr WHERE lr.copy_id = book_copies.id AND lr.returned = false)").
+            Updates(map[string]interface{}{"status": models.CopyStatusOnLoan, "updated_at": gorm.Expr("NOW()")})
+        if marked.Error != nil {
+            return marked.Error
+        }
+        result.MarkedOnLoan = int(marked.RowsAffected)
+
+        var untrackedLoans int64
+        if err := tx.Model(&models.LoanRecord{}).
+            Where("book_id = ? AND returned = false AND (copy_id = 0 OR copy_id IS NULL)", bookID).
+            Count(&untrackedLoans).Error; err != nil {
+            return err
+        }
+
+        var orphanIDs []uint
+        if err := tx.Model(&models.BookCopy{}).
+            Where("book_id = ? AND status = ?", bookID, models.CopyStatusOnLoan).
+            Where("NOT EXISTS (SELECT 1 FROM loan_records lr WHERE lr.copy_id = book_copies.id AND lr.returned = false)").
+            Order("id").Pluck("id", &orphanIDs).Error; err != nil {
+            return err
+        }
+        if len(orphanIDs) > int(untrackedLoans) {
+            release := orphanIDs[untrackedLoans:]
+            if err := tx.Model(&models.BookCopy{}).Where("id IN ?", release).
+                Updates(map[string]interface{}{"status": models.CopyStatusAvailable, "updated_at": gorm.Expr("NOW()")}).Error; err != nil {
+                return err
+            }
+            result.Released = len(release)
+        }
+
+        if err := syncBookStock(tx, bookID); err != nil {
+            return err
+        }
+        if err := tx.First(&book, "id = ?", bookID).Error; err != nil {
+            return err
+        }
+        result.Stock = book.Stock
+        result.MaxStock = book.MaxStock
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    return result, nil
+}
+
+// GetAllBookIDs returns the IDs of every book that is not soft-deleted
+func (r *bookCopyRepository) GetAllBookIDs() ([]int, error) {
+    var ids []int
+    if err := r.db.Model(&models.Book{}).Order("id").Pluck("id", &ids).Error; err != nil {
+        return nil, err
+    }
+    return ids, nil
+}
+
+// createCopies generates n available copies with sequential barcodes for a book
+func createCopies(tx *gorm.DB, bookID int, n int) error {
+    if n <= 0 {
+        return nil
+    }
+
+    var existing int64
+    if err := tx.Model(&models.BookCopy{}).Where("book_id = ?", bookID).Count(&existing).Error; err != nil {
+        return err
+    }
+
+    copies := make([]models.BookCopy, n)
+    for i := range copies {
+        copies[i] = models.BookCopy{
+            BookID:          bookID,
+            Barcode:         fmt.Sprintf("BK%06d-%03d", bookID, int(existing)+i+1),
+            AcquisitionDate: time.Now(),
+            Condition:       "GOOD",
+            Status:          models.CopyStatusAvailable,
+        }
+    }
+    return tx.Create(&copies).Error
+}
+
+// lockBookRow mengunci baris buku (SELECT ... FOR UPDATE) seperti LoanRepository.LockBook,
+// sehingga perubahan eksemplar untuk buku yang sama berjalan bergantian
+func lockBookRow(tx *gorm.DB, bookID int) error {
+    var book models.Book
+    err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&book, "id = ?", bookID).Error
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        return errors.New("book not found")
+    }
+    return err
+}
+
+// syncBookStock menghitung ulang stock dan max_stock buku dari status eksemplarnya.
+// stock = eksemplar AVAILABLE, max_stock = eksemplar yang belum hilang/ditarik.
+// Baris buku dikunci dulu agar hitungan dimulai setelah transaksi lain yang mengubah
+// eksemplar buku ini selesai; tanpa itu dua transaksi bisa menulis hitungan yang basi.
+func syncBookStock(tx *gorm.DB, bookID int) error {
+    if err := lockBookRow(tx, bookID); err != nil {
+        return err
+    }
+    query := `
+        UPDATE books SET
+            stock = (SELECT COUNT(*) FROM book_copies WHERE book_id = ? AND status = ?),
+            max_stock = (SELECT COUNT(*) FROM book_copies WHERE book_id = ? AND status NOT IN (?, ?))
+        WHERE id = ?;
+    `
+    result := tx.Exec(query,
+        bookID, models.CopyStatusAvailable,
+        bookID, models.CopyStatusLost, models.CopyStatusWithdrawn,
+        bookID)
+    if result.Error != nil {
+        return result.Error
+    }
+    if result.RowsAffected == 0 {
+        return errors.New("book not found")
+    }
+    return nil
+}
@@ -2,20 +2,92 @@
 package repository
 
 import (
+    "database/sql"
     "errors"
     "auth-user-api/models"
+    "auth-user-api/query"
 
     "gorm.io/gorm"
+    "gorm.io/gorm/clause"
 )
 
 type BookRepository interface {
     CreateBook(book *models.Book) error
+    CreateBookWithCopies(book *models.Book, copies int) error
     GetBookByID(id int) (*models.Book, error)
-    GetAllBooks() ([]*models.Book, error)
+    GetBookByISBN(isbn string) (*models.Book, error)
+    GetAllBooks(spec *query.Spec) ([]*models.Book, *query.Page, error)
+    SearchBooks(tsquery string, spec *query.Spec) (*BookSearchResult, *query.Page, error)
+    StreamBooks(spec *query.Spec, batchSize int, fn func([]*models.Book) error) error
     UpdateBook(book *models.Book) error
     DeleteBook(id int) error
+    LockBook(id int) error
+    CountActiveLoans(bookID int) (int64, error)
+    CountOpenRequests(bookID int) (int64, error)
+    AppendEvent(event *models.DomainEvent) error
+    Transaction(fn func(tx BookRepository) error) error
 }
 
+// BookSearchHit is one ranked full-text match. Title dan Summary berisi potongan teks yang
+// sudah di-escape sebagai HTML, dengan kata yang cocok diapit <mark>...</mark>, sehingga aman
+// disisipkan langsung ke halaman.
+type BookSearchHit struct {
+    ID              int     `json:"id"`
+    Title           string  `json:"title"`
+    Summary         string  `json:"summary"`
+    Category        string  `json:"category"`
+    ISBN            *string `gorm:"column:isbn" json:"isbn"`
+    PublicationYear *int    `json:"publication_year"`
+    CoverImageURL   string  `json:"cover_image_url"`
+    AuthorID        int     `json:"author_id"`
+    AuthorName      string  `json:"author_name"`
+    PublisherID     int     `json:"publisher_id"`
+    PublisherName   string  `json:"publisher_name"`
+    Stock           int     `json:"stock"`
+    MaxStock        int     `json:"max_stock"`
+    Rank            float64 `json:"rank"`
+}
+
+// FacetCount is the number of matches sharing one author or publisher
+type FacetCount struct {
+    ID    int    `json:"id"`
+    Name  string `json:"name"`
+    Count int64  `json:"count"`
+}
+
+type AvailabilityFacet struct {
+    Available   int64 `json:"available"`   // Buku dengan minimal satu eksemplar AVAILABLE
+    Unavailable int64 `json:"unavailable"`
+}
+
+// BookSearchFacets dihitung dari seluruh hasil pencarian setelah filter, bukan hanya halaman ini
+type BookSearchFacets struct {
+    Authors      []FacetCount      `json:"authors"`
+    Publishers   []FacetCount      `json:"publishers"`
+    Availability AvailabilityFacet `json:"availability"`
+}
+
+type BookSearchResult struct {
+    Results []*BookSearchHit `json:"results"`
+    Facets  BookSearchFacets `json:"facets"`
+}
+
+// facetLimit membatasi jumlah author/publisher yang dikembalikan per facet
+const facetLimit = 20
+
+// bookSearchBase mencocokkan tsquery dengan books.search_vector (dikelola trigger di migrasi 017)
+const bookSearchBase = `
+    SELECT b.id, b.title, b.summary, b.category, b.isbn, b.publication_year, b.cover_image_url,
+           b.author_id, a.name AS author_name,
+           b.publisher_id, p.name AS publisher_name, b.stock, b.max_stock,
+           ts_rank_cd(b.search_vector, q.query) AS rank
+    FROM books b
+    CROSS JOIN to_tsquery('simple', ?) AS q(query)
+    LEFT JOIN authors a ON a.id = b.author_id
+    LEFT JOIN publishers p ON p.id = b.publisher_id
+    WHERE b.deleted_at IS NULL AND b.search_vector @@ q.query
+`
+
 type bookRepository struct {
     db *gorm.DB
 }
@@ -24,40 +96,233 @@ func NewBookRepository(db *gorm.DB) BookRepository {
     return &bookRepository{db}
 }
 
+// Transaction runs fn with a repository bound to one database transaction, mis. agar
+// perubahan buku dan event domainnya tersimpan bersama
+func (r *bookRepository) Transaction(fn func(tx BookRepository) error) error {
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        return fn(&bookRepository{tx})
+    })
+}
+
 func (r *bookRepository) CreateBook(book *models.Book) error {
-    if book.Stock > book.MaxStock {
-        return errors.New("stock cannot exceed max_stock")
+    return r.CreateBookWithCopies(book, 0)
+}
+
+// CreateBookWithCopies creates a book together with its initial available copies
+func (r *bookRepository) CreateBookWithCopies(book *models.Book, copies int) error {
+    if copies < 0 {
+        return errors.New("copies cannot be negative")
     }
-    return r.db.Create(book).Error
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        book.Stock = 0
+        book.MaxStock = 0
+        if err := tx.Omit("Contributors").Create(book).Error; err != nil {
+            return err
+        }
+        if err := replaceContributors(tx, book); err != nil {
+            return err
+        }
+        if err := createCopies(tx, book.ID, copies); err != nil {
+            return err
+        }
+        if err := syncBookStock(tx, book.ID); err != nil {
+            return err
+        }
+        return tx.Select("stock", "max_stock").First(book, book.ID).Error
+    })
 }
 
 func (r *bookRepository) GetBookByID(id int) (*models.Book, error) {
     var book models.Book
-    if err := r.db.Preload("Author").Preload("Publisher").First(&book, id).Error; err != nil {
+    if err := r.db.Scopes(preloadBook).First(&book, id).Error; err != nil {
         return nil, err
     }
     return &book, nil
 }
 
-func (r *bookRepository) GetAllBooks() ([]*models.Book, error) {
-    var books []models.Book
-    if err := r.db.Preload("Author").Preload("Publisher").Find(&books).Error; err != nil {
+// GetBookByISBN mencari buku (yang belum dihapus) dengan ISBN-13 yang sudah dinormalisasi
+func (r *bookRepository) GetBookByISBN(isbn string) (*models.Book, error) {
+    var book models.Book
+    if err := r.db.Where("isbn = ?", isbn).First(&book).Error; err != nil {
         return nil, err
     }
+    return &book, nil
+}
 
-    var bookPointers []*models.Book
-    for i := range books {
-        bookPointers = append(bookPointers, &books[i])
+func (r *bookRepository) GetAllBooks(spec *query.Spec) ([]*models.Book, *query.Page, error) {
+    var books []*models.Book
+    page, err := findPage(r.db.Model(&models.Book{}), spec, &books, preloadBook)
+    if err != nil {
+        return nil, nil, err
     }
+    return books, page, nil
+}
 
-    return bookPointers, nil
+// htmlEscapeSQL membungkus ekspresi teks SQL dengan replace yang meng-escape karakter khusus
+// HTML seperti html.EscapeString; & lebih dulu agar entity hasil escape tidak ikut diubah
+func htmlEscapeSQL(expr string) string {
+    return "replace(replace(replace(replace(replace(" + expr +
+        `, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&#34;'), '''', '&#39;')`
 }
 
+// SearchBooks returns one ranked page of books matching tsquery, with highlighted
+// title/summary snippets and facet counts over every match.
+func (r *bookRepository) SearchBooks(tsquery string, spec *query.Spec) (*BookSearchResult, *query.Page, error) {
+    matches := func() *gorm.DB {
+        return spec.Where(r.db.Table("(?) AS s", r.db.Raw(bookSearchBase, tsquery)))
+    }
+
+    result := &BookSearchResult{Results: []*BookSearchHit{}}
+    page, err := findPage(r.db.Table("(?) AS s", r.db.Raw(bookSearchBase, tsquery)), spec, &result.Results)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    // ts_headline mahal, jadi hanya dijalankan untuk baris di halaman ini
+    if len(result.Results) > 0 {
+        ids := make([]int, len(result.Results))
+        for i, hit := range result.Results {
+            ids[i] = hit.ID
+        }
+        var highlights []struct {
+            ID      int
+            Title   string
+            Summary string
+        }
+        // Teks di-escape sebelum ts_headline agar hanya <mark> yang menjadi tag HTML; parser
+        // full-text melewati entity seperti &lt; sehingga kata yang cocok tidak berubah
+        err := r.db.Raw(`
+            SELECT b.id,
+                   ts_headline('simple', `+htmlEscapeSQL("b.title")+`, q.query, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') AS title,
+                   ts_headline('simple', `+htmlEscapeSQL("coalesce(b.summary, '')")+`, q.query, 'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2') AS summary
+            FROM books b
+            CROSS JOIN to_tsquery('simple', ?) AS q(query)
+            WHERE b.id IN ?
+        `, tsquery, ids).Scan(&highlights).Error
+        if err != nil {
+            return nil, nil, err
+        }
+
+        byID := make(map[int]int, len(highlights))
+        for i, highlight := range highlights {
+            byID[highlight.ID] = i
+        }
+        for _, hit := range result.Results {
+            if i, ok := byID[hit.ID]; ok {
+                hit.Title = highlights[i].Title
+                hit.Summary = highlights[i].Summary
+            }
+        }
+    }
+
+    facets := &result.Facets
+    err = matches().
+        Select("s.author_id AS id, coalesce(s.author_name, '') AS name, COUNT(*) AS count").
+        Group("s.author_id, s.author_name").
+        Order("count DESC, name").
+        Limit(facetLimit).
+        Scan(&facets.Authors).Error
+    if err != nil {
+        return nil, nil, err
+    }
+    err = matches().
+        Select("s.publisher_id AS id, coalesce(s.publisher_name, '') AS name, COUNT(*) AS count").
+        Group("s.publisher_id, s.publisher_name").
+        Order("count DESC, name").
+        Limit(facetLimit).
+        Scan(&facets.Publishers).Error
+    if err != nil {
+        return nil, nil, err
+    }
+    err = matches().
+        Select("COUNT(*) FILTER (WHERE s.stock > 0) AS available, COUNT(*) FILTER (WHERE s.stock <= 0) AS unavailable").
+        Scan(&facets.Availability).Error
+    if err != nil {
+        return nil, nil, err
+    }
+
+    return result, page, nil
+}
+
+// StreamBooks memanggil fn untuk setiap batch buku yang cocok dengan filter spec, urut ID,
+// lengkap dengan author, publisher dan kontributornya. Semua batch dibaca dalam satu
+// transaksi REPEATABLE READ agar export konsisten walaupun katalog berubah di tengah jalan.
+// Limit, offset dan urutan spec diabaikan.
+func (r *bookRepository) StreamBooks(spec *query.Spec, batchSize int, fn func([]*models.Book) error) error {
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        var books []*models.Book
+        result := spec.Where(tx.Model(&models.Book{})).Scopes(preloadBook).
+            FindInBatches(&books, batchSize, func(_ *gorm.DB, _ int) error {
+                return fn(books)
+            })
+        return result.Error
+    }, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+}
+
+// UpdateBook menyimpan perubahan buku; stock dan max_stock dikelola lewat BookCopy.
+// Contributors yang tidak nil menggantikan seluruh kontributor lama.
 func (r *bookRepository) UpdateBook(book *models.Book) error {
-    if book.Stock > book.MaxStock {
-        return errors.New("stock cannot exceed max_stock")
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Omit("stock", "max_stock", "Contributors").Save(book).Error; err != nil {
+            return err
+        }
+        if book.Contributors == nil {
+            return nil
+        }
+        return replaceContributors(tx, book)
+    })
+}
+
+// preloadBook memuat relasi yang ditampilkan di BookResponse, kontributor urut sesuai position
+func preloadBook(db *gorm.DB) *gorm.DB {
+    return db.Preload("Author").
+        Preload("Publisher").
+        Preload("Contributors", func(db *gorm.DB) *gorm.DB { return db.Order("position, id") }).
+        Preload("Contributors.Author")
+}
+
+func replaceContributors(tx *gorm.DB, book *models.Book) error {
+    if err := tx.Where("book_id = ?", book.ID).Delete(&models.BookContributor{}).Error; err != nil {
+        return err
     }
-    return r.db.Save(book).Error
+    if len(book.Contributors) == 0 {
+        return nil
+    }
+    for i := range book.Contributors {
+        book.Contributors[i].ID = 0
+        book.Contributors[i].BookID = book.ID
+    }
+    return tx.Omit("Author").Create(&book.Contributors).Error
+}
+
+// LockBook mengunci baris buku (SELECT ... FOR UPDATE) agar pinjaman, request dan hold baru
+// menunggu sampai penghapusan selesai. Must be called inside Transaction.
+func (r *bookRepository) LockBook(id int) error {
+    var book models.Book
+    err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&book, "id = ?", id).Error
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        return errors.New("book not found")
+    }
+    return err
+}
+
+// CountOpenRequests menghitung loan request PENDING dan hold yang masih menunggu untuk buku
+func (r *bookRepository) CountOpenRequests(bookID int) (int64, error) {
+    var requests, holds int64
+    if err := r.db.Model(&models.LoanRequest{}).Where("book_id = ? AND status = ?", bookID, "PENDING").Count(&requests).Error; err != nil {
+        return 0, err
+    }
+    if err := r.db.Model(&models.Hold{}).Where("book_id = ? AND status IN ?", bookID, activeHoldStatuses).Count(&holds).Error; err != nil {
+        return 0, err
+    }
+    return requests + holds, nil
+}
+
+// CountActiveLoans menghitung pinjaman buku yang belum dikembalikan
+func (r *bookRepository) CountActiveLoans(bookID int) (int64, error) {
+    var count int64
+    err := r.db.Model(&models.LoanRecord{}).Where("book_id = ? AND returned = ?", bookID, false).Count(&count).Error
+    return count, err
 }
 
 func (r *bookRepository) DeleteBook(id int) error {
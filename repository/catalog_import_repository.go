@@ -0,0 +1,138 @@
+// repository/catalog_import_repository.go
+package repository
+
+import (
+    "errors"
+    "strings"
+    "auth-user-api/models"
+
+    "gorm.io/gorm"
+)
+
+// CatalogImportRepository menyimpan hasil import katalog massal. Seperti LoanRepository,
+// repository ini konkret dan dipakai di dalam Transaction agar satu import bisa dibatalkan utuh.
+type CatalogImportRepository struct {
+    DB *gorm.DB
+}
+
+func NewCatalogImportRepository(db *gorm.DB) *CatalogImportRepository {
+    return &CatalogImportRepository{DB: db}
+}
+
+// Transaction runs fn in a database transaction. Dipanggil dari repository yang sudah
+// terikat transaksi, GORM memakai SAVEPOINT sehingga error fn hanya membatalkan bagian itu.
+func (r *CatalogImportRepository) Transaction(fn func(tx *CatalogImportRepository) error) error {
+    return r.DB.Transaction(func(tx *gorm.DB) error {
+        return fn(&CatalogImportRepository{DB: tx})
+    })
+}
+
+// lockName mengunci nama (tanpa membedakan huruf besar/kecil dan spasi di tepi) di table sampai
+// transaksi selesai, sehingga import yang berjalan bersamaan mencocokkan nama yang sama secara
+// bergantian dan tidak membuat baris ganda. Nama tabel menjadi key pertama agar author dan
+// publisher bernama sama tidak saling menunggu.
+func (r *CatalogImportRepository) lockName(table, name string) error {
+    return r.DB.Exec("SELECT pg_advisory_xact_lock(hashtext(?), hashtext(lower(btrim(?))))", table, name).Error
+}
+
+// FindOrCreateAuthor mencocokkan author berdasarkan nama tanpa membedakan huruf besar/kecil,
+// dan membuatnya jika belum ada. created bernilai true jika author baru dibuat.
+// Must be called inside Transaction: lock nama dilepas saat transaksi import selesai.
+func (r *CatalogImportRepository) FindOrCreateAuthor(name string) (*models.Author, bool, error) {
+    name = strings.TrimSpace(name)
+    if err := r.lockName("authors", name); err != nil {
+        return nil, false, err
+    }
+
+    var author models.Author
+    err := r.DB.Where("lower(btrim(name)) = lower(?)", name).Order("id").First(&author).Error
+    if err == nil {
+        return &author, false, nil
+    }
+    if !errors.Is(err, gorm.ErrRecordNotFound) {
+        return nil, false, err
+    }
+
+    author = models.Author{Name: name}
+    if err := r.DB.Create(&author).Error; err != nil {
+        return nil, false, err
+    }
+    return &author, true, nil
+}
+
+// FindOrCreatePublisher sama seperti FindOrCreateAuthor untuk publisher
+func (r *CatalogImportRepository) FindOrCreatePublisher(name string) (*models.Publisher, bool, error) {
+    name = strings.TrimSpace(name)
+    if err := r.lockName("publishers", name); err != nil {
+        return nil, false, err
+    }
+
+    var publisher models.Publisher
+    err := r.DB.Where("lower(btrim(name)) = lower(?)", name).Order("id").First(&publisher).Error
+    if err == nil {
+        return &publisher, false, nil
+    }
+    if !errors.Is(err, gorm.ErrRecordNotFound) {
+        return nil, false, err
+    }
+
+    publisher = models.Publisher{Name: name}
+    if err := r.DB.Create(&publisher).Error; err != nil {
+        return nil, false, err
+    }
+    return &publisher, true, nil
+}
+
+func (r *CatalogImportRepository) GetBookByISBN(isbn string) (*models.Book, error) {
+    var book models.Book
+    if err := r.DB.Where("isbn = ?", isbn).First(&book).Error; err != nil {
+        return nil, err
+    }
+    return &book, nil
+}
+
+// CreateBook membuat buku beserta kontributor dan copies eksemplar AVAILABLE. Status
+// eksemplar lain (rusak, hilang) diatur lewat endpoint eksemplar setelah import.
+func (r *CatalogImportRepository) CreateBook(book *models.Book, copies int) error {
+    if copies < 0 {
+        return errors.New("copies cannot be negative")
+    }
+
+    book.Stock = 0
+    book.MaxStock = 0
+    if err := r.DB.Omit("Contributors").Create(book).Error; err != nil {
+        return err
+    }
+    if err := replaceContributors(r.DB, book); err != nil {
+        return err
+    }
+    if err := createCopies(r.DB, book.ID, copies); err != nil {
+        return err
+    }
+    if err := syncBookStock(r.DB, book.ID); err != nil {
+        return err
+    }
+    return r.DB.Select("stock", "max_stock").First(book, book.ID).Error
+}
+
+// UpdateBook menyimpan metadata buku yang sudah ada, mengganti kontributornya, dan
+// menambah addCopies eksemplar AVAILABLE. Import tidak pernah mengurangi eksemplar.
+func (r *CatalogImportRepository) UpdateBook(book *models.Book, addCopies int) error {
+    if addCopies < 0 {
+        return errors.New("copies cannot be negative")
+    }
+
+    if err := r.DB.Omit("stock", "max_stock", "Contributors").Save(book).Error; err != nil {
+        return err
+    }
+    if err := replaceContributors(r.DB, book); err != nil {
+        return err
+    }
+    if err := createCopies(r.DB, book.ID, addCopies); err != nil {
+        return err
+    }
+    if err := syncBookStock(r.DB, book.ID); err != nil {
+        return err
+    }
+    return r.DB.Select("stock", "max_stock").First(book, book.ID).Error
+}
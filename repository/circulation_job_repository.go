@@ -0,0 +1,63 @@
+// repository/circulation_job_repository.go
+package repository
+
+import (
+    "time"
+    "auth-user-api/models"
+
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+// Query untuk job overdue dan pengingat jatuh tempo, memakai LoanRepository agar bisa
+// berjalan di transaksi yang sama dengan pencatatan denda.
+
+// WithAdvisoryLock runs fn on a single pinned connection while holding the Postgres
+// session advisory lock key. Jika lock dipegang sesi lain, fn tidak dijalankan dan
+// acquired bernilai false. Transaction di dalam fn memakai koneksi yang sama.
+func (r *LoanRepository) WithAdvisoryLock(key int64, fn func(conn *LoanRepository) error) (bool, error) {
+    acquired := false
+    err := r.DB.Connection(func(conn *gorm.DB) error {
+        if err := conn.Raw("SELECT pg_try_advisory_lock(?)", key).Scan(&acquired).Error; err != nil {
+            return err
+        }
+        if !acquired {
+            return nil
+        }
+        // Lock dilepas walaupun fn gagal; jika koneksi putus Postgres melepasnya sendiri
+        defer conn.Exec("SELECT pg_advisory_unlock(?)", key)
+        return fn(&LoanRepository{DB: conn})
+    })
+    return acquired, err
+}
+
+// MarkOverdueLoans mengisi overdue_at untuk pinjaman yang baru melewati jatuh tempo
+func (r *LoanRepository) MarkOverdueLoans(now time.Time) (int64, error) {
+    result := r.DB.Model(&models.LoanRecord{}).
+        Where("returned = false AND due_date < ? AND overdue_at IS NULL", now).
+        Update("overdue_at", now)
+    return result.RowsAffected, result.Error
+}
+
+// GetOpenLoanIDsDueBefore returns unreturned loans due before the given time, earliest first
+func (r *LoanRepository) GetOpenLoanIDsDueBefore(before time.Time) ([]uint, error) {
+    var ids []uint
+    err := r.DB.Model(&models.LoanRecord{}).
+        Where("returned = false AND due_date < ?", before).
+        Order("due_date, id").Pluck("id", &ids).Error
+    return ids, err
+}
+
+// QueueReminder menyimpan pengingat jika belum pernah dibuat. queued bernilai false jika
+// pengingat dengan pinjaman, jenis, level dan due date yang sama sudah ada.
+func (r *LoanRepository) QueueReminder(reminder *models.LoanReminder) (bool, error) {
+    result := r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(reminder)
+    return result.RowsAffected > 0, result.Error
+}
+
+func (r *LoanRepository) MarkReminderSent(id uint, sentAt time.Time) error {
+    return r.DB.Model(&models.LoanReminder{}).Where("id = ?", id).Updates(map[string]interface{}{
+        "status":  models.ReminderStatusSent,
+        "sent_at": sentAt,
+    }).Error
+}
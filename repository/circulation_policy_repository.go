@@ -0,0 +1,81 @@
+// repository/circulation_policy_repository.go
+package repository
+
+import (
+    "errors"
+    "auth-user-api/models"
+
+    "gorm.io/gorm"
+)
+
+type CirculationPolicyRepository interface {
+    CreatePolicy(policy *models.CirculationPolicy) error
+    GetPolicyByID(id uint) (*models.CirculationPolicy, error)
+    GetAllPolicies() ([]*models.CirculationPolicy, error)
+    UpdatePolicy(policy *models.CirculationPolicy) error
+    DeletePolicy(id uint) error
+    FindMatchingPolicies(role int, category string) ([]*models.CirculationPolicy, error)
+    CountPolicies() (int64, error)
+}
+
+type circulationPolicyRepository struct {
+    db *gorm.DB
+}
+
+func NewCirculationPolicyRepository(db *gorm.DB) CirculationPolicyRepository {
+    return &circulationPolicyRepository{db}
+}
+
+func (r *circulationPolicyRepository) CreatePolicy(policy *models.CirculationPolicy) error {
+    return r.db.Create(policy).Error
+}
+
+func (r *circulationPolicyRepository) GetPolicyByID(id uint) (*models.CirculationPolicy, error) {
+    var policy models.CirculationPolicy
+    if err := r.db.First(&policy, "id = ?", id).Error; err != nil {
+        return nil, err
+    }
+    return &policy, nil
+}
+
+func (r *circulationPolicyRepository) GetAllPolicies() ([]*models.CirculationPolicy, error) {
+    var policies []*models.CirculationPolicy
+    if err := r.db.Order("role, category").Find(&policies).Error; err != nil {
+        return nil, err
+    }
+    return policies, nil
+}
+
+func (r *circulationPolicyRepository) UpdatePolicy(policy *models.CirculationPolicy) error {
+    return r.db.Save(policy).Error
+}
+
+func (r *circulationPolicyRepository) DeletePolicy(id uint) error {
+    result := r.db.Delete(&models.CirculationPolicy{}, id)
+    if result.Error != nil {
+        return result.Error
+    }
+    if result.RowsAffected == 0 {
+        return errors.New("policy not found")
+    }
+    return nil
+}
+
+// FindMatchingPolicies retrieves every policy that applies to the role and category,
+// including the wildcard rows (role 0 and empty category)
+func (r *circulationPolicyRepository) FindMatchingPolicies(role int, category string) ([]*models.CirculationPolicy, error) {
+    var policies []*models.CirculationPolicy
+    err := r.db.Where("role IN ?", []int{role, 0}).
+        Where("category IN ?", []string{category, ""}).
+        Find(&policies).Error
+    if err != nil {
+        return nil, err
+    }
+    return policies, nil
+}
+
+func (r *circulationPolicyRepository) CountPolicies() (int64, error) {
+    var count int64
+    err := r.db.Model(&models.CirculationPolicy{}).Count(&count).Error
+    return count, err
+}
@@ -0,0 +1,102 @@
+// repository/domain_event_repository.go
+package repository
+
+import (
+    "time"
+    "auth-user-api/models"
+
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+// Event domain ditulis lewat AppendEvent milik repository yang sedang dipakai transaksi
+// (LoanRepository, BookRepository, ...), sedangkan EventRepository dipakai dispatcher.
+
+func appendEvent(db *gorm.DB, event *models.DomainEvent) error {
+    if event.NextAttemptAt.IsZero() {
+        event.NextAttemptAt = event.OccurredAt
+    }
+    event.Status = models.DomainEventStatusPending
+    return db.Create(event).Error
+}
+
+func (r *LoanRepository) AppendEvent(event *models.DomainEvent) error {
+    return appendEvent(r.DB, event)
+}
+
+func (r *CatalogImportRepository) AppendEvent(event *models.DomainEvent) error {
+    return appendEvent(r.DB, event)
+}
+
+func (r *TrashRepository) AppendEvent(event *models.DomainEvent) error {
+    return appendEvent(r.DB, event)
+}
+
+func (r *bookRepository) AppendEvent(event *models.DomainEvent) error {
+    return appendEvent(r.db, event)
+}
+
+func (r *authorRepository) AppendEvent(event *models.DomainEvent) error {
+    return appendEvent(r.db, event)
+}
+
+func (r *publisherRepository) AppendEvent(event *models.DomainEvent) error {
+    return appendEvent(r.db, event)
+}
+
+// EventRepository membaca dan memperbarui outbox event untuk dispatcher
+type EventRepository struct {
+    DB *gorm.DB
+}
+
+func NewEventRepository(db *gorm.DB) *EventRepository {
+    return &EventRepository{DB: db}
+}
+
+// ClaimDueEvents mengambil sampai limit event PENDING yang sudah waktunya dikirim, urut id,
+// dan menggeser next_attempt_at sejauh lease. Sama seperti outbox notifikasi, baris yang
+// dikunci instance lain dilewati dan diambil lagi jika lease habis.
+func (r *EventRepository) ClaimDueEvents(now time.Time, limit int, lease time.Duration) ([]*models.DomainEvent, error) {
+    var events []*models.DomainEvent
+    err := r.DB.Transaction(func(tx *gorm.DB) error {
+        err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+            Where("status = ? AND next_attempt_at <= ?", models.DomainEventStatusPending, now).
+            Order("id").Limit(limit).Find(&events).Error
+        if err != nil || len(events) == 0 {
+            return err
+        }
+
+        ids := make([]uint, len(events))
+        for i, e := range events {
+            ids[i] = e.ID
+        }
+        return tx.Model(&models.DomainEvent{}).Where("id IN ?", ids).
+            Update("next_attempt_at", now.Add(lease)).Error
+    })
+    return events, err
+}
+
+func (r *EventRepository) MarkEventDispatched(event *models.DomainEvent, dispatchedAt time.Time) error {
+    return r.DB.Model(&models.DomainEvent{}).Where("id = ?", event.ID).Updates(map[string]interface{}{
+        "status":        models.DomainEventStatusDispatched,
+        "attempts":      event.Attempts,
+        "delivered_to":  event.DeliveredTo,
+        "last_error":    nil,
+        "dispatched_at": dispatchedAt,
+    }).Error
+}
+
+// MarkEventFailed menyimpan percobaan yang gagal sebagian atau seluruhnya. Jika final,
+// status menjadi FAILED; selain itu event tetap PENDING sampai next_attempt_at.
+func (r *EventRepository) MarkEventFailed(event *models.DomainEvent, final bool) error {
+    updates := map[string]interface{}{
+        "attempts":        event.Attempts,
+        "delivered_to":    event.DeliveredTo,
+        "last_error":      event.LastError,
+        "next_attempt_at": event.NextAttemptAt,
+    }
+    if final {
+        updates["status"] = models.DomainEventStatusFailed
+    }
+    return r.DB.Model(&models.DomainEvent{}).Where("id = ?", event.ID).Updates(updates).Error
+}
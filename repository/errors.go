@@ -0,0 +1,18 @@
+// repository/errors.go
+package repository
+
+import (
+    "errors"
+
+    "github.com/jackc/pgx/v5/pgconn"
+)
+
+// IsUniqueViolation melaporkan apakah err adalah pelanggaran unique constraint/index Postgres
+// (SQLSTATE 23505). constraint kosong cocok dengan constraint apa pun.
+func IsUniqueViolation(err error, constraint string) bool {
+    var pgErr *pgconn.PgError
+    if !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+        return false
+    }
+    return constraint == "" || pgErr.ConstraintName == constraint
+}
@@ -0,0 +1,80 @@
+// repository/fine_repository.go
+package repository
+
+import (
+    "auth-user-api/models"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm/clause"
+)
+
+// Denda memakai LoanRepository agar bisa dicatat dalam transaksi pengembalian buku.
+
+func (r *LoanRepository) CreateFine(fine *models.Fine) error {
+    return r.DB.Create(fine).Error
+}
+
+func (r *LoanRepository) UpdateFine(fine *models.Fine) error {
+    return r.DB.Save(fine).Error
+}
+
+func (r *LoanRepository) GetFineByID(id uint) (*models.Fine, error) {
+    var fine models.Fine
+    if err := r.DB.First(&fine, "id = ?", id).Error; err != nil {
+        return nil, err
+    }
+    return &fine, nil
+}
+
+// LockFine loads a fine with a row lock. Must be called inside Transaction.
+func (r *LoanRepository) LockFine(id uint) (*models.Fine, error) {
+    var fine models.Fine
+    if err := r.DB.Clauses(clause.Locking{Strength: "UPDATE"}).First(&fine, "id = ?", id).Error; err != nil {
+        return nil, err
+    }
+    return &fine, nil
+}
+
+// LockLateFee loads the late fee fine of a loan with a row lock. Must be called inside Transaction.
+func (r *LoanRepository) LockLateFee(loanID uint) (*models.Fine, error) {
+    var fine models.Fine
+    err := r.DB.Clauses(clause.Locking{Strength: "UPDATE"}).
+        Where("loan_record_id = ? AND reason = ?", loanID, models.FineReasonLateReturn).
+        Order("id").First(&fine).Error
+    if err != nil {
+        return nil, err
+    }
+    return &fine, nil
+}
+
+// GetFinesByUser retrieves every fine of a user, newest first
+func (r *LoanRepository) GetFinesByUser(userID uuid.UUID) ([]*models.Fine, error) {
+    var fines []*models.Fine
+    if err := r.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&fines).Error; err != nil {
+        return nil, err
+    }
+    return fines, nil
+}
+
+// GetOutstandingBalance sums what the user still owes across all fines
+func (r *LoanRepository) GetOutstandingBalance(userID uuid.UUID) (int, error) {
+    var balance int
+    err := r.DB.Model(&models.Fine{}).
+        Where("user_id = ? AND status = ?", userID, models.FineStatusOutstanding).
+        Select("COALESCE(SUM(amount - paid_amount - waived_amount), 0)").
+        Scan(&balance).Error
+    return balance, err
+}
+
+func (r *LoanRepository) CreatePayment(payment *models.Payment) error {
+    return r.DB.Create(payment).Error
+}
+
+// GetPaymentsByFine retrieves the payments made against a fine, oldest first
+func (r *LoanRepository) GetPaymentsByFine(fineID uint) ([]*models.Payment, error) {
+    var payments []*models.Payment
+    if err := r.DB.Where("fine_id = ?", fineID).Order("created_at").Find(&payments).Error; err != nil {
+        return nil, err
+    }
+    return payments, nil
+}
@@ -0,0 +1,158 @@
+// repository/hold_repository.go
+package repository
+
+import (
+    "time"
+    "auth-user-api/models"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm/clause"
+)
+
+// Antrean reservasi memakai LoanRepository agar bisa berbagi transaksi dengan alur peminjaman.
+
+// activeHoldStatuses are the statuses of holds that still occupy the queue
+var activeHoldStatuses = []string{models.HoldStatusWaiting, models.HoldStatusReady}
+
+// LockBook takes a row lock on the book so queue changes for it are serialized.
+// Buku di trash ikut terkunci agar pinjaman yang masih berjalan tetap bisa dikembalikan;
+// alur yang membuat pinjaman atau reservasi baru harus memeriksa book.DeletedAt sendiri.
+// Must be called inside Transaction.
+func (r *LoanRepository) LockBook(bookID int) (*models.Book, error) {
+    var book models.Book
+    if err := r.DB.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).First(&book, "id = ?", bookID).Error; err != nil {
+        return nil, err
+    }
+    return &book, nil
+}
+
+func (r *LoanRepository) CreateHold(hold *models.Hold) error {
+    return r.DB.Create(hold).Error
+}
+
+func (r *LoanRepository) UpdateHold(hold *models.Hold) error {
+    return r.DB.Save(hold).Error
+}
+
+func (r *LoanRepository) GetHoldByID(id uint) (*models.Hold, error) {
+    var hold models.Hold
+    if err := r.DB.First(&hold, "id = ?", id).Error; err != nil {
+        return nil, err
+    }
+    return &hold, nil
+}
+
+// LockHold loads a hold with a row lock. Must be called inside Transaction.
+func (r *LoanRepository) LockHold(id uint) (*models.Hold, error) {
+    var hold models.Hold
+    if err := r.DB.Clauses(clause.Locking{Strength: "UPDATE"}).First(&hold, "id = ?", id).Error; err != nil {
+        return nil, err
+    }
+    return &hold, nil
+}
+
+// GetActiveHold retrieves the user's waiting or ready hold on a book
+func (r *LoanRepository) GetActiveHold(bookID int, userID uuid.UUID) (*models.Hold, error) {
+    var hold models.Hold
+    err := r.DB.Where("book_id = ? AND user_id = ? AND status IN ?", bookID, userID, activeHoldStatuses).
+        First(&hold).Error
+    if err != nil {
+        return nil, err
+    }
+    return &hold, nil
+}
+
+// GetWaitingHolds retrieves the waiting holds of a book in queue order
+func (r *LoanRepository) GetWaitingHolds(bookID int) ([]*models.Hold, error) {
+    var holds []*models.Hold
+    err := r.DB.Where("book_id = ? AND status = ?", bookID, models.HoldStatusWaiting).
+        Order("position, id").Find(&holds).Error
+    if err != nil {
+        return nil, err
+    }
+    return holds, nil
+}
+
+// NextHoldPosition returns the position for a new hold at the end of the queue
+func (r *LoanRepository) NextHoldPosition(bookID int) (int, error) {
+    var maxPosition int
+    err := r.DB.Model(&models.Hold{}).
+        Where("book_id = ? AND status = ?", bookID, models.HoldStatusWaiting).
+        Select("COALESCE(MAX(position), 0)").Scan(&maxPosition).Error
+    if err != nil {
+        return 0, err
+    }
+    return maxPosition + 1, nil
+}
+
+// CountWaitingHolds counts members still waiting in the queue of a book
+func (r *LoanRepository) CountWaitingHolds(bookID int) (int64, error) {
+    var count int64
+    err := r.DB.Model(&models.Hold{}).
+        Where("book_id = ? AND status = ?", bookID, models.HoldStatusWaiting).
+        Count(&count).Error
+    return count, err
+}
+
+// GetExpiredReadyHolds retrieves ready holds whose pickup window has passed
+func (r *LoanRepository) GetExpiredReadyHolds(now time.Time) ([]*models.Hold, error) {
+    var holds []*models.Hold
+    err := r.DB.Where("status = ? AND expires_at < ?", models.HoldStatusReady, now).
+        Order("expires_at").Find(&holds).Error
+    if err != nil {
+        return nil, err
+    }
+    return holds, nil
+}
+
+// GetBooksAwaitingCopies lists books that have waiting holds while copies sit on the shelf
+func (r *LoanRepository) GetBooksAwaitingCopies() ([]int, error) {
+    var bookIDs []int
+    query := `
+        SELECT DISTINCT h.book_id
+        FROM holds h
+        WHERE h.status = ?
+          AND EXISTS (SELECT 1 FROM book_copies bc WHERE bc.book_id = h.book_id AND bc.status = ?);
+    `
+    if err := r.DB.Raw(query, models.HoldStatusWaiting, models.CopyStatusAvailable).Scan(&bookIDs).Error; err != nil {
+        return nil, err
+    }
+    return bookIDs, nil
+}
+
+// GetHoldQueue retrieves the active holds of a book along with the member's username
+func (r *LoanRepository) GetHoldQueue(bookID int) ([]map[string]interface{}, error) {
+    var results []map[string]interface{}
+
+    query := `
+        SELECT h.id, h.book_id, h.user_id, h.position, h.status, h.copy_id,
+               h.ready_at, h.expires_at, h.created_at, u.username AS borrower_name
+        FROM holds h
+        JOIN users u ON h.user_id = u.id
+        WHERE h.book_id = ? AND h.status IN ?
+        ORDER BY CASE WHEN h.status = ? THEN 0 ELSE 1 END, h.position, h.id;
+    `
+
+    if err := r.DB.Raw(query, bookID, activeHoldStatuses, models.HoldStatusReady).Scan(&results).Error; err != nil {
+        return nil, err
+    }
+    return results, nil
+}
+
+// GetHoldQueuePositions retrieves the active holds of a book without identifying the members:
+// hanya posisi dan status, dengan is_mine menandai reservasi milik userID
+func (r *LoanRepository) GetHoldQueuePositions(bookID int, userID uuid.UUID) ([]map[string]interface{}, error) {
+    var results []map[string]interface{}
+
+    query := `
+        SELECT h.position, h.status, h.user_id = ? AS is_mine
+        FROM holds h
+        WHERE h.book_id = ? AND h.status IN ?
+        ORDER BY CASE WHEN h.status = ? THEN 0 ELSE 1 END, h.position, h.id;
+    `
+
+    if err := r.DB.Raw(query, userID, bookID, activeHoldStatuses, models.HoldStatusReady).Scan(&results).Error; err != nil {
+        return nil, err
+    }
+    return results, nil
+}
@@ -0,0 +1,111 @@
+// repository/invite_repository.go
+package repository
+
+import (
+    "errors"
+    "time"
+    "auth-user-api/models"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+)
+
+// ErrAdminExists dikembalikan CreateFirstAdmin jika sudah ada user admin
+var ErrAdminExists = errors.New("an admin user already exists")
+
+// bootstrapLockKey mencegah dua registrasi bootstrap membuat dua admin sekaligus
+const bootstrapLockKey = 72512
+
+type InviteRepository interface {
+    CreateInvite(invite *models.Invite) error
+    GetInviteByID(id uuid.UUID) (*models.Invite, error)
+    GetAllInvites() ([]*models.Invite, error)
+    RevokeInvite(id uuid.UUID) error
+    RedeemInvite(invite *models.Invite, user *models.User) error
+    CreateFirstAdmin(user *models.User) error
+}
+
+type inviteRepository struct {
+    db *gorm.DB
+}
+
+func NewInviteRepository(db *gorm.DB) InviteRepository {
+    return &inviteRepository{db}
+}
+
+func (r *inviteRepository) CreateInvite(invite *models.Invite) error {
+    return r.db.Create(invite).Error
+}
+
+func (r *inviteRepository) GetInviteByID(id uuid.UUID) (*models.Invite, error) {
+    var invite models.Invite
+    if err := r.db.First(&invite, "id = ?", id).Error; err != nil {
+        return nil, err
+    }
+    return &invite, nil
+}
+
+func (r *inviteRepository) GetAllInvites() ([]*models.Invite, error) {
+    var invites []*models.Invite
+    if err := r.db.Order("created_at DESC").Find(&invites).Error; err != nil {
+        return nil, err
+    }
+    return invites, nil
+}
+
+// RevokeInvite membatalkan undangan yang belum dipakai. Returns ErrRowConflict jika sudah dipakai/dibatalkan.
+func (r *inviteRepository) RevokeInvite(id uuid.UUID) error {
+    result := r.db.Model(&models.Invite{}).
+        Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+        Update("revoked_at", time.Now())
+    if result.Error != nil {
+        return result.Error
+    }
+    if result.RowsAffected == 0 {
+        return ErrRowConflict
+    }
+    return nil
+}
+
+// RedeemInvite menandai undangan terpakai dan membuat user dalam satu transaksi.
+// Jika undangan sudah dipakai atau dibatalkan oleh request lain, ErrRowConflict dikembalikan.
+func (r *inviteRepository) RedeemInvite(invite *models.Invite, user *models.User) error {
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Create(user).Error; err != nil {
+            return err
+        }
+
+        userID, err := uuid.Parse(user.ID)
+        if err != nil {
+            return err
+        }
+        result := tx.Model(&models.Invite{}).
+            Where("id = ? AND used_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()", invite.ID).
+            Updates(map[string]interface{}{"used_at": time.Now(), "used_by": userID})
+        if result.Error != nil {
+            return result.Error
+        }
+        if result.RowsAffected == 0 {
+            return ErrRowConflict
+        }
+        return nil
+    })
+}
+
+// CreateFirstAdmin membuat user admin hanya jika belum ada admin sama sekali
+func (r *inviteRepository) CreateFirstAdmin(user *models.User) error {
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", bootstrapLockKey).Error; err != nil {
+            return err
+        }
+
+        var admins int64
+        if err := tx.Model(&models.User{}).Where("role = ? AND deleted_at IS NULL", 1).Count(&admins).Error; err != nil {
+            return err
+        }
+        if admins > 0 {
+            return ErrAdminExists
+        }
+        return tx.Create(user).Error
+    })
+}
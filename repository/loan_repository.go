@@ -2,11 +2,17 @@
 package repository
 
 import (
+    "errors"
     "auth-user-api/models"
+    "auth-user-api/query"
     "gorm.io/gorm"
+    "gorm.io/gorm/clause"
     "github.com/google/uuid"
 )
 
+// ErrRowConflict dikembalikan jika baris sudah diubah transaksi lain sebelum update bersyarat
+var ErrRowConflict = errors.New("row was modified concurrently")
+
 type LoanRepository struct {
     DB *gorm.DB
 }
@@ -15,6 +21,14 @@ func NewLoanRepository(db *gorm.DB) *LoanRepository {
     return &LoanRepository{DB: db}
 }
 
+// Transaction runs fn in a single database transaction. The repository passed to fn
+// is bound to that transaction; any returned error rolls everything back.
+func (r *LoanRepository) Transaction(fn func(tx *LoanRepository) error) error {
+    return r.DB.Transaction(func(tx *gorm.DB) error {
+        return fn(&LoanRepository{DB: tx})
+    })
+}
+
 func (r *LoanRepository) CreateLoanRequest(req *models.LoanRequest) error {
     return r.DB.Create(req).Error
 }
@@ -31,12 +45,26 @@ func (r *LoanRepository) GetLoanRequestByID(id uint) (*models.LoanRequest, error
     return &req, nil
 }
 
+// LockLoanRequest loads a loan request with a row lock (SELECT ... FOR UPDATE).
+// Must be called inside Transaction.
+func (r *LoanRepository) LockLoanRequest(id uint) (*models.LoanRequest, error) {
+    var req models.LoanRequest
+    if err := r.DB.Clauses(clause.Locking{Strength: "UPDATE"}).First(&req, "id = ?", id).Error; err != nil {
+        return nil, err
+    }
+    return &req, nil
+}
+
 func (r *LoanRepository) CreateLoanRecord(record *models.LoanRecord) error {
     return r.DB.Create(record).Error
 }
 
+// UpdateLoanRecord menyimpan kolom yang diubah alur pengembalian dan perpanjangan saja;
+// kolom lain (mis. copy_id pinjaman lama yang masih NULL) tidak ditulis ulang
 func (r *LoanRepository) UpdateLoanRecord(record *models.LoanRecord) error {
-    return r.DB.Save(record).Error
+    return r.DB.Model(record).
+        Select("returned", "return_date", "due_date", "renewal_count").
+        Updates(record).Error
 }
 
 func (r *LoanRepository) GetLoanRecordByID(id uint) (*models.LoanRecord, error) {
@@ -47,15 +75,86 @@ func (r *LoanRepository) GetLoanRecordByID(id uint) (*models.LoanRecord, error)
     return &record, nil
 }
 
+// LockLoanRecord loads a loan record with a row lock. Must be called inside Transaction.
+func (r *LoanRepository) LockLoanRecord(id uint) (*models.LoanRecord, error) {
+    var record models.LoanRecord
+    if err := r.DB.Clauses(clause.Locking{Strength: "UPDATE"}).First(&record, "id = ?", id).Error; err != nil {
+        return nil, err
+    }
+    return &record, nil
+}
+
+func (r *LoanRepository) CreateLoanRenewal(renewal *models.LoanRenewal) error {
+    return r.DB.Create(renewal).Error
+}
+
+// GetLoanRenewals retrieves the renewal history of a loan, oldest first
+func (r *LoanRepository) GetLoanRenewals(loanID uint) ([]models.LoanRenewal, error) {
+    var renewals []models.LoanRenewal
+    if err := r.DB.Where("loan_record_id = ?", loanID).Order("renewed_at").Find(&renewals).Error; err != nil {
+        return nil, err
+    }
+    return renewals, nil
+}
+
+// GetBookByID juga mengembalikan buku di trash: request, pinjaman dan denda yang sudah ada
+// tetap diproses walaupun bukunya dihapus
 func (r *LoanRepository) GetBookByID(id int) (*models.Book, error) {
     var book models.Book
-    return &book, r.DB.First(&book, "id = ?", id).Error
+    return &book, r.DB.Unscoped().First(&book, "id = ?", id).Error
+}
+
+// ClaimAvailableCopy locks the first available copy of a book, skipping copies
+// already locked by concurrent approvals. Must be called inside Transaction.
+func (r *LoanRepository) ClaimAvailableCopy(bookID int) (*models.BookCopy, error) {
+    var bookCopy models.BookCopy
+    err := r.DB.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+        Where("book_id = ? AND status = ?", bookID, models.CopyStatusAvailable).
+        Order("id").First(&bookCopy).Error
+    if err != nil {
+        return nil, err
+    }
+    return &bookCopy, nil
 }
 
-func (r *LoanRepository) UpdateBookStock(bookID int, change int) error {
-    return r.DB.Model(&models.Book{}).
-        Where("id = ?", bookID).
-        Update("stock", gorm.Expr("stock + ?", change)).Error
+// GetUntrackedLoanedCopy mencari eksemplar ON_LOAN yang tidak terhubung ke pinjaman aktif,
+// dipakai untuk pinjaman lama yang dibuat sebelum ada BookCopy.
+func (r *LoanRepository) GetUntrackedLoanedCopy(bookID int) (*models.BookCopy, error) {
+    var bookCopy models.BookCopy
+    err := r.DB.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+        Where("book_id = ? AND status = ?", bookID, models.CopyStatusOnLoan).
+        Where("NOT EXISTS (SELECT 1 FROM loan_records lr WHERE lr.copy_id = book_copies.id AND lr.returned = false)").
+        Order("id").First(&bookCopy).Error
+    if err != nil {
+        return nil, err
+    }
+    return &bookCopy, nil
+}
+
+// UpdateCopyStatus moves a copy from one status to another only if it still has the
+// expected status, then recalculates the book's stock. Returns ErrRowConflict otherwise.
+func (r *LoanRepository) UpdateCopyStatus(bookCopy *models.BookCopy, from, to string) error {
+    result := r.DB.Model(&models.BookCopy{}).
+        Where("id = ? AND status = ?", bookCopy.ID, from).
+        Updates(map[string]interface{}{"status": to, "updated_at": gorm.Expr("NOW()")})
+    if result.Error != nil {
+        return result.Error
+    }
+    if result.RowsAffected == 0 {
+        return ErrRowConflict
+    }
+    bookCopy.Status = to
+    return syncBookStock(r.DB, bookCopy.BookID)
+}
+
+// LockCopy locks a copy row for update. Must be called inside Transaction.
+func (r *LoanRepository) LockCopy(id uint) (*models.BookCopy, error) {
+    var bookCopy models.BookCopy
+    err := r.DB.Clauses(clause.Locking{Strength: "UPDATE"}).First(&bookCopy, "id = ?", id).Error
+    if err != nil {
+        return nil, err
+    }
+    return &bookCopy, nil
 }
 
 // GetLoanRecordWithUserInfo fetches loan record with the corresponding user's username.
@@ -86,15 +185,91 @@ func (r *LoanRepository) GetUsernameByUserID(userID uuid.UUID) (string, error) {
     return username, nil
 }
 
-// GetAllLoanRequests fetches all loan requests along with the borrower's username.
-func (r *LoanRepository) GetAllLoanRequests() ([]map[string]interface{}, error) {
+// LockUser takes a row lock on an active user so concurrent loan requests of the same member
+// are evaluated one at a time. Must be called inside Transaction.
+func (r *LoanRepository) LockUser(userID uuid.UUID) error {
+    var user models.User
+    return r.DB.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+        Where("id = ? AND deleted_at IS NULL", userID).First(&user).Error
+}
+
+// GetUserRole fetches the role of the user with the given UUID.
+func (r *LoanRepository) GetUserRole(userID uuid.UUID) (int, error) {
+    var user models.User
+    if err := r.DB.Select("role").Where("id = ? AND deleted_at IS NULL", userID).First(&user).Error; err != nil {
+        return 0, err
+    }
+    return user.Role, nil
+}
+
+// GetBorrowerRole fetches the role of a borrower on an existing loan. Anggota yang sudah dihapus
+// (soft delete) tetap dicari, agar pengembalian, perpanjangan dan denda pinjamannya tetap berjalan.
+func (r *LoanRepository) GetBorrowerRole(userID uuid.UUID) (int, error) {
+    var user models.User
+    if err := r.DB.Unscoped().Select("role").Where("id = ?", userID).First(&user).Error; err != nil {
+        return 0, err
+    }
+    return user.Role, nil
+}
+
+// CountActiveLoans counts loans the user has not yet returned.
+func (r *LoanRepository) CountActiveLoans(userID uuid.UUID) (int64, error) {
+    var count int64
+    err := r.DB.Model(&models.LoanRecord{}).
+        Where("user_id = ? AND returned = false", userID).
+        Count(&count).Error
+    return count, err
+}
+
+// CountPendingRequests counts the user's loan requests still waiting for approval.
+func (r *LoanRepository) CountPendingRequests(userID uuid.UUID) (int64, error) {
+    var count int64
+    err := r.DB.Model(&models.LoanRequest{}).
+        Where("user_id = ? AND status = ?", userID, "PENDING").
+        Count(&count).Error
+    return count, err
+}
+
+// HasPendingRequest reports whether the user already has a pending request for the book.
+func (r *LoanRepository) HasPendingRequest(userID uuid.UUID, bookID int) (bool, error) {
+    var count int64
+    err := r.DB.Model(&models.LoanRequest{}).
+        Where("user_id = ? AND book_id = ? AND status = ?", userID, bookID, "PENDING").
+        Count(&count).Error
+    return count > 0, err
+}
+
+// HasActiveLoanForBook reports whether the user currently holds a copy of the book.
+func (r *LoanRepository) HasActiveLoanForBook(userID uuid.UUID, bookID int) (bool, error) {
+    var count int64
+    err := r.DB.Model(&models.LoanRecord{}).
+        Where("user_id = ? AND book_id = ? AND returned = false", userID, bookID).
+        Count(&count).Error
+    return count > 0, err
+}
+
+// CountOverdueLoans counts the user's unreturned loans past their due date.
+func (r *LoanRepository) CountOverdueLoans(userID uuid.UUID) (int64, error) {
+    var count int64
+    err := r.DB.Model(&models.LoanRecord{}).
+        Where("user_id = ? AND returned = false AND due_date < NOW()", userID).
+        Count(&count).Error
+    return count, err
+}
+
+// GetOverdueLoans retrieves every unreturned loan past its due date, most overdue first.
+func (r *LoanRepository) GetOverdueLoans() ([]map[string]interface{}, error) {
     var results []map[string]interface{}
 
     query := `
-        SELECT lr.id, lr.book_id, lr.request_time, lr.status, lr.reject_reason,
-               u.username AS borrower_name
-        FROM loan_requests lr
-        JOIN users u ON lr.user_id = u.id;
+        SELECT lr.id, lr.book_id, lr.copy_id, b.title, lr.loan_date, lr.due_date,
+               u.username AS borrower_name, u.email,
+               EXTRACT(DAY FROM NOW() - lr.due_date)::int AS days_overdue
+        FROM loan_records lr
+        JOIN users u ON lr.user_id = u.id
+        JOIN books b ON lr.book_id = b.id
+        WHERE lr.returned = false AND lr.due_date < NOW()
+        ORDER BY lr.due_date;
     `
 
     if err := r.DB.Raw(query).Scan(&results).Error; err != nil {
@@ -103,21 +278,44 @@ func (r *LoanRepository) GetAllLoanRequests() ([]map[string]interface{}, error)
     return results, nil
 }
 
-// GetAllLoanRecords retrieves all loan records with the corresponding user's username and return_date.
-func (r *LoanRepository) GetAllLoanRecords() ([]map[string]interface{}, error) {
+// GetAllLoanRequests fetches one page of loan requests along with the borrower's username.
+func (r *LoanRepository) GetAllLoanRequests(spec *query.Spec) ([]map[string]interface{}, *query.Page, error) {
     var results []map[string]interface{}
 
-    query := `
-        SELECT lr.id, lr.book_id, lr.loan_date, lr.due_date, lr.returned, 
-               lr.return_date, u.username AS borrower_name
+    base := `
+        SELECT lr.id, lr.book_id, lr.user_id, lr.request_time, lr.status, lr.reject_reason,
+               u.username AS borrower_name
+        FROM loan_requests lr
+        JOIN users u ON lr.user_id = u.id
+    `
+
+    page, err := findPage(r.DB.Table("(?) AS q", r.DB.Raw(base)), spec, &results)
+    if err != nil {
+        return nil, nil, err
+    }
+    return results, page, nil
+}
+
+// GetAllLoanRecords retrieves one page of loan records with the corresponding user's username and return_date.
+// status diturunkan dari returned dan due_date: RETURNED, OVERDUE atau ON_LOAN.
+func (r *LoanRepository) GetAllLoanRecords(spec *query.Spec) ([]map[string]interface{}, *query.Page, error) {
+    var results []map[string]interface{}
+
+    base := `
+        SELECT lr.id, lr.book_id, lr.copy_id, lr.user_id, lr.loan_date, lr.due_date, lr.returned,
+               lr.return_date, u.username AS borrower_name,
+               CASE WHEN lr.returned THEN 'RETURNED'
+                    WHEN lr.due_date < NOW() THEN 'OVERDUE'
+                    ELSE 'ON_LOAN' END AS status
         FROM loan_records lr
-        JOIN users u ON lr.user_id = u.id;
+        JOIN users u ON lr.user_id = u.id
     `
 
-    if err := r.DB.Raw(query).Scan(&results).Error; err != nil {
-        return nil, err
+    page, err := findPage(r.DB.Table("(?) AS q", r.DB.Raw(base)), spec, &results)
+    if err != nil {
+        return nil, nil, err
     }
-    return results, nil
+    return results, page, nil
 }
 
 // GetLoansByUsername retrieves all loans associated with the given username.
@@ -139,6 +337,43 @@ func (r *LoanRepository) GetLoansByUsername(username string) ([]map[string]inter
     return results, nil
 }
 
+// GetLoansByUserID retrieves all loans associated with the given user ID.
+func (r *LoanRepository) GetLoansByUserID(userID uuid.UUID) ([]map[string]interface{}, error) {
+    var results []map[string]interface{}
+
+    query := `
+        SELECT lr.id, lr.book_id, lr.copy_id, lr.loan_date, lr.due_date, lr.returned,
+               lr.return_date, lr.renewal_count, u.username AS borrower_name
+        FROM loan_records lr
+        JOIN users u ON lr.user_id = u.id
+        WHERE lr.user_id = ?
+        ORDER BY lr.loan_date DESC;
+    `
+
+    if err := r.DB.Raw(query, userID).Scan(&results).Error; err != nil {
+        return nil, err
+    }
+    return results, nil
+}
+
+// GetLoanRequestsByUserID retrieves all loan requests filed for the given user ID.
+func (r *LoanRepository) GetLoanRequestsByUserID(userID uuid.UUID) ([]map[string]interface{}, error) {
+    var results []map[string]interface{}
+
+    query := `
+        SELECT lr.id, lr.book_id, lr.request_time, lr.status, lr.reject_reason,
+               lr.requested_by, lr.cancelled_by
+        FROM loan_requests lr
+        WHERE lr.user_id = ?
+        ORDER BY lr.request_time DESC;
+    `
+
+    if err := r.DB.Raw(query, userID).Scan(&results).Error; err != nil {
+        return nil, err
+    }
+    return results, nil
+}
+
 // GetActiveLoanByUsername retrieves an active loan record for the given username.
 func (r *LoanRepository) GetActiveLoanByUsername(username string) (*models.LoanRecord, error) {
     var loan models.LoanRecord
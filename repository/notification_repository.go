@@ -0,0 +1,135 @@
+// repository/notification_repository.go
+package repository
+
+import (
+    "time"
+    "auth-user-api/models"
+    "auth-user-api/query"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+// Outbox notifikasi memakai LoanRepository agar notifikasi dibuat di transaksi yang sama
+// dengan persetujuan pinjaman atau job overdue; pengirimannya terjadi di luar transaksi itu.
+
+// GetNotificationRecipient loads the fields needed to address a notification to a user
+func (r *LoanRepository) GetNotificationRecipient(userID uuid.UUID) (*models.User, error) {
+    var user models.User
+    err := r.DB.Select("id", "username", "email", "locale").
+        Where("id = ? AND deleted_at IS NULL", userID).First(&user).Error
+    if err != nil {
+        return nil, err
+    }
+    return &user, nil
+}
+
+func (r *LoanRepository) UpdateUserLocale(userID uuid.UUID, locale string) error {
+    result := r.DB.Model(&models.User{}).Where("id = ? AND deleted_at IS NULL", userID).Update("locale", locale)
+    if result.Error != nil {
+        return result.Error
+    }
+    if result.RowsAffected == 0 {
+        return gorm.ErrRecordNotFound
+    }
+    return nil
+}
+
+func (r *LoanRepository) CreateNotifications(notifications []*models.Notification) error {
+    if len(notifications) == 0 {
+        return nil
+    }
+    return r.DB.Create(&notifications).Error
+}
+
+// ClaimDueNotifications mengambil sampai limit notifikasi PENDING yang sudah waktunya dikirim
+// dan menggeser next_attempt_at sejauh lease. Baris yang sedang dikunci instance lain dilewati,
+// dan jika pengirim mati di tengah jalan baris akan diambil lagi setelah lease habis.
+func (r *LoanRepository) ClaimDueNotifications(now time.Time, limit int, lease time.Duration) ([]*models.Notification, error) {
+    var notifications []*models.Notification
+    err := r.DB.Transaction(func(tx *gorm.DB) error {
+        err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+            Where("status = ? AND next_attempt_at <= ?", models.NotificationStatusPending, now).
+            Order("next_attempt_at, id").Limit(limit).Find(&notifications).Error
+        if err != nil || len(notifications) == 0 {
+            return err
+        }
+
+        ids := make([]uint, len(notifications))
+        for i, n := range notifications {
+            ids[i] = n.ID
+        }
+        return tx.Model(&models.Notification{}).Where("id IN ?", ids).
+            Update("next_attempt_at", now.Add(lease)).Error
+    })
+    return notifications, err
+}
+
+func (r *LoanRepository) MarkNotificationSent(id uint, attempts int, sentAt time.Time) error {
+    return r.DB.Model(&models.Notification{}).Where("id = ?", id).Updates(map[string]interface{}{
+        "status":     models.NotificationStatusSent,
+        "attempts":   attempts,
+        "sent_at":    sentAt,
+        "last_error": nil,
+    }).Error
+}
+
+// MarkNotificationFailed mencatat percobaan yang gagal. Jika final, status menjadi FAILED
+// dan tidak dicoba lagi; selain itu notifikasi tetap PENDING sampai nextAttempt.
+func (r *LoanRepository) MarkNotificationFailed(id uint, attempts int, lastError string, nextAttempt time.Time, final bool) error {
+    updates := map[string]interface{}{
+        "attempts":        attempts,
+        "last_error":      lastError,
+        "next_attempt_at": nextAttempt,
+    }
+    if final {
+        updates["status"] = models.NotificationStatusFailed
+    }
+    return r.DB.Model(&models.Notification{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// GetInbox fetches one page of a user's in-app notifications
+func (r *LoanRepository) GetInbox(userID uuid.UUID, spec *query.Spec) ([]*models.Notification, *query.Page, error) {
+    var notifications []*models.Notification
+    db := r.DB.Model(&models.Notification{}).
+        Where("notifications.user_id = ? AND notifications.channel = ?", userID, models.NotificationChannelInApp)
+    page, err := findPage(db, spec, &notifications)
+    if err != nil {
+        return nil, nil, err
+    }
+    return notifications, page, nil
+}
+
+func (r *LoanRepository) CountUnreadNotifications(userID uuid.UUID) (int64, error) {
+    var count int64
+    err := r.DB.Model(&models.Notification{}).
+        Where("user_id = ? AND channel = ? AND read_at IS NULL", userID, models.NotificationChannelInApp).
+        Count(&count).Error
+    return count, err
+}
+
+// MarkNotificationRead menandai satu notifikasi inbox milik user sebagai sudah dibaca.
+// Notifikasi milik user lain diperlakukan seperti tidak ada.
+func (r *LoanRepository) MarkNotificationRead(userID uuid.UUID, id uint, readAt time.Time) (*models.Notification, error) {
+    var notification models.Notification
+    err := r.DB.Where("id = ? AND user_id = ? AND channel = ?", id, userID, models.NotificationChannelInApp).
+        First(&notification).Error
+    if err != nil {
+        return nil, err
+    }
+    if notification.ReadAt == nil {
+        notification.ReadAt = &readAt
+        if err := r.DB.Model(&notification).Update("read_at", readAt).Error; err != nil {
+            return nil, err
+        }
+    }
+    return &notification, nil
+}
+
+func (r *LoanRepository) MarkAllNotificationsRead(userID uuid.UUID, readAt time.Time) (int64, error) {
+    result := r.DB.Model(&models.Notification{}).
+        Where("user_id = ? AND channel = ? AND read_at IS NULL", userID, models.NotificationChannelInApp).
+        Update("read_at", readAt)
+    return result.RowsAffected, result.Error
+}
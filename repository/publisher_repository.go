@@ -3,15 +3,18 @@ package repository
 import (
     "errors"
     "auth-user-api/models"
+    "auth-user-api/query"
     "gorm.io/gorm"
 )
 
 type PublisherRepository interface {
     CreatePublisher(publisher *models.Publisher) error
     GetPublisherByID(id int) (*models.Publisher, error)
-    GetAllPublishers() ([]*models.Publisher, error)
+    GetAllPublishers(spec *query.Spec) ([]*models.Publisher, *query.Page, error)
     UpdatePublisher(publisher *models.Publisher) error
     DeletePublisher(id int) error
+    AppendEvent(event *models.DomainEvent) error
+    Transaction(fn func(tx PublisherRepository) error) error
 }
 
 type publisherRepository struct {
@@ -22,6 +25,12 @@ func NewPublisherRepository(db *gorm.DB) PublisherRepository {
     return &publisherRepository{db}
 }
 
+func (r *publisherRepository) Transaction(fn func(tx PublisherRepository) error) error {
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        return fn(&publisherRepository{tx})
+    })
+}
+
 func (r *publisherRepository) CreatePublisher(publisher *models.Publisher) error {
     return r.db.Create(publisher).Error
 }
@@ -34,18 +43,13 @@ func (r *publisherRepository) GetPublisherByID(id int) (*models.Publisher, error
     return &publisher, nil
 }
 
-func (r *publisherRepository) GetAllPublishers() ([]*models.Publisher, error) {
-    var publishers []models.Publisher
-    if err := r.db.Find(&publishers).Error; err != nil {
-        return nil, err
-    }
-
-    var publisherPointers []*models.Publisher
-    for i := range publishers {
-        publisherPointers = append(publisherPointers, &publishers[i])
+func (r *publisherRepository) GetAllPublishers(spec *query.Spec) ([]*models.Publisher, *query.Page, error) {
+    var publishers []*models.Publisher
+    page, err := findPage(r.db.Model(&models.Publisher{}), spec, &publishers)
+    if err != nil {
+        return nil, nil, err
     }
-
-    return publisherPointers, nil
+    return publishers, page, nil
 }
 
 func (r *publisherRepository) UpdatePublisher(publisher *models.Publisher) error {
@@ -0,0 +1,197 @@
+// repository/query_schemas.go
+package repository
+
+import (
+    "auth-user-api/query"
+    "auth-user-api/utils"
+
+    "gorm.io/gorm"
+)
+
+// Schema list endpoint: field yang boleh dipakai untuk filter dan sort beserta kolom SQL-nya.
+// Kolom loan memakai alias q karena query-nya dibungkus sebagai subquery.
+
+var BookQuerySchema = &query.Schema{
+    Fields: map[string]query.Field{
+        "id":               {Column: "books.id", Type: query.Int, Sortable: true, Filterable: true},
+        "title":            {Column: "books.title", Type: query.String, Sortable: true, Filterable: true},
+        // category boleh NULL pada buku lama; COALESCE agar baris itu tidak hilang dari halaman cursor
+        "category":         {Column: "COALESCE(books.category, '')", Type: query.String, Sortable: true, Filterable: true},
+        "author_id":        {Column: "books.author_id", Type: query.Int, Sortable: true, Filterable: true},
+        "publisher_id":     {Column: "books.publisher_id", Type: query.Int, Sortable: true, Filterable: true},
+        "stock":            {Column: "books.stock", Type: query.Int, Sortable: true, Filterable: true},
+        "in_stock":         {Type: query.Bool, Filterable: true, Condition: "books.stock > 0"},
+        "isbn":             {Column: "books.isbn", Type: query.String, Filterable: true, Normalize: utils.NormalizeISBN},
+        "language":         {Column: "books.language", Type: query.String, Filterable: true},
+        "publication_year": {Column: "books.publication_year", Type: query.Int, Filterable: true},
+        "page_count":       {Column: "books.page_count", Type: query.Int, Filterable: true},
+        "created_at":       {Column: "books.created_at", Type: query.Time, Sortable: true, Filterable: true, Key: "CreatedAt"},
+    },
+    DefaultSort: []query.Sort{{Field: "id"}},
+    Tiebreaker:  "id",
+}
+
+// BookSearchQuerySchema hanya mendukung paginasi offset: rank bertipe real sehingga tidak stabil sebagai cursor
+var BookSearchQuerySchema = &query.Schema{
+    Fields: map[string]query.Field{
+        "rank":         {Column: "s.rank", Type: query.Float, Sortable: true},
+        "id":           {Column: "s.id", Type: query.Int, Sortable: true},
+        "title":        {Column: "s.title", Type: query.String, Sortable: true},
+        "category":     {Column: "s.category", Type: query.String, Filterable: true},
+        "author_id":    {Column: "s.author_id", Type: query.Int, Filterable: true},
+        "publisher_id": {Column: "s.publisher_id", Type: query.Int, Filterable: true},
+        "in_stock":     {Type: query.Bool, Filterable: true, Condition: "s.stock > 0"},
+    },
+    DefaultSort: []query.Sort{{Field: "rank", Desc: true}},
+    Tiebreaker:  "id",
+}
+
+var AuthorQuerySchema = &query.Schema{
+    Fields: map[string]query.Field{
+        "id":         {Column: "authors.id", Type: query.Int, Sortable: true, Filterable: true},
+        "name":       {Column: "authors.name", Type: query.String, Sortable: true, Filterable: true},
+        "created_at": {Column: "authors.created_at", Type: query.Time, Sortable: true, Filterable: true, Key: "CreatedAt"},
+    },
+    DefaultSort: []query.Sort{{Field: "id"}},
+    Tiebreaker:  "id",
+}
+
+var PublisherQuerySchema = &query.Schema{
+    Fields: map[string]query.Field{
+        "id":         {Column: "publishers.id", Type: query.Int, Sortable: true, Filterable: true},
+        "name":       {Column: "publishers.name", Type: query.String, Sortable: true, Filterable: true},
+        "created_at": {Column: "publishers.created_at", Type: query.Time, Sortable: true, Filterable: true, Key: "CreatedAt"},
+    },
+    DefaultSort: []query.Sort{{Field: "id"}},
+    Tiebreaker:  "id",
+}
+
+var UserQuerySchema = &query.Schema{
+    Fields: map[string]query.Field{
+        "id":         {Column: "users.id", Type: query.UUID, Sortable: true, Filterable: true},
+        "username":   {Column: "users.username", Type: query.String, Sortable: true, Filterable: true},
+        "email":      {Column: "users.email", Type: query.String, Sortable: true, Filterable: true},
+        "role":       {Column: "users.role", Type: query.Int, Sortable: true, Filterable: true},
+        "created_at": {Column: "users.created_at", Type: query.Time, Sortable: true, Filterable: true},
+    },
+    DefaultSort: []query.Sort{{Field: "created_at"}},
+    Tiebreaker:  "id",
+}
+
+var LoanRequestQuerySchema = &query.Schema{
+    Fields: map[string]query.Field{
+        "id":            {Column: "q.id", Type: query.Int, Sortable: true, Filterable: true},
+        "book_id":       {Column: "q.book_id", Type: query.Int, Sortable: true, Filterable: true},
+        "user_id":       {Column: "q.user_id", Type: query.UUID, Filterable: true},
+        "status":        {Column: "q.status", Type: query.String, Sortable: true, Filterable: true},
+        "request_time":  {Column: "q.request_time", Type: query.Time, Sortable: true, Filterable: true},
+        "borrower_name": {Column: "q.borrower_name", Type: query.String, Sortable: true, Filterable: true},
+    },
+    DefaultSort: []query.Sort{{Field: "request_time", Desc: true}},
+    Tiebreaker:  "id",
+}
+
+var LoanRecordQuerySchema = &query.Schema{
+    Fields: map[string]query.Field{
+        "id":            {Column: "q.id", Type: query.Int, Sortable: true, Filterable: true},
+        "book_id":       {Column: "q.book_id", Type: query.Int, Sortable: true, Filterable: true},
+        "copy_id":       {Column: "q.copy_id", Type: query.Int, Filterable: true},
+        "user_id":       {Column: "q.user_id", Type: query.UUID, Filterable: true},
+        "status":        {Column: "q.status", Type: query.String, Sortable: true, Filterable: true},
+        "loan_date":     {Column: "q.loan_date", Type: query.Time, Sortable: true, Filterable: true},
+        "due_date":      {Column: "q.due_date", Type: query.Time, Sortable: true, Filterable: true},
+        "return_date":   {Column: "q.return_date", Type: query.Time, Filterable: true},
+        "returned":      {Column: "q.returned", Type: query.Bool, Filterable: true},
+        "overdue":       {Type: query.Bool, Filterable: true, Condition: "q.returned = false AND q.due_date < NOW()"},
+        "borrower_name": {Column: "q.borrower_name", Type: query.String, Sortable: true, Filterable: true},
+    },
+    DefaultSort: []query.Sort{{Field: "loan_date", Desc: true}},
+    Tiebreaker:  "id",
+}
+
+var NotificationQuerySchema = &query.Schema{
+    Fields: map[string]query.Field{
+        "id":         {Column: "notifications.id", Type: query.Int, Sortable: true, Filterable: true},
+        "event":      {Column: "notifications.event", Type: query.String, Filterable: true},
+        "read":       {Type: query.Bool, Filterable: true, Condition: "notifications.read_at IS NOT NULL"},
+        "created_at": {Column: "notifications.created_at", Type: query.Time, Sortable: true, Filterable: true},
+    },
+    DefaultSort: []query.Sort{{Field: "created_at", Desc: true}},
+    Tiebreaker:  "id",
+}
+
+var AuditLogQuerySchema = &query.Schema{
+    Fields: map[string]query.Field{
+        "id":          {Column: "audit_logs.id", Type: query.Int, Sortable: true, Filterable: true},
+        "actor_id":    {Column: "audit_logs.actor_id", Type: query.UUID, Filterable: true},
+        "actor_name":  {Column: "audit_logs.actor_name", Type: query.String, Sortable: true, Filterable: true},
+        "action":      {Column: "audit_logs.action", Type: query.String, Sortable: true, Filterable: true},
+        "entity_type": {Column: "audit_logs.entity_type", Type: query.String, Sortable: true, Filterable: true},
+        "entity_id":   {Column: "audit_logs.entity_id", Type: query.String, Filterable: true},
+        "request_id":  {Column: "audit_logs.request_id", Type: query.String, Filterable: true},
+        "ip":          {Column: "audit_logs.ip", Type: query.String, Filterable: true},
+        "method":      {Column: "audit_logs.method", Type: query.String, Filterable: true},
+        "status":      {Column: "audit_logs.status", Type: query.Int, Filterable: true},
+        "created_at":  {Column: "audit_logs.created_at", Type: query.Time, Sortable: true, Filterable: true},
+    },
+    DefaultSort: []query.Sort{{Field: "created_at", Desc: true}},
+    Tiebreaker:  "id",
+}
+
+// Schema trash: baris yang dihapus, terbaru dulu. Key cursor DeletedAt mengikuti JSON gorm.Model.
+var TrashedBookQuerySchema = &query.Schema{
+    Fields: map[string]query.Field{
+        "id":         {Column: "books.id", Type: query.Int, Sortable: true, Filterable: true},
+        "title":      {Column: "books.title", Type: query.String, Sortable: true, Filterable: true},
+        "deleted_at": {Column: "books.deleted_at", Type: query.Time, Sortable: true, Filterable: true, Key: "DeletedAt"},
+    },
+    DefaultSort: []query.Sort{{Field: "deleted_at", Desc: true}},
+    Tiebreaker:  "id",
+}
+
+var TrashedAuthorQuerySchema = &query.Schema{
+    Fields: map[string]query.Field{
+        "id":         {Column: "authors.id", Type: query.Int, Sortable: true, Filterable: true},
+        "name":       {Column: "authors.name", Type: query.String, Sortable: true, Filterable: true},
+        "deleted_at": {Column: "authors.deleted_at", Type: query.Time, Sortable: true, Filterable: true, Key: "DeletedAt"},
+    },
+    DefaultSort: []query.Sort{{Field: "deleted_at", Desc: true}},
+    Tiebreaker:  "id",
+}
+
+var TrashedPublisherQuerySchema = &query.Schema{
+    Fields: map[string]query.Field{
+        "id":         {Column: "publishers.id", Type: query.Int, Sortable: true, Filterable: true},
+        "name":       {Column: "publishers.name", Type: query.String, Sortable: true, Filterable: true},
+        "deleted_at": {Column: "publishers.deleted_at", Type: query.Time, Sortable: true, Filterable: true, Key: "DeletedAt"},
+    },
+    DefaultSort: []query.Sort{{Field: "deleted_at", Desc: true}},
+    Tiebreaker:  "id",
+}
+
+var TrashedUserQuerySchema = &query.Schema{
+    Fields: map[string]query.Field{
+        "id":         {Column: "users.id", Type: query.UUID, Sortable: true, Filterable: true},
+        "username":   {Column: "users.username", Type: query.String, Sortable: true, Filterable: true},
+        "email":      {Column: "users.email", Type: query.String, Sortable: true, Filterable: true},
+        "deleted_at": {Column: "users.deleted_at", Type: query.Time, Sortable: true, Filterable: true},
+    },
+    DefaultSort: []query.Sort{{Field: "deleted_at", Desc: true}},
+    Tiebreaker:  "id",
+}
+
+// findPage menghitung total baris yang cocok dengan filter lalu mengambil satu halaman ke dest.
+// scopes (mis. Preload) dipasang setelah Count agar tidak ikut dijalankan saat menghitung.
+func findPage(db *gorm.DB, spec *query.Spec, dest interface{}, scopes ...func(*gorm.DB) *gorm.DB) (*query.Page, error) {
+    base := spec.Where(db).Session(&gorm.Session{})
+
+    var total int64
+    if err := base.Count(&total).Error; err != nil {
+        return nil, err
+    }
+
+    if err := spec.Paginate(base).Scopes(scopes...).Find(dest).Error; err != nil {
+        return nil, err
+    }
+    return spec.Finish(dest, total)
+}
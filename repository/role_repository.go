@@ -0,0 +1,86 @@
+// repository/role_repository.go
+package repository
+
+import (
+    "auth-user-api/models"
+
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+type RoleRepository interface {
+    GetAllRoles() ([]*models.Role, error)
+    GetRoleByID(id int) (*models.Role, error)
+    GetPermissionsByRole() (map[int][]string, error)
+    ReplacePermissions(roleID int, permissions []string) error
+    CreateRoleIfMissing(role *models.Role) error
+}
+
+type roleRepository struct {
+    db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+    return &roleRepository{db}
+}
+
+func (r *roleRepository) GetAllRoles() ([]*models.Role, error) {
+    var roles []*models.Role
+    if err := r.db.Preload("Permissions").Order("id").Find(&roles).Error; err != nil {
+        return nil, err
+    }
+    return roles, nil
+}
+
+func (r *roleRepository) GetRoleByID(id int) (*models.Role, error) {
+    var role models.Role
+    if err := r.db.Preload("Permissions").First(&role, "id = ?", id).Error; err != nil {
+        return nil, err
+    }
+    return &role, nil
+}
+
+// GetPermissionsByRole loads every role's permissions keyed by role ID
+func (r *roleRepository) GetPermissionsByRole() (map[int][]string, error) {
+    var rows []models.RolePermission
+    if err := r.db.Find(&rows).Error; err != nil {
+        return nil, err
+    }
+
+    permissions := make(map[int][]string)
+    for _, row := range rows {
+        permissions[row.RoleID] = append(permissions[row.RoleID], row.Permission)
+    }
+    return permissions, nil
+}
+
+// ReplacePermissions swaps a role's permission set in a single transaction
+func (r *roleRepository) ReplacePermissions(roleID int, permissions []string) error {
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Where("role_id = ?", roleID).Delete(&models.RolePermission{}).Error; err != nil {
+            return err
+        }
+        if len(permissions) == 0 {
+            return nil
+        }
+        rows := make([]models.RolePermission, len(permissions))
+        for i, permission := range permissions {
+            rows[i] = models.RolePermission{RoleID: roleID, Permission: permission}
+        }
+        return tx.Create(&rows).Error
+    })
+}
+
+// CreateRoleIfMissing inserts the role and its permissions only if the role ID does not exist yet
+func (r *roleRepository) CreateRoleIfMissing(role *models.Role) error {
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        result := tx.Clauses(clause.OnConflict{DoNothing: true}).Omit("Permissions").Create(role)
+        if result.Error != nil || result.RowsAffected == 0 {
+            return result.Error
+        }
+        if len(role.Permissions) == 0 {
+            return nil
+        }
+        return tx.Create(&role.Permissions).Error
+    })
+}
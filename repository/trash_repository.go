@@ -0,0 +1,234 @@
+// repository/trash_repository.go
+package repository
+
+import (
+    "time"
+    "auth-user-api/models"
+    "auth-user-api/query"
+
+    "gorm.io/gorm"
+)
+
+// TrashRepository membaca, memulihkan dan menghapus permanen baris soft-deleted (buku, author,
+// publisher dan user). Semua query memakai Unscoped karena baris di trash punya deleted_at.
+type TrashRepository struct {
+    DB *gorm.DB
+}
+
+func NewTrashRepository(db *gorm.DB) *TrashRepository {
+    return &TrashRepository{DB: db}
+}
+
+func (r *TrashRepository) Transaction(fn func(tx *TrashRepository) error) error {
+    return r.DB.Transaction(func(tx *gorm.DB) error {
+        return fn(&TrashRepository{DB: tx})
+    })
+}
+
+// WithAdvisoryLock runs fn on a single pinned connection while holding the Postgres
+// session advisory lock key, seperti LoanRepository.WithAdvisoryLock. acquired bernilai
+// false jika sesi lain memegang lock dan fn tidak dijalankan.
+func (r *TrashRepository) WithAdvisoryLock(key int64, fn func(conn *TrashRepository) error) (bool, error) {
+    acquired := false
+    err := r.DB.Connection(func(conn *gorm.DB) error {
+        if err := conn.Raw("SELECT pg_try_advisory_lock(?)", key).Scan(&acquired).Error; err != nil {
+            return err
+        }
+        if !acquired {
+            return nil
+        }
+        defer conn.Exec("SELECT pg_advisory_unlock(?)", key)
+        return fn(&TrashRepository{DB: conn})
+    })
+    return acquired, err
+}
+
+func (r *TrashRepository) trashed(model interface{}) *gorm.DB {
+    return r.DB.Unscoped().Model(model).Where("deleted_at IS NOT NULL")
+}
+
+// restore mengosongkan deleted_at; gorm.ErrRecordNotFound jika baris tidak ada di trash
+func (r *TrashRepository) restore(model interface{}, id interface{}) error {
+    result := r.trashed(model).Where("id = ?", id).Update("deleted_at", nil)
+    if result.Error != nil {
+        return result.Error
+    }
+    if result.RowsAffected == 0 {
+        return gorm.ErrRecordNotFound
+    }
+    return nil
+}
+
+func (r *TrashRepository) GetTrashedBooks(spec *query.Spec) ([]*models.Book, *query.Page, error) {
+    var books []*models.Book
+    page, err := findPage(r.trashed(&models.Book{}), spec, &books)
+    if err != nil {
+        return nil, nil, err
+    }
+    return books, page, nil
+}
+
+func (r *TrashRepository) GetTrashedAuthors(spec *query.Spec) ([]*models.Author, *query.Page, error) {
+    var authors []*models.Author
+    page, err := findPage(r.trashed(&models.Author{}), spec, &authors)
+    if err != nil {
+        return nil, nil, err
+    }
+    return authors, page, nil
+}
+
+func (r *TrashRepository) GetTrashedPublishers(spec *query.Spec) ([]*models.Publisher, *query.Page, error) {
+    var publishers []*models.Publisher
+    page, err := findPage(r.trashed(&models.Publisher{}), spec, &publishers)
+    if err != nil {
+        return nil, nil, err
+    }
+    return publishers, page, nil
+}
+
+func (r *TrashRepository) GetTrashedUsers(spec *query.Spec) ([]*models.User, *query.Page, error) {
+    var users []*models.User
+    page, err := findPage(r.trashed(&models.User{}), spec, &users)
+    if err != nil {
+        return nil, nil, err
+    }
+    return users, page, nil
+}
+
+func (r *TrashRepository) GetTrashedBook(id int) (*models.Book, error) {
+    var book models.Book
+    if err := r.trashed(&models.Book{}).Where("id = ?", id).First(&book).Error; err != nil {
+        return nil, err
+    }
+    return &book, nil
+}
+
+func (r *TrashRepository) GetTrashedAuthor(id int) (*models.Author, error) {
+    var author models.Author
+    if err := r.trashed(&models.Author{}).Where("id = ?", id).First(&author).Error; err != nil {
+        return nil, err
+    }
+    return &author, nil
+}
+
+func (r *TrashRepository) GetTrashedPublisher(id int) (*models.Publisher, error) {
+    var publisher models.Publisher
+    if err := r.trashed(&models.Publisher{}).Where("id = ?", id).First(&publisher).Error; err != nil {
+        return nil, err
+    }
+    return &publisher, nil
+}
+
+func (r *TrashRepository) GetTrashedUser(id string) (*models.User, error) {
+    var user models.User
+    if err := r.trashed(&models.User{}).Where("id = ?", id).First(&user).Error; err != nil {
+        return nil, err
+    }
+    return &user, nil
+}
+
+func (r *TrashRepository) RestoreBook(id int) error {
+    return r.restore(&models.Book{}, id)
+}
+
+func (r *TrashRepository) RestoreAuthor(id int) error {
+    return r.restore(&models.Author{}, id)
+}
+
+func (r *TrashRepository) RestorePublisher(id int) error {
+    return r.restore(&models.Publisher{}, id)
+}
+
+func (r *TrashRepository) RestoreUser(id string) error {
+    return r.restore(&models.User{}, id)
+}
+
+// IsTrashed melaporkan apakah baris model dengan id ada di trash, mis. author sebuah buku
+func (r *TrashRepository) IsTrashed(model interface{}, id interface{}) (bool, error) {
+    var count int64
+    err := r.trashed(model).Where("id = ?", id).Count(&count).Error
+    return count > 0, err
+}
+
+// ISBNInUse melaporkan apakah buku aktif selain excludeID sudah memakai isbn
+func (r *TrashRepository) ISBNInUse(isbn string, excludeID int) (bool, error) {
+    var count int64
+    err := r.DB.Model(&models.Book{}).Where("isbn = ? AND id <> ?", isbn, excludeID).Count(&count).Error
+    return count > 0, err
+}
+
+// UserFieldInUse melaporkan apakah user aktif selain excludeID sudah memakai nilai kolom
+// (username atau email)
+func (r *TrashRepository) UserFieldInUse(column, value, excludeID string) (bool, error) {
+    var count int64
+    err := r.DB.Model(&models.User{}).Where(column+" = ? AND id <> ?", value, excludeID).Count(&count).Error
+    return count > 0, err
+}
+
+// Purge menghapus permanen baris di trash yang deleted_at-nya sebelum cutoff. Baris yang masih
+// dirujuk riwayat (pinjaman, denda, buku) dibiarkan di trash agar riwayat tetap utuh.
+// Urutannya penting: buku dihapus lebih dulu supaya author dan publisher-nya ikut lepas.
+
+// PurgeBooks menghapus buku tanpa riwayat pinjaman, request atau hold beserta eksemplarnya;
+// kontributor ikut terhapus lewat ON DELETE CASCADE
+func (r *TrashRepository) PurgeBooks(cutoff time.Time) (int64, error) {
+    var ids []int
+    err := r.trashed(&models.Book{}).
+        Where("deleted_at < ?", cutoff).
+        Where("NOT EXISTS (SELECT 1 FROM loan_records lr WHERE lr.book_id = books.id)").
+        Where("NOT EXISTS (SELECT 1 FROM loan_requests q WHERE q.book_id = books.id)").
+        Where("NOT EXISTS (SELECT 1 FROM holds h WHERE h.book_id = books.id)").
+        Pluck("id", &ids).Error
+    if err != nil || len(ids) == 0 {
+        return 0, err
+    }
+    if err := r.DB.Where("book_id IN ?", ids).Delete(&models.BookCopy{}).Error; err != nil {
+        return 0, err
+    }
+    result := r.DB.Unscoped().Where("id IN ?", ids).Delete(&models.Book{})
+    return result.RowsAffected, result.Error
+}
+
+// PurgeAuthors menghapus author yang tidak lagi dirujuk buku mana pun, termasuk buku di trash
+func (r *TrashRepository) PurgeAuthors(cutoff time.Time) (int64, error) {
+    result := r.DB.Unscoped().
+        Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+        Where("NOT EXISTS (SELECT 1 FROM books b WHERE b.author_id = authors.id)").
+        Where("NOT EXISTS (SELECT 1 FROM book_contributors bc WHERE bc.author_id = authors.id)").
+        Delete(&models.Author{})
+    return result.RowsAffected, result.Error
+}
+
+func (r *TrashRepository) PurgePublishers(cutoff time.Time) (int64, error) {
+    result := r.DB.Unscoped().
+        Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+        Where("NOT EXISTS (SELECT 1 FROM books b WHERE b.publisher_id = publishers.id)").
+        Delete(&models.Publisher{})
+    return result.RowsAffected, result.Error
+}
+
+// PurgeUsers menghapus user tanpa riwayat sirkulasi, denda atau invite. Token dan notifikasi
+// milik user tersebut tidak berguna lagi sehingga ikut dihapus.
+func (r *TrashRepository) PurgeUsers(cutoff time.Time) (int64, error) {
+    var ids []string
+    err := r.trashed(&models.User{}).
+        Where("deleted_at < ?", cutoff).
+        Where("NOT EXISTS (SELECT 1 FROM loan_requests q WHERE users.id IN (q.user_id, q.requested_by, q.cancelled_by))").
+        Where("NOT EXISTS (SELECT 1 FROM loan_records lr WHERE lr.user_id = users.id)").
+        Where("NOT EXISTS (SELECT 1 FROM holds h WHERE h.user_id = users.id)").
+        Where("NOT EXISTS (SELECT 1 FROM fines f WHERE f.user_id = users.id)").
+        Where("NOT EXISTS (SELECT 1 FROM payments p WHERE p.user_id = users.id)").
+        Where("NOT EXISTS (SELECT 1 FROM loan_reminders lm WHERE lm.user_id = users.id)").
+        Where("NOT EXISTS (SELECT 1 FROM invites i WHERE users.id IN (i.created_by, i.used_by))").
+        Pluck("id", &ids).Error
+    if err != nil || len(ids) == 0 {
+        return 0, err
+    }
+    for _, table := range []string{"notifications", "revoked_tokens", "refresh_tokens"} {
+        if err := r.DB.Exec("DELETE FROM "+table+" WHERE user_id IN ?", ids).Error; err != nil {
+            return 0, err
+        }
+    }
+    result := r.DB.Unscoped().Where("id IN ?", ids).Delete(&models.User{})
+    return result.RowsAffected, result.Error
+}
@@ -4,6 +4,7 @@ package repository
 
 import (
     "auth-user-api/models"
+    "auth-user-api/query"
 
     "gorm.io/gorm"
 )
@@ -14,7 +15,7 @@ type UserRepository interface {
     GetUserByID(id string) (*models.User, error)
     UpdateUser(user *models.User) error
     DeleteUser(id string) error
-    GetAllUsers() ([]*models.User, error)
+    GetAllUsers(spec *query.Spec) ([]*models.User, *query.Page, error)
 }
 
 type userRepository struct {
@@ -37,12 +38,13 @@ func (r *userRepository) GetUserByUsername(username string) (*models.User, error
     return &user, nil
 }
 
-func (r *userRepository) GetAllUsers() ([]*models.User, error) {
+func (r *userRepository) GetAllUsers(spec *query.Spec) ([]*models.User, *query.Page, error) {
     var users []*models.User
-    if err := r.db.Find(&users).Error; err != nil {
-        return nil, err
+    page, err := findPage(r.db.Model(&models.User{}), spec, &users)
+    if err != nil {
+        return nil, nil, err
     }
-    return users, nil
+    return users, page, nil
 }
 
 func (r *userRepository) GetUserByID(id string) (*models.User, error) {
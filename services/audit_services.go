@@ -0,0 +1,122 @@
+// services/audit_services.go
+package services
+
+import (
+    "encoding/json"
+    "reflect"
+    "strings"
+    "auth-user-api/models"
+    "auth-user-api/query"
+    "auth-user-api/repository"
+)
+
+// AuditEntry is a change a handler reports for the audit log. Before dan After boleh berupa
+// struct, map atau nil; keduanya di-encode ke JSON lalu hanya field yang berbeda disimpan.
+type AuditEntry struct {
+    Action     string // mis. "book.update"
+    EntityType string
+    EntityID   string
+    Before     interface{} // nil untuk create
+    After      interface{} // nil untuk delete
+}
+
+type AuditService interface {
+    // Record melengkapi log (actor, request, status) dengan diff entry lalu menyimpannya
+    Record(log *models.AuditLog, entry *AuditEntry) error
+    GetAuditLogs(spec *query.Spec) ([]*models.AuditLog, *query.Page, error)
+}
+
+type auditService struct {
+    repo repository.AuditRepository
+}
+
+func NewAuditService(repo repository.AuditRepository) AuditService {
+    return &auditService{repo}
+}
+
+func (s *auditService) Record(log *models.AuditLog, entry *AuditEntry) error {
+    log.Action = entry.Action
+    log.EntityType = entry.EntityType
+    log.EntityID = entry.EntityID
+
+    before, after, err := auditDiff(entry.Before, entry.After)
+    if err != nil {
+        return err
+    }
+    log.Before = before
+    log.After = after
+    return s.repo.CreateAuditLog(log)
+}
+
+func (s *auditService) GetAuditLogs(spec *query.Spec) ([]*models.AuditLog, *query.Page, error) {
+    return s.repo.GetAuditLogs(spec)
+}
+
+// auditIgnoredFields berubah di setiap update dan hanya menambah noise pada diff
+var auditIgnoredFields = map[string]bool{"updated_at": true}
+
+// auditDiff mengembalikan nilai lama dan baru dari field yang berubah. Pada create atau
+// delete seluruh field dicatat di sisi yang ada.
+func auditDiff(before, after interface{}) (models.JSONMap, models.JSONMap, error) {
+    b, err := auditFields(before)
+    if err != nil {
+        return nil, nil, err
+    }
+    a, err := auditFields(after)
+    if err != nil {
+        return nil, nil, err
+    }
+    if before == nil || after == nil {
+        return b, a, nil
+    }
+
+    changedBefore, changedAfter := models.JSONMap{}, models.JSONMap{}
+    for key, value := range b {
+        if !auditIgnoredFields[key] && !reflect.DeepEqual(value, a[key]) {
+            changedBefore[key] = value
+        }
+    }
+    for key, value := range a {
+        if !auditIgnoredFields[key] && !reflect.DeepEqual(value, b[key]) {
+            changedAfter[key] = value
+        }
+    }
+    return changedBefore, changedAfter, nil
+}
+
+// auditFields mengubah v menjadi objek JSON dengan rahasia disamarkan. Nilai yang bukan
+// objek (mis. daftar permission) disimpan di bawah kunci "value".
+func auditFields(v interface{}) (models.JSONMap, error) {
+    fields := models.JSONMap{}
+    if v == nil {
+        return fields, nil
+    }
+    raw, err := json.Marshal(v)
+    if err != nil {
+        return nil, err
+    }
+    var decoded interface{}
+    if err := json.Unmarshal(raw, &decoded); err != nil {
+        return nil, err
+    }
+    if object, ok := decoded.(map[string]interface{}); ok {
+        fields = object
+    } else {
+        fields["value"] = decoded
+    }
+    redactSecrets(fields)
+    return fields, nil
+}
+
+func redactSecrets(fields map[string]interface{}) {
+    for key, value := range fields {
+        lower := strings.ToLower(key)
+        if strings.Contains(lower, "password") || strings.Contains(lower, "token") || strings.Contains(lower, "secret") {
+            fields[key] = "[REDACTED]"
+            continue
+        }
+        if nested, ok := value.(map[string]interface{}); ok {
+            redactSecrets(nested)
+        }
+    }
+}
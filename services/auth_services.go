@@ -0,0 +1,251 @@
+// services/auth_services.go
+package services
+
+import (
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "errors"
+    "log"
+    "time"
+    "auth-user-api/models"
+    "auth-user-api/repository"
+
+    "github.com/golang-jwt/jwt/v4"
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+)
+
+var (
+    ErrInvalidToken        = errors.New("invalid or expired token")
+    ErrTokenRevoked        = errors.New("token has been revoked")
+    ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+    ErrRefreshTokenReused  = errors.New("refresh token reuse detected, session revoked")
+)
+
+// TokenClaims are the claims carried by an access token. ID (jti) is used for revocation.
+type TokenClaims struct {
+    UserID   string `json:"user_id"`
+    Username string `json:"username"`
+    Role     int    `json:"role"`
+    jwt.RegisteredClaims
+}
+
+// TokenPair is the result of a login or refresh
+type TokenPair struct {
+    AccessToken      string
+    AccessExpiresAt  time.Time
+    RefreshToken     string
+    RefreshExpiresAt time.Time
+}
+
+type AuthService interface {
+    Login(username, password string) (*TokenPair, error)
+    Refresh(refreshToken string) (*TokenPair, error)
+    ParseAccessToken(tokenString string) (*TokenClaims, error)
+    Logout(claims *TokenClaims) error
+    LogoutAll(userID string) error
+    RunCleanupWorker(interval time.Duration)
+}
+
+type authService struct {
+    users      UserService
+    repo       repository.AuthTokenRepository
+    secret     []byte
+    accessTTL  time.Duration
+    refreshTTL time.Duration
+}
+
+// NewAuthService membuat AuthService yang menerbitkan access token berumur pendek (accessTTL)
+// dan refresh token yang dirotasi setiap dipakai (refreshTTL)
+func NewAuthService(users UserService, repo repository.AuthTokenRepository, secret []byte, accessTTL, refreshTTL time.Duration) AuthService {
+    return &authService{users: users, repo: repo, secret: secret, accessTTL: accessTTL, refreshTTL: refreshTTL}
+}
+
+// Login mengautentikasi user dan membuka sesi baru
+func (s *authService) Login(username, password string) (*TokenPair, error) {
+    user, err := s.users.Authenticate(username, password)
+    if err != nil {
+        return nil, err
+    }
+
+    pair, session, err := s.issue(user, uuid.New())
+    if err != nil {
+        return nil, err
+    }
+    if err := s.repo.CreateRefreshToken(session); err != nil {
+        return nil, err
+    }
+    return pair, nil
+}
+
+// Refresh menukar refresh token dengan pasangan token baru. Token lama langsung dicabut;
+// jika token yang sudah dirotasi dipakai lagi, seluruh rantai sesinya ikut dicabut.
+func (s *authService) Refresh(refreshToken string) (*TokenPair, error) {
+    current, err := s.repo.GetRefreshTokenByHash(hashRefreshToken(refreshToken))
+    if err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return nil, ErrInvalidRefreshToken
+        }
+        return nil, err
+    }
+
+    if current.RevokedAt != nil {
+        if current.ReplacedByID != nil {
+            return nil, s.revokeReusedFamily(current)
+        }
+        return nil, ErrInvalidRefreshToken
+    }
+    if time.Now().After(current.ExpiresAt) {
+        return nil, ErrInvalidRefreshToken
+    }
+
+    // Role dan username diambil ulang agar perubahan data user ikut masuk ke token baru
+    user, err := s.users.GetUserByID(current.UserID.String())
+    if err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return nil, ErrInvalidRefreshToken
+        }
+        return nil, err
+    }
+
+    pair, next, err := s.issue(user, current.FamilyID)
+    if err != nil {
+        return nil, err
+    }
+    if err := s.repo.RotateRefreshToken(current, next); err != nil {
+        if errors.Is(err, repository.ErrRowConflict) {
+            return nil, s.revokeReusedFamily(current)
+        }
+        return nil, err
+    }
+    return pair, nil
+}
+
+func (s *authService) revokeReusedFamily(token *models.RefreshToken) error {
+    if err := s.repo.RevokeFamily(token.FamilyID); err != nil {
+        return err
+    }
+    return ErrRefreshTokenReused
+}
+
+// ParseAccessToken memverifikasi signature dan masa berlaku access token serta memastikan jti belum dicabut
+func (s *authService) ParseAccessToken(tokenString string) (*TokenClaims, error) {
+    claims := &TokenClaims{}
+    token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+        if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+            return nil, ErrInvalidToken
+        }
+        return s.secret, nil
+    })
+    if err != nil || !token.Valid || claims.ID == "" {
+        return nil, ErrInvalidToken
+    }
+
+    revoked, err := s.repo.IsJTIRevoked(claims.ID)
+    if err != nil {
+        return nil, err
+    }
+    if revoked {
+        return nil, ErrTokenRevoked
+    }
+    return claims, nil
+}
+
+// Logout mencabut access token yang sedang dipakai beserta sesi refresh token-nya
+func (s *authService) Logout(claims *TokenClaims) error {
+    session, err := s.repo.GetRefreshTokenByAccessJTI(claims.ID)
+    if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+        return err
+    }
+    if session != nil {
+        if err := s.repo.RevokeFamily(session.FamilyID); err != nil {
+            return err
+        }
+    }
+
+    userID, err := uuid.Parse(claims.UserID)
+    if err != nil {
+        return ErrInvalidToken
+    }
+    return s.repo.RevokeJTI(&models.RevokedToken{
+        JTI:       claims.ID,
+        UserID:    userID,
+        ExpiresAt: claims.ExpiresAt.Time,
+    })
+}
+
+// LogoutAll mencabut semua sesi milik user
+func (s *authService) LogoutAll(userID string) error {
+    id, err := uuid.Parse(userID)
+    if err != nil {
+        return ErrInvalidToken
+    }
+    return s.repo.RevokeAllForUser(id)
+}
+
+// RunCleanupWorker menghapus jti dan refresh token kedaluwarsa setiap interval
+func (s *authService) RunCleanupWorker(interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for range ticker.C {
+        if err := s.repo.PurgeExpired(time.Now()); err != nil {
+            log.Printf("Failed to purge expired tokens: %v", err)
+        }
+    }
+}
+
+// issue menandatangani access token baru dan menyiapkan baris refresh token untuk sesi familyID
+func (s *authService) issue(user *models.User, familyID uuid.UUID) (*TokenPair, *models.RefreshToken, error) {
+    userID, err := uuid.Parse(user.ID)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    now := time.Now()
+    accessExpiresAt := now.Add(s.accessTTL)
+    claims := &TokenClaims{
+        UserID:   user.ID,
+        Username: user.Username,
+        Role:     user.Role,
+        RegisteredClaims: jwt.RegisteredClaims{
+            ID:        uuid.NewString(),
+            Subject:   user.ID,
+            IssuedAt:  jwt.NewNumericDate(now),
+            ExpiresAt: jwt.NewNumericDate(accessExpiresAt),
+        },
+    }
+    accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    raw := make([]byte, 32)
+    if _, err := rand.Read(raw); err != nil {
+        return nil, nil, err
+    }
+    refreshToken := base64.RawURLEncoding.EncodeToString(raw)
+
+    session := &models.RefreshToken{
+        UserID:          userID,
+        FamilyID:        familyID,
+        TokenHash:       hashRefreshToken(refreshToken),
+        AccessJTI:       claims.ID,
+        AccessExpiresAt: accessExpiresAt,
+        ExpiresAt:       now.Add(s.refreshTTL),
+    }
+
+    return &TokenPair{
+        AccessToken:      accessToken,
+        AccessExpiresAt:  accessExpiresAt,
+        RefreshToken:     refreshToken,
+        RefreshExpiresAt: session.ExpiresAt,
+    }, session, nil
+}
+
+func hashRefreshToken(token string) string {
+    sum := sha256.Sum256([]byte(token))
+    return hex.EncodeToString(sum[:])
+}
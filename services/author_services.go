@@ -2,16 +2,24 @@
 package services
 
 import (
+    "errors"
+    "fmt"
+    "auth-user-api/events"
     "auth-user-api/models"
+    "auth-user-api/query"
     "auth-user-api/repository"
 )
 
+var ErrAuthorHasBooks = errors.New("author is still referenced by books")
+
 type AuthorService interface {
     CreateAuthor(author *models.Author) error
     GetAuthorByID(id int) (*models.Author, error)
-    GetAllAuthors() ([]*models.Author, error)
+    GetAllAuthors(spec *query.Spec) ([]*models.Author, *query.Page, error)
     UpdateAuthor(author *models.Author) error
-    DeleteAuthor(id int) error
+    // DeleteAuthor memindahkan author ke trash; tanpa force ditolak selama masih dipakai buku
+    DeleteAuthor(id int, force bool) error
+    GetContributions(authorIDs ...int) (map[int][]repository.AuthorContribution, error)
 }
 
 type authorService struct {
@@ -23,21 +31,50 @@ func NewAuthorService(repo repository.AuthorRepository) AuthorService {
 }
 
 func (s *authorService) CreateAuthor(author *models.Author) error {
-    return s.repo.CreateAuthor(author)
+    return s.repo.Transaction(func(tx repository.AuthorRepository) error {
+        if err := tx.CreateAuthor(author); err != nil {
+            return err
+        }
+        return recordEvent(tx, events.AuthorChanged{Type: events.TypeAuthorCreated, AuthorID: author.ID, Name: author.Name})
+    })
 }
 
 func (s *authorService) GetAuthorByID(id int) (*models.Author, error) {
     return s.repo.GetAuthorByID(id)
 }
 
-func (s *authorService) GetAllAuthors() ([]*models.Author, error) {
-    return s.repo.GetAllAuthors()
+func (s *authorService) GetAllAuthors(spec *query.Spec) ([]*models.Author, *query.Page, error) {
+    return s.repo.GetAllAuthors(spec)
 }
 
 func (s *authorService) UpdateAuthor(author *models.Author) error {
-    return s.repo.UpdateAuthor(author)
+    return s.repo.Transaction(func(tx repository.AuthorRepository) error {
+        if err := tx.UpdateAuthor(author); err != nil {
+            return err
+        }
+        return recordEvent(tx, events.AuthorChanged{Type: events.TypeAuthorUpdated, AuthorID: author.ID, Name: author.Name})
+    })
+}
+
+func (s *authorService) DeleteAuthor(id int, force bool) error {
+    return s.repo.Transaction(func(tx repository.AuthorRepository) error {
+        if !force {
+            books, err := tx.CountBooks(id)
+            if err != nil {
+                return err
+            }
+            if books > 0 {
+                return &ConflictError{Err: fmt.Errorf("%w (%d books); reassign them first or delete with force", ErrAuthorHasBooks, books)}
+            }
+        }
+        if err := tx.DeleteAuthor(id); err != nil {
+            return err
+        }
+        return recordEvent(tx, events.AuthorChanged{Type: events.TypeAuthorDeleted, AuthorID: id})
+    })
 }
 
-func (s *authorService) DeleteAuthor(id int) error {
-    return s.repo.DeleteAuthor(id)
+// GetContributions returns the books each author contributed to, keyed by author ID
+func (s *authorService) GetContributions(authorIDs ...int) (map[int][]repository.AuthorContribution, error) {
+    return s.repo.GetContributions(authorIDs)
 }
@@ -0,0 +1,140 @@
+// services/book_copy_services.go
+package services
+
+import (
+    "errors"
+    "time"
+    "auth-user-api/models"
+    "auth-user-api/repository"
+)
+
+var (
+    ErrInvalidCopyStatus = errors.New("invalid copy status")
+    ErrCopyStatusManaged = errors.New("copy status is managed by circulation: on-loan copies can only be marked as lost, held copies cannot be changed")
+)
+
+type BookCopyService interface {
+    AddCopy(bookCopy *models.BookCopy) error
+    GetCopyByID(id uint) (*models.BookCopy, error)
+    GetCopiesByBookID(bookID int) ([]*models.BookCopy, error)
+    UpdateCopy(id uint, update *CopyUpdate) (*models.BookCopy, error)
+    GetLoanHistory(copyID uint) ([]*models.LoanRecord, error)
+    RecalculateStock(bookID int) ([]*repository.StockReconciliation, error)
+}
+
+// CopyUpdate berisi field eksemplar yang boleh diubah admin; nil berarti tidak diubah
+type CopyUpdate struct {
+    Barcode       *string
+    Condition     *string
+    ShelfLocation *string
+    Status        *string
+}
+
+type bookCopyService struct {
+    repo     repository.BookCopyRepository
+    bookRepo repository.BookRepository
+}
+
+func NewBookCopyService(repo repository.BookCopyRepository, bookRepo repository.BookRepository) BookCopyService {
+    return &bookCopyService{repo: repo, bookRepo: bookRepo}
+}
+
+// AddCopy mendaftarkan eksemplar baru untuk buku yang sudah ada
+func (s *bookCopyService) AddCopy(bookCopy *models.BookCopy) error {
+    if _, err := s.bookRepo.GetBookByID(bookCopy.BookID); err != nil {
+        return err
+    }
+    if bookCopy.Barcode == "" {
+        return errors.New("barcode is required")
+    }
+    if bookCopy.Status == "" {
+        bookCopy.Status = models.CopyStatusAvailable
+    }
+    if isCirculationStatus(bookCopy.Status) || !models.IsValidCopyStatus(bookCopy.Status) {
+        return ErrInvalidCopyStatus
+    }
+    if bookCopy.AcquisitionDate.IsZero() {
+        bookCopy.AcquisitionDate = time.Now()
+    }
+    return s.repo.CreateCopy(bookCopy)
+}
+
+func (s *bookCopyService) GetCopyByID(id uint) (*models.BookCopy, error) {
+    return s.repo.GetCopyByID(id)
+}
+
+func (s *bookCopyService) GetCopiesByBookID(bookID int) ([]*models.BookCopy, error) {
+    return s.repo.GetCopiesByBookID(bookID)
+}
+
+// UpdateCopy menyimpan perubahan eksemplar. Status ON_LOAN dan ON_HOLD hanya diatur oleh alur
+// peminjaman, jadi perpindahan status diperiksa terhadap baris yang dikunci: peminjaman yang
+// mengambil eksemplar bersamaan tidak bisa tertimpa.
+func (s *bookCopyService) UpdateCopy(id uint, update *CopyUpdate) (*models.BookCopy, error) {
+    var bookCopy *models.BookCopy
+    err := s.repo.Transaction(func(tx repository.BookCopyRepository) error {
+        var err error
+        if bookCopy, err = tx.LockCopy(id); err != nil {
+            return err
+        }
+
+        if update.Status != nil && *update.Status != bookCopy.Status {
+            status := *update.Status
+            if !models.IsValidCopyStatus(status) || isCirculationStatus(status) {
+                return ErrInvalidCopyStatus
+            }
+            if bookCopy.Status == models.CopyStatusOnHold {
+                return ErrCopyStatusManaged
+            }
+            if bookCopy.Status == models.CopyStatusOnLoan && status != models.CopyStatusLost {
+                return ErrCopyStatusManaged
+            }
+            bookCopy.Status = status
+        }
+        if update.Barcode != nil {
+            bookCopy.Barcode = *update.Barcode
+        }
+        if update.Condition != nil {
+            bookCopy.Condition = *update.Condition
+        }
+        if update.ShelfLocation != nil {
+            bookCopy.ShelfLocation = *update.ShelfLocation
+        }
+        return tx.UpdateCopy(bookCopy)
+    })
+    if err != nil {
+        return nil, err
+    }
+    return bookCopy, nil
+}
+
+// isCirculationStatus reports whether a status can only be set by the loan and hold flows
+func isCirculationStatus(status string) bool {
+    return status == models.CopyStatusOnLoan || status == models.CopyStatusOnHold
+}
+
+func (s *bookCopyService) GetLoanHistory(copyID uint) ([]*models.LoanRecord, error) {
+    return s.repo.GetLoanHistory(copyID)
+}
+
+// RecalculateStock menyamakan status eksemplar dengan pinjaman aktif dan menghitung ulang stok.
+// bookID 0 berarti semua buku.
+func (s *bookCopyService) RecalculateStock(bookID int) ([]*repository.StockReconciliation, error) {
+    bookIDs := []int{bookID}
+    if bookID == 0 {
+        var err error
+        if bookIDs, err = s.repo.GetAllBookIDs(); err != nil {
+            return nil, err
+        }
+    }
+
+    var results []*repository.StockReconciliation
+    for _, id := range bookIDs {
+        result, err := s.repo.ReconcileStock(id)
+        if err != nil {
+            return results, err
+        }
+        results = append(results, result)
+    }
+    return results, nil
+}
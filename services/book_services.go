@@ -2,16 +2,49 @@
 package services
 
 import (
+    "errors"
+    "fmt"
+    "net/url"
+    "regexp"
+    "strings"
+    "time"
+    "unicode"
+    "auth-user-api/events"
     "auth-user-api/models"
+    "auth-user-api/query"
     "auth-user-api/repository"
+    "auth-user-api/utils"
+
+    "gorm.io/gorm"
+)
+
+var (
+    ErrEmptySearchQuery = errors.New("search query must contain at least one letter or digit")
+    ErrInvalidBook      = errors.New("invalid book")
+    ErrDuplicateISBN    = errors.New("another book already has this ISBN")
+    ErrBookHasLoans     = errors.New("book has unreturned loans")
+    ErrBookHasRequests  = errors.New("book has pending loan requests or holds")
 )
 
+var languageCode = regexp.MustCompile(`^[a-z]{2,3}$`)
+
+const (
+    maxSubjects      = 20
+    maxSubjectLength = 100
+)
+
+// maxSearchTerms membatasi panjang tsquery yang dibangun dari input user
+const maxSearchTerms = 10
+
 type BookService interface {
     CreateBook(book *models.Book) error
     GetBookByID(id int) (*models.Book, error)
-    GetAllBooks() ([]*models.Book, error)
+    GetAllBooks(spec *query.Spec) ([]*models.Book, *query.Page, error)
+    SearchBooks(text string, spec *query.Spec) (*repository.BookSearchResult, *query.Page, error)
     UpdateBook(book *models.Book) error
-    DeleteBook(id int) error
+    // DeleteBook memindahkan buku ke trash; tanpa force ditolak selama masih ada pinjaman aktif,
+    // dan selalu ditolak selama masih ada loan request PENDING atau hold yang menunggu
+    DeleteBook(id int, force bool) error
 }
 
 type bookService struct {
@@ -22,22 +55,255 @@ func NewBookService(repo repository.BookRepository) BookService {
     return &bookService{repo}
 }
 
+// CreateBook membuat buku baru; max_stock pada input dianggap jumlah eksemplar awal
 func (s *bookService) CreateBook(book *models.Book) error {
-    return s.repo.CreateBook(book)
+    if err := normalizeContributors(book); err != nil {
+        return err
+    }
+    if err := s.normalizeMetadata(book); err != nil {
+        return err
+    }
+    return s.repo.Transaction(func(tx repository.BookRepository) error {
+        if err := tx.CreateBookWithCopies(book, book.MaxStock); err != nil {
+            return duplicateISBN(err)
+        }
+        return recordEvent(tx, bookChanged(events.TypeBookCreated, book))
+    })
 }
 
 func (s *bookService) GetBookByID(id int) (*models.Book, error) {
     return s.repo.GetBookByID(id)
 }
 
-func (s *bookService) GetAllBooks() ([]*models.Book, error) {
-    return s.repo.GetAllBooks()
+func (s *bookService) GetAllBooks(spec *query.Spec) ([]*models.Book, *query.Page, error) {
+    return s.repo.GetAllBooks(spec)
+}
+
+// SearchBooks mencari buku dengan full-text search. Setiap kata dicocokkan sebagai prefix
+// (mis. "harr pot" cocok dengan "Harry Potter") sehingga bisa dipakai untuk type-ahead.
+func (s *bookService) SearchBooks(text string, spec *query.Spec) (*repository.BookSearchResult, *query.Page, error) {
+    // Input yang berupa ISBN (dengan atau tanpa tanda hubung) dicari sebagai ISBN-13 utuh
+    if isbn, err := utils.NormalizeISBN(text); err == nil {
+        return s.repo.SearchBooks(isbn, spec)
+    }
+
+    tsquery := prefixTSQuery(text)
+    if tsquery == "" {
+        return nil, nil, ErrEmptySearchQuery
+    }
+    return s.repo.SearchBooks(tsquery, spec)
+}
+
+// prefixTSQuery mengubah input bebas menjadi "kata1:* & kata2:*". Hanya huruf dan angka yang
+// dipertahankan agar operator tsquery dari input tidak bisa membuat query yang tidak valid.
+func prefixTSQuery(text string) string {
+    words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+        return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+    })
+    if len(words) > maxSearchTerms {
+        words = words[:maxSearchTerms]
+    }
+    for i, word := range words {
+        words[i] = word + ":*"
+    }
+    return strings.Join(words, " & ")
 }
 
 func (s *bookService) UpdateBook(book *models.Book) error {
-    return s.repo.UpdateBook(book)
+    if err := normalizeContributors(book); err != nil {
+        return err
+    }
+    if err := s.normalizeMetadata(book); err != nil {
+        return err
+    }
+    return s.repo.Transaction(func(tx repository.BookRepository) error {
+        if err := tx.UpdateBook(book); err != nil {
+            return duplicateISBN(err)
+        }
+        return recordEvent(tx, bookChanged(events.TypeBookUpdated, book))
+    })
+}
+
+func bookChanged(eventType string, book *models.Book) events.BookChanged {
+    return events.BookChanged{
+        Type:        eventType,
+        BookID:      book.ID,
+        Title:       book.Title,
+        ISBN:        book.ISBN,
+        Category:    book.Category,
+        AuthorID:    book.AuthorID,
+        PublisherID: book.PublisherID,
+        Stock:       book.Stock,
+        MaxStock:    book.MaxStock,
+    }
+}
+
+// normalizeContributors memastikan setiap buku punya minimal satu kontributor dengan peran
+// yang valid. Buku yang hanya mengirim author_id mendapat satu kontributor "author".
+// Position mengikuti urutan input dan AuthorID disamakan dengan penulis pertama.
+func normalizeContributors(book *models.Book) error {
+    if len(book.Contributors) == 0 {
+        if book.AuthorID == 0 {
+            return fmt.Errorf("%w: at least one contributor or author_id is required", ErrInvalidBook)
+        }
+        book.Contributors = []models.BookContributor{{AuthorID: book.AuthorID, Role: models.ContributorRoleAuthor}}
+    }
+
+    seen := map[string]bool{}
+    primary := 0
+    for i := range book.Contributors {
+        contributor := &book.Contributors[i]
+        contributor.Role = strings.ToLower(strings.TrimSpace(contributor.Role))
+        if contributor.Role == "" {
+            contributor.Role = models.ContributorRoleAuthor
+        }
+        if !models.IsValidContributorRole(contributor.Role) {
+            return fmt.Errorf("%w: unknown contributor role %q (use author, editor, translator or illustrator)", ErrInvalidBook, contributor.Role)
+        }
+        if contributor.AuthorID <= 0 {
+            return fmt.Errorf("%w: contributor author_id is required", ErrInvalidBook)
+        }
+
+        key := fmt.Sprintf("%d/%s", contributor.AuthorID, contributor.Role)
+        if seen[key] {
+            return fmt.Errorf("%w: author %d is listed twice as %s", ErrInvalidBook, contributor.AuthorID, contributor.Role)
+        }
+        seen[key] = true
+
+        contributor.Position = i
+        if primary == 0 && contributor.Role == models.ContributorRoleAuthor {
+            primary = contributor.AuthorID
+        }
+    }
+
+    // Buku suntingan tanpa penulis memakai kontributor pertama sebagai author utama
+    if primary == 0 {
+        primary = book.Contributors[0].AuthorID
+    }
+    book.AuthorID = primary
+    return nil
 }
 
-func (s *bookService) DeleteBook(id int) error {
-    return s.repo.DeleteBook(id)
+// normalizeMetadata memvalidasi dan merapikan data bibliografis buku. Error validasi
+// membungkus ErrInvalidBook; ISBN yang sudah dipakai buku lain menjadi ConflictError.
+func (s *bookService) normalizeMetadata(book *models.Book) error {
+    if err := normalizeBookFields(book); err != nil {
+        return err
+    }
+    if book.ISBN == nil {
+        return nil
+    }
+
+    existing, err := s.repo.GetBookByISBN(*book.ISBN)
+    if err == nil && existing.ID != book.ID {
+        return &ConflictError{Err: ErrDuplicateISBN}
+    }
+    if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+        return err
+    }
+    return nil
+}
+
+// duplicateISBN memetakan pelanggaran idx_books_isbn ke ConflictError. Pemeriksaan di
+// normalizeMetadata berjalan di luar transaksi, jadi dua request bersamaan dengan ISBN yang
+// sama baru tertangkap oleh indeks unik.
+func duplicateISBN(err error) error {
+    if repository.IsUniqueViolation(err, "idx_books_isbn") {
+        return &ConflictError{Err: ErrDuplicateISBN}
+    }
+    return err
+}
+
+// normalizeBookFields adalah bagian normalizeMetadata yang tidak butuh database
+func normalizeBookFields(book *models.Book) error {
+    if book.ISBN != nil {
+        if strings.TrimSpace(*book.ISBN) == "" {
+            book.ISBN = nil
+        } else {
+            isbn, err := utils.NormalizeISBN(*book.ISBN)
+            if err != nil {
+                return fmt.Errorf("%w: %v", ErrInvalidBook, err)
+            }
+            book.ISBN = &isbn
+        }
+    }
+
+    if book.PublicationYear != nil && (*book.PublicationYear < 1 || *book.PublicationYear > time.Now().Year()+1) {
+        return fmt.Errorf("%w: publication_year must be between 1 and %d", ErrInvalidBook, time.Now().Year()+1)
+    }
+    if book.PageCount != nil && *book.PageCount < 1 {
+        return fmt.Errorf("%w: page_count must be positive", ErrInvalidBook)
+    }
+
+    book.Edition = strings.TrimSpace(book.Edition)
+    if len(book.Edition) > 100 {
+        return fmt.Errorf("%w: edition must be at most 100 characters", ErrInvalidBook)
+    }
+
+    book.Language = strings.ToLower(strings.TrimSpace(book.Language))
+    if book.Language != "" && !languageCode.MatchString(book.Language) {
+        return fmt.Errorf("%w: language must be an ISO 639-1 or 639-2 code such as \"id\" or \"eng\"", ErrInvalidBook)
+    }
+
+    book.CoverImageURL = strings.TrimSpace(book.CoverImageURL)
+    if book.CoverImageURL != "" {
+        cover, err := url.Parse(book.CoverImageURL)
+        if err != nil || (cover.Scheme != "http" && cover.Scheme != "https") || cover.Host == "" {
+            return fmt.Errorf("%w: cover_image_url must be an absolute http(s) URL", ErrInvalidBook)
+        }
+    }
+
+    // Subjek dirapikan dan duplikatnya dibuang tanpa membedakan huruf besar/kecil
+    subjects := models.StringList{}
+    seen := map[string]bool{}
+    for _, subject := range book.Subjects {
+        subject = strings.Join(strings.Fields(subject), " ")
+        if subject == "" || seen[strings.ToLower(subject)] {
+            continue
+        }
+        if len(subject) > maxSubjectLength {
+            return fmt.Errorf("%w: subjects must be at most %d characters each", ErrInvalidBook, maxSubjectLength)
+        }
+        seen[strings.ToLower(subject)] = true
+        subjects = append(subjects, subject)
+    }
+    if len(subjects) > maxSubjects {
+        return fmt.Errorf("%w: at most %d subjects are allowed", ErrInvalidBook, maxSubjects)
+    }
+    book.Subjects = subjects
+    return nil
+}
+
+func (s *bookService) DeleteBook(id int, force bool) error {
+    return s.repo.Transaction(func(tx repository.BookRepository) error {
+        // Kunci buku dulu agar tidak ada pinjaman atau request baru di antara pengecekan dan penghapusan
+        if err := tx.LockBook(id); err != nil {
+            return err
+        }
+
+        // Request dan hold yang menunggu tidak bisa dilayani lagi setelah buku dihapus, jadi
+        // selalu ditolak, juga dengan force; tolak atau batalkan lebih dulu
+        open, err := tx.CountOpenRequests(id)
+        if err != nil {
+            return err
+        }
+        if open > 0 {
+            return &ConflictError{Err: fmt.Errorf("%w (%d open); reject or cancel them first", ErrBookHasRequests, open)}
+        }
+
+        // Pinjaman yang masih berjalan tetap bisa dikembalikan dan diperpanjang setelah dihapus
+        if !force {
+            active, err := tx.CountActiveLoans(id)
+            if err != nil {
+                return err
+            }
+            if active > 0 {
+                return &ConflictError{Err: fmt.Errorf("%w (%d active); return them first or delete with force", ErrBookHasLoans, active)}
+            }
+        }
+        if err := tx.DeleteBook(id); err != nil {
+            return err
+        }
+        return recordEvent(tx, events.BookChanged{Type: events.TypeBookDeleted, BookID: id})
+    })
 }
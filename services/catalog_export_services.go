@@ -0,0 +1,408 @@
+// services/catalog_export_services.go
+package services
+
+import (
+    "encoding/csv"
+    "encoding/json"
+    "encoding/xml"
+    "errors"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+    "time"
+    "auth-user-api/models"
+    "auth-user-api/query"
+    "auth-user-api/repository"
+)
+
+const (
+    ExportFormatCSV     = "csv"
+    ExportFormatJSONL   = "jsonl"
+    ExportFormatMARCXML = "marcxml"
+)
+
+// exportBatchSize adalah jumlah buku yang dimuat dari database sekali jalan
+const exportBatchSize = 500
+
+var ErrUnknownExportFormat = errors.New("export format must be csv, jsonl or marcxml")
+
+// ExportContentType returns the Content-Type and file extension of an export format
+func ExportContentType(format string) (contentType, extension string, err error) {
+    switch format {
+    case ExportFormatCSV:
+        return "text/csv; charset=utf-8", ".csv", nil
+    case ExportFormatJSONL:
+        return "application/x-ndjson", ".jsonl", nil
+    case ExportFormatMARCXML:
+        return "application/marcxml+xml", ".xml", nil
+    }
+    return "", "", ErrUnknownExportFormat
+}
+
+type CatalogExportService interface {
+    ExportBooks(w io.Writer, format string, spec *query.Spec) error
+}
+
+type catalogExportService struct {
+    repo repository.BookRepository
+}
+
+func NewCatalogExportService(repo repository.BookRepository) CatalogExportService {
+    return &catalogExportService{repo}
+}
+
+// flusher diimplementasikan oleh response HTTP; setiap batch dikirim ke client begitu
+// selesai ditulis sehingga katalog besar tidak pernah ditampung utuh di memori
+type flusher interface {
+    Flush()
+}
+
+// bookExporter menulis satu format export: begin sekali, write per buku, end di akhir
+type bookExporter interface {
+    begin() error
+    write(book *models.Book) error
+    end() error
+    flush() error
+}
+
+// ExportBooks streams every book matching spec's filters to w in the given format
+func (s *catalogExportService) ExportBooks(w io.Writer, format string, spec *query.Spec) error {
+    var exporter bookExporter
+    switch format {
+    case ExportFormatCSV:
+        exporter = &csvExporter{w: csv.NewWriter(w)}
+    case ExportFormatJSONL:
+        exporter = &jsonlExporter{encoder: json.NewEncoder(w)}
+    case ExportFormatMARCXML:
+        encoder := xml.NewEncoder(w)
+        encoder.Indent("", "  ")
+        exporter = &marcExporter{w: w, encoder: encoder}
+    default:
+        return ErrUnknownExportFormat
+    }
+
+    // Header baru ditulis saat batch pertama tiba, sehingga error query awal masih bisa
+    // dilaporkan sebagai response error biasa sebelum ada byte yang terkirim
+    started := false
+    start := func() error {
+        if started {
+            return nil
+        }
+        started = true
+        return exporter.begin()
+    }
+
+    err := s.repo.StreamBooks(spec, exportBatchSize, func(books []*models.Book) error {
+        if err := start(); err != nil {
+            return err
+        }
+        for _, book := range books {
+            if err := exporter.write(book); err != nil {
+                return err
+            }
+        }
+        if err := exporter.flush(); err != nil {
+            return err
+        }
+        if f, ok := w.(flusher); ok {
+            f.Flush()
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    if err := start(); err != nil {
+        return err
+    }
+    if err := exporter.end(); err != nil {
+        return err
+    }
+    if f, ok := w.(flusher); ok {
+        f.Flush()
+    }
+    return nil
+}
+
+// formatContributors menulis kontributor dengan format yang sama seperti kolom authors
+// pada import CSV, mis. "Jane Doe; John Roe (editor)"
+func formatContributors(book *models.Book) string {
+    names := make([]string, len(book.Contributors))
+    for i, contributor := range book.Contributors {
+        names[i] = contributor.Author.Name
+        if contributor.Role != models.ContributorRoleAuthor {
+            names[i] += " (" + contributor.Role + ")"
+        }
+    }
+    return strings.Join(names, "; ")
+}
+
+func optionalInt(value *int) string {
+    if value == nil {
+        return ""
+    }
+    return strconv.Itoa(*value)
+}
+
+type csvExporter struct {
+    w *csv.Writer
+}
+
+func (e *csvExporter) begin() error {
+    return e.w.Write([]string{
+        "id", "title", "authors", "author_id", "publisher", "publisher_id", "isbn", "summary",
+        "category", "publication_year", "edition", "language", "page_count", "subjects",
+        "cover_image_url", "stock", "max_stock", "created_at", "updated_at",
+    })
+}
+
+func (e *csvExporter) write(book *models.Book) error {
+    isbn := ""
+    if book.ISBN != nil {
+        isbn = *book.ISBN
+    }
+    return e.w.Write([]string{
+        strconv.Itoa(book.ID),
+        book.Title,
+        formatContributors(book),
+        strconv.Itoa(book.AuthorID),
+        book.Publisher.Name,
+        strconv.Itoa(book.PublisherID),
+        isbn,
+        book.Summary,
+        book.Category,
+        optionalInt(book.PublicationYear),
+        book.Edition,
+        book.Language,
+        optionalInt(book.PageCount),
+        strings.Join(book.Subjects, "; "),
+        book.CoverImageURL,
+        strconv.Itoa(book.Stock),
+        strconv.Itoa(book.MaxStock),
+        book.CreatedAt.Format(time.RFC3339),
+        book.UpdatedAt.Format(time.RFC3339),
+    })
+}
+
+func (e *csvExporter) end() error {
+    return e.flush()
+}
+
+func (e *csvExporter) flush() error {
+    e.w.Flush()
+    return e.w.Error()
+}
+
+type exportContributor struct {
+    AuthorID int    `json:"author_id"`
+    Name     string `json:"name"`
+    Role     string `json:"role"`
+}
+
+type exportPublisher struct {
+    ID   int    `json:"id"`
+    Name string `json:"name"`
+}
+
+// exportBook adalah satu baris JSON Lines
+type exportBook struct {
+    ID              int                 `json:"id"`
+    Title           string              `json:"title"`
+    Contributors    []exportContributor `json:"contributors"`
+    Publisher       exportPublisher     `json:"publisher"`
+    ISBN            *string             `json:"isbn"`
+    Summary         string              `json:"summary"`
+    Category        string              `json:"category"`
+    PublicationYear *int                `json:"publication_year"`
+    Edition         string              `json:"edition"`
+    Language        string              `json:"language"`
+    PageCount       *int                `json:"page_count"`
+    Subjects        []string            `json:"subjects"`
+    CoverImageURL   string              `json:"cover_image_url"`
+    Stock           int                 `json:"stock"`
+    MaxStock        int                 `json:"max_stock"`
+    CreatedAt       time.Time           `json:"created_at"`
+    UpdatedAt       time.Time           `json:"updated_at"`
+}
+
+type jsonlExporter struct {
+    encoder *json.Encoder
+}
+
+func (e *jsonlExporter) begin() error { return nil }
+func (e *jsonlExporter) end() error   { return nil }
+func (e *jsonlExporter) flush() error { return nil }
+
+func (e *jsonlExporter) write(book *models.Book) error {
+    contributors := make([]exportContributor, len(book.Contributors))
+    for i, contributor := range book.Contributors {
+        contributors[i] = exportContributor{AuthorID: contributor.AuthorID, Name: contributor.Author.Name, Role: contributor.Role}
+    }
+    subjects := []string(book.Subjects)
+    if subjects == nil {
+        subjects = []string{}
+    }
+
+    // Encoder menulis langsung ke writer dan menambahkan newline setelah setiap objek
+    return e.encoder.Encode(exportBook{
+        ID:              book.ID,
+        Title:           book.Title,
+        Contributors:    contributors,
+        Publisher:       exportPublisher{ID: book.PublisherID, Name: book.Publisher.Name},
+        ISBN:            book.ISBN,
+        Summary:         book.Summary,
+        Category:        book.Category,
+        PublicationYear: book.PublicationYear,
+        Edition:         book.Edition,
+        Language:        book.Language,
+        PageCount:       book.PageCount,
+        Subjects:        subjects,
+        CoverImageURL:   book.CoverImageURL,
+        Stock:           book.Stock,
+        MaxStock:        book.MaxStock,
+        CreatedAt:       book.CreatedAt,
+        UpdatedAt:       book.UpdatedAt,
+    })
+}
+
+// MARC21-XML (MARCXML slim schema)
+
+const marcNamespace = "http://www.loc.gov/MARC21/slim"
+
+type marcRecord struct {
+    XMLName       xml.Name           `xml:"record"`
+    Leader        string             `xml:"leader"`
+    ControlFields []marcControlField `xml:"controlfield"`
+    DataFields    []marcDataField    `xml:"datafield"`
+}
+
+type marcControlField struct {
+    Tag   string `xml:"tag,attr"`
+    Value string `xml:",chardata"`
+}
+
+type marcDataField struct {
+    Tag       string        `xml:"tag,attr"`
+    Ind1      string        `xml:"ind1,attr"`
+    Ind2      string        `xml:"ind2,attr"`
+    Subfields []marcSubfield `xml:"subfield"`
+}
+
+type marcSubfield struct {
+    Code  string `xml:"code,attr"`
+    Value string `xml:",chardata"`
+}
+
+// marcLanguages memetakan kode ISO 639-1 yang umum ke kode bahasa MARC (tiga huruf)
+var marcLanguages = map[string]string{
+    "id": "ind", "en": "eng", "ms": "may", "jv": "jav", "su": "sun", "ar": "ara", "zh": "chi",
+    "ja": "jpn", "ko": "kor", "nl": "dut", "de": "ger", "fr": "fre", "es": "spa", "pt": "por",
+    "it": "ita", "ru": "rus",
+}
+
+type marcExporter struct {
+    w       io.Writer
+    encoder *xml.Encoder
+}
+
+func (e *marcExporter) begin() error {
+    _, err := io.WriteString(e.w, xml.Header+`<collection xmlns="`+marcNamespace+`">`+"\n")
+    return err
+}
+
+func (e *marcExporter) write(book *models.Book) error {
+    return e.encoder.Encode(marcBookRecord(book))
+}
+
+func (e *marcExporter) end() error {
+    if err := e.encoder.Flush(); err != nil {
+        return err
+    }
+    _, err := io.WriteString(e.w, "\n</collection>\n")
+    return err
+}
+
+func (e *marcExporter) flush() error {
+    return e.encoder.Flush()
+}
+
+// marcBookRecord membangun record bibliografis MARC21. Stok dan kategori tidak punya field
+// standar di record bibliografis, jadi ditulis di field lokal 999 ($a stock, $b max_stock,
+// $c category).
+func marcBookRecord(book *models.Book) marcRecord {
+    record := marcRecord{
+        // Record length dan base address diisi nol; MARCXML tidak memakainya
+        Leader: "00000nam a2200000 i 4500",
+        ControlFields: []marcControlField{
+            {Tag: "001", Value: strconv.Itoa(book.ID)},
+            {Tag: "005", Value: book.UpdatedAt.UTC().Format("20060102150405") + ".0"},
+            {Tag: "008", Value: marcFixedData(book)},
+        },
+    }
+    field := func(tag, ind1, ind2 string, subfields ...marcSubfield) {
+        var kept []marcSubfield
+        for _, subfield := range subfields {
+            if subfield.Value != "" {
+                kept = append(kept, subfield)
+            }
+        }
+        if len(kept) > 0 {
+            record.DataFields = append(record.DataFields, marcDataField{Tag: tag, Ind1: ind1, Ind2: ind2, Subfields: kept})
+        }
+    }
+
+    if book.ISBN != nil {
+        field("020", " ", " ", marcSubfield{"a", *book.ISBN})
+    }
+
+    // Kontributor pertama menjadi main entry (100), sisanya added entry (700)
+    titleInd1 := "0"
+    for i, contributor := range book.Contributors {
+        tag := "700"
+        if i == 0 {
+            tag = "100"
+            titleInd1 = "1"
+        }
+        field(tag, "1", " ", marcSubfield{"a", contributor.Author.Name}, marcSubfield{"e", contributor.Role})
+    }
+
+    field("245", titleInd1, "0", marcSubfield{"a", book.Title})
+    field("250", " ", " ", marcSubfield{"a", book.Edition})
+    field("264", " ", "1", marcSubfield{"b", book.Publisher.Name}, marcSubfield{"c", optionalInt(book.PublicationYear)})
+    if book.PageCount != nil {
+        field("300", " ", " ", marcSubfield{"a", fmt.Sprintf("%d pages", *book.PageCount)})
+    }
+    field("520", " ", " ", marcSubfield{"a", book.Summary})
+    for _, subject := range book.Subjects {
+        field("650", " ", "4", marcSubfield{"a", subject})
+    }
+    if book.CoverImageURL != "" {
+        field("856", "4", "2", marcSubfield{"3", "Cover image"}, marcSubfield{"u", book.CoverImageURL})
+    }
+    field("999", " ", " ",
+        marcSubfield{"a", strconv.Itoa(book.Stock)},
+        marcSubfield{"b", strconv.Itoa(book.MaxStock)},
+        marcSubfield{"c", book.Category})
+    return record
+}
+
+// marcFixedData mengisi field 008 (40 karakter): tanggal entri, tahun terbit dan bahasa
+func marcFixedData(book *models.Book) string {
+    dates := "nuuuu"
+    if book.PublicationYear != nil && *book.PublicationYear >= 1000 && *book.PublicationYear <= 9999 {
+        dates = fmt.Sprintf("s%04d", *book.PublicationYear)
+    }
+
+    language := book.Language
+    if mapped, ok := marcLanguages[language]; ok {
+        language = mapped
+    }
+    if len(language) != 3 {
+        language = "und"
+    }
+
+    // 00-05 tanggal entri, 06-14 tanggal terbit, 15-17 tempat terbit (tidak diketahui),
+    // 18-34 elemen khusus buku (kosong), 35-37 bahasa, 38 modified record, 39 sumber katalog
+    return book.CreatedAt.UTC().Format("060102") + dates + "    " + "xx " + strings.Repeat(" ", 17) + language + " " + "d"
+}
@@ -0,0 +1,575 @@
+// services/catalog_import_services.go
+package services
+
+import (
+    "bufio"
+    "bytes"
+    "encoding/csv"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "regexp"
+    "strconv"
+    "strings"
+    "auth-user-api/events"
+    "auth-user-api/models"
+    "auth-user-api/repository"
+
+    "gorm.io/gorm"
+)
+
+const (
+    ImportFormatCSV  = "csv"
+    ImportFormatJSON = "json" // JSON lines (satu objek per baris) atau satu array JSON
+
+    ImportModeAtomic = "atomic" // Semua baris dalam satu transaksi; satu baris gagal membatalkan semuanya
+    ImportModeBatch  = "batch"  // Commit per batch; baris yang gagal dilewati
+
+    DefaultImportBatchSize = 100
+    MaxImportBatchSize     = 1000
+    MaxImportRows          = 5000
+
+    // maxImportCopies membatasi jumlah eksemplar per baris agar salah ketik tidak membuat ribuan eksemplar
+    maxImportCopies = 500
+)
+
+const (
+    ImportStatusCreated = "created"
+    ImportStatusUpdated = "updated"
+    ImportStatusFailed  = "failed"
+)
+
+var (
+    ErrInvalidImportFile    = errors.New("invalid import file")
+    ErrInvalidImportOptions = errors.New("invalid import options")
+
+    // errImportRollback membatalkan transaksi dry-run atau import atomic yang punya baris gagal
+    errImportRollback = errors.New("import rolled back")
+)
+
+// ImportRow is one book in an import file. Nama kolom CSV sama dengan tag JSON; di CSV,
+// authors dan subjects dipisah ";". Setiap author boleh diberi peran, mis. "Jane Doe (editor)".
+type ImportRow struct {
+    Title           string   `json:"title"`
+    Authors         []string `json:"authors"`
+    Publisher       string   `json:"publisher"`
+    ISBN            string   `json:"isbn"`
+    Summary         string   `json:"summary"`
+    Category        string   `json:"category"`
+    PublicationYear *int     `json:"publication_year"`
+    Edition         string   `json:"edition"`
+    Language        string   `json:"language"`
+    PageCount       *int     `json:"page_count"`
+    Subjects        []string `json:"subjects"`
+    CoverImageURL   string   `json:"cover_image_url"`
+    MaxStock        *int     `json:"max_stock"` // Jumlah eksemplar
+    Stock           *int     `json:"stock"`     // Eksemplar yang tersedia; jika diisi harus sama dengan max_stock
+}
+
+// ImportRecord is a parsed row. Err diisi jika baris tidak bisa dibaca; baris tersebut
+// dilaporkan gagal tanpa menghentikan import.
+type ImportRecord struct {
+    Row  int // Urutan record di file mulai dari 1, header CSV tidak dihitung
+    Data ImportRow
+    Err  error
+}
+
+type ImportOptions struct {
+    DryRun    bool
+    Mode      string
+    BatchSize int
+}
+
+type ImportRowResult struct {
+    Row    int    `json:"row"`
+    Status string `json:"status"`
+    BookID *int   `json:"book_id,omitempty"` // Tidak diisi jika perubahan dibatalkan
+    Title  string `json:"title,omitempty"`
+    ISBN   string `json:"isbn,omitempty"`
+    Error  string `json:"error,omitempty"`
+}
+
+// ImportReport merangkum hasil import. Pada dry-run atau import atomic yang dibatalkan,
+// angka-angkanya menunjukkan apa yang akan terjadi dan Committed bernilai false.
+type ImportReport struct {
+    DryRun            bool              `json:"dry_run"`
+    Mode              string            `json:"mode"`
+    Committed         bool              `json:"committed"`
+    Total             int               `json:"total"`
+    Created           int               `json:"created"`
+    Updated           int               `json:"updated"`
+    Failed            int               `json:"failed"`
+    AuthorsCreated    int               `json:"authors_created"`
+    PublishersCreated int               `json:"publishers_created"`
+    Rows              []ImportRowResult `json:"rows"`
+}
+
+var importColumns = []string{
+    "title", "authors", "publisher", "isbn", "summary", "category", "publication_year",
+    "edition", "language", "page_count", "subjects", "cover_image_url", "max_stock", "stock",
+}
+
+// ParseImportFile reads every record of a CSV or JSON import file. Kesalahan pada satu baris
+// (mis. angka tidak valid) dicatat di record tersebut; file yang rusak secara keseluruhan
+// mengembalikan ErrInvalidImportFile.
+func ParseImportFile(r io.Reader, format string) ([]ImportRecord, error) {
+    var records []ImportRecord
+    var err error
+    switch strings.ToLower(format) {
+    case ImportFormatCSV:
+        records, err = parseImportCSV(r)
+    case ImportFormatJSON, "jsonl", "ndjson":
+        records, err = parseImportJSON(r)
+    default:
+        return nil, fmt.Errorf("%w: format must be csv or json", ErrInvalidImportFile)
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    if len(records) == 0 {
+        return nil, fmt.Errorf("%w: file contains no rows", ErrInvalidImportFile)
+    }
+    if len(records) > MaxImportRows {
+        return nil, fmt.Errorf("%w: at most %d rows can be imported at once", ErrInvalidImportFile, MaxImportRows)
+    }
+    return records, nil
+}
+
+func parseImportCSV(r io.Reader) ([]ImportRecord, error) {
+    reader := csv.NewReader(r)
+    reader.TrimLeadingSpace = true
+
+    header, err := reader.Read()
+    if err == io.EOF {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidImportFile, err)
+    }
+
+    known := map[string]bool{}
+    for _, column := range importColumns {
+        known[column] = true
+    }
+    seen := map[string]bool{}
+    for i, column := range header {
+        column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+        if !known[column] {
+            return nil, fmt.Errorf("%w: unknown column %q (allowed: %s)", ErrInvalidImportFile, column, strings.Join(importColumns, ", "))
+        }
+        if seen[column] {
+            return nil, fmt.Errorf("%w: column %q appears twice", ErrInvalidImportFile, column)
+        }
+        seen[column] = true
+        header[i] = column
+    }
+    if !seen["title"] {
+        return nil, fmt.Errorf("%w: the title column is required", ErrInvalidImportFile)
+    }
+
+    var records []ImportRecord
+    for {
+        fields, err := reader.Read()
+        if err == io.EOF {
+            break
+        }
+        record := ImportRecord{Row: len(records) + 1}
+        if err != nil {
+            var parseErr *csv.ParseError
+            if !errors.As(err, &parseErr) || !errors.Is(parseErr.Err, csv.ErrFieldCount) {
+                return nil, fmt.Errorf("%w: %w", ErrInvalidImportFile, err)
+            }
+            record.Err = fmt.Errorf("expected %d columns, got %d", len(header), len(fields))
+            records = append(records, record)
+            continue
+        }
+
+        for i, column := range header {
+            if err := setImportColumn(&record.Data, column, strings.TrimSpace(fields[i])); err != nil {
+                record.Err = err
+                break
+            }
+        }
+        records = append(records, record)
+    }
+    return records, nil
+}
+
+func setImportColumn(row *ImportRow, column, value string) error {
+    number := func(dest **int) error {
+        if value == "" {
+            return nil
+        }
+        n, err := strconv.Atoi(value)
+        if err != nil {
+            return fmt.Errorf("%s must be an integer", column)
+        }
+        *dest = &n
+        return nil
+    }
+
+    switch column {
+    case "title":
+        row.Title = value
+    case "authors":
+        row.Authors = splitImportList(value)
+    case "publisher":
+        row.Publisher = value
+    case "isbn":
+        row.ISBN = value
+    case "summary":
+        row.Summary = value
+    case "category":
+        row.Category = value
+    case "publication_year":
+        return number(&row.PublicationYear)
+    case "edition":
+        row.Edition = value
+    case "language":
+        row.Language = value
+    case "page_count":
+        return number(&row.PageCount)
+    case "subjects":
+        row.Subjects = splitImportList(value)
+    case "cover_image_url":
+        row.CoverImageURL = value
+    case "max_stock":
+        return number(&row.MaxStock)
+    case "stock":
+        return number(&row.Stock)
+    }
+    return nil
+}
+
+func splitImportList(value string) []string {
+    var items []string
+    for _, item := range strings.Split(value, ";") {
+        if item = strings.TrimSpace(item); item != "" {
+            items = append(items, item)
+        }
+    }
+    return items
+}
+
+// parseImportJSON menerima JSON lines atau satu array objek, dibedakan dari karakter pertama
+func parseImportJSON(r io.Reader) ([]ImportRecord, error) {
+    buffered := bufio.NewReader(r)
+    for {
+        b, err := buffered.Peek(1)
+        if err == io.EOF {
+            return nil, nil
+        }
+        if err != nil {
+            return nil, fmt.Errorf("%w: %w", ErrInvalidImportFile, err)
+        }
+        if b[0] != ' ' && b[0] != '\t' && b[0] != '\r' && b[0] != '\n' {
+            break
+        }
+        buffered.ReadByte()
+    }
+
+    var raws []json.RawMessage
+    if b, _ := buffered.Peek(1); b[0] == '[' {
+        if err := json.NewDecoder(buffered).Decode(&raws); err != nil {
+            return nil, fmt.Errorf("%w: %w", ErrInvalidImportFile, err)
+        }
+    } else {
+        scanner := bufio.NewScanner(buffered)
+        scanner.Buffer(make([]byte, 64*1024), 1<<20)
+        for scanner.Scan() {
+            line := bytes.TrimSpace(scanner.Bytes())
+            if len(line) == 0 {
+                continue
+            }
+            raws = append(raws, append(json.RawMessage(nil), line...))
+        }
+        if err := scanner.Err(); err != nil {
+            return nil, fmt.Errorf("%w: %w", ErrInvalidImportFile, err)
+        }
+    }
+
+    records := make([]ImportRecord, len(raws))
+    for i, raw := range raws {
+        records[i].Row = i + 1
+        decoder := json.NewDecoder(bytes.NewReader(raw))
+        decoder.DisallowUnknownFields()
+        if err := decoder.Decode(&records[i].Data); err != nil {
+            records[i].Err = fmt.Errorf("invalid JSON: %v", err)
+        }
+    }
+    return records, nil
+}
+
+// CatalogImportService creates or updates books in bulk, matching authors and publishers by name
+type CatalogImportService struct {
+    Repo *repository.CatalogImportRepository
+}
+
+func NewCatalogImportService(repo *repository.CatalogImportRepository) *CatalogImportService {
+    return &CatalogImportService{Repo: repo}
+}
+
+// importCounts adalah author/publisher yang dibuat oleh satu baris
+type importCounts struct {
+    authors    int
+    publishers int
+}
+
+// Import menyimpan records dan mengembalikan laporan per baris. Buku dengan ISBN yang sudah
+// ada diperbarui; selain itu dibuat baru. Setiap baris memakai savepoint sehingga baris yang
+// gagal tidak merusak transaksi baris lain.
+func (s *CatalogImportService) Import(records []ImportRecord, opts ImportOptions) (*ImportReport, error) {
+    if opts.Mode == "" {
+        opts.Mode = ImportModeAtomic
+    }
+    if opts.Mode != ImportModeAtomic && opts.Mode != ImportModeBatch {
+        return nil, fmt.Errorf("%w: mode must be atomic or batch", ErrInvalidImportOptions)
+    }
+    if opts.BatchSize == 0 {
+        opts.BatchSize = DefaultImportBatchSize
+    }
+    if opts.BatchSize < 1 || opts.BatchSize > MaxImportBatchSize {
+        return nil, fmt.Errorf("%w: batch_size must be between 1 and %d", ErrInvalidImportOptions, MaxImportBatchSize)
+    }
+
+    report := &ImportReport{DryRun: opts.DryRun, Mode: opts.Mode, Total: len(records), Rows: make([]ImportRowResult, 0, len(records))}
+    size := len(records)
+    if opts.Mode == ImportModeBatch {
+        size = opts.BatchSize
+    }
+
+    seenISBN := map[string]int{}
+    for start := 0; start < len(records); start += size {
+        end := start + size
+        if end > len(records) {
+            end = len(records)
+        }
+
+        var results []ImportRowResult
+        var counts importCounts
+        err := s.Repo.Transaction(func(tx *repository.CatalogImportRepository) error {
+            results = results[:0]
+            counts = importCounts{}
+            failed := false
+            for _, record := range records[start:end] {
+                result, rowCounts := s.importRow(tx, record, seenISBN)
+                results = append(results, result)
+                counts.authors += rowCounts.authors
+                counts.publishers += rowCounts.publishers
+                failed = failed || result.Status == ImportStatusFailed
+            }
+            if opts.DryRun || (failed && opts.Mode == ImportModeAtomic) {
+                return errImportRollback
+            }
+            return nil
+        })
+        committed := err == nil
+        if err != nil && !errors.Is(err, errImportRollback) {
+            if start > 0 {
+                return nil, fmt.Errorf("batch starting at row %d (earlier batches were committed): %w", records[start].Row, err)
+            }
+            return nil, err
+        }
+
+        for _, result := range results {
+            if !committed {
+                result.BookID = nil
+            }
+            switch result.Status {
+            case ImportStatusCreated:
+                report.Created++
+            case ImportStatusUpdated:
+                report.Updated++
+            case ImportStatusFailed:
+                report.Failed++
+            }
+            report.Rows = append(report.Rows, result)
+        }
+        report.AuthorsCreated += counts.authors
+        report.PublishersCreated += counts.publishers
+        report.Committed = report.Committed || committed
+    }
+    return report, nil
+}
+
+func (s *CatalogImportService) importRow(tx *repository.CatalogImportRepository, record ImportRecord, seenISBN map[string]int) (ImportRowResult, importCounts) {
+    result := ImportRowResult{Row: record.Row, Title: strings.TrimSpace(record.Data.Title), Status: ImportStatusFailed}
+    var counts importCounts
+    fail := func(err error) (ImportRowResult, importCounts) {
+        result.Error = err.Error()
+        return result, importCounts{}
+    }
+
+    if record.Err != nil {
+        return fail(record.Err)
+    }
+    book, names, err := buildImportBook(record.Data)
+    if err != nil {
+        return fail(err)
+    }
+    if book.ISBN != nil {
+        result.ISBN = *book.ISBN
+        if row, ok := seenISBN[*book.ISBN]; ok {
+            return fail(fmt.Errorf("ISBN %s already appears in row %d", *book.ISBN, row))
+        }
+        seenISBN[*book.ISBN] = record.Row
+    }
+
+    err = tx.Transaction(func(rowTx *repository.CatalogImportRepository) error {
+        for i, name := range names {
+            author, created, err := rowTx.FindOrCreateAuthor(name)
+            if err != nil {
+                return err
+            }
+            if created {
+                counts.authors++
+                if err := recordEvent(rowTx, events.AuthorChanged{Type: events.TypeAuthorCreated, AuthorID: author.ID, Name: author.Name}); err != nil {
+                    return err
+                }
+            }
+            book.Contributors[i].AuthorID = author.ID
+        }
+        publisher, created, err := rowTx.FindOrCreatePublisher(record.Data.Publisher)
+        if err != nil {
+            return err
+        }
+        if created {
+            counts.publishers++
+            if err := recordEvent(rowTx, events.PublisherChanged{Type: events.TypePublisherCreated, PublisherID: publisher.ID, Name: publisher.Name}); err != nil {
+                return err
+            }
+        }
+        book.PublisherID = publisher.ID
+        if err := normalizeContributors(book); err != nil {
+            return err
+        }
+
+        var existing *models.Book
+        if book.ISBN != nil {
+            existing, err = rowTx.GetBookByISBN(*book.ISBN)
+            if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+                return err
+            }
+        }
+
+        if existing == nil {
+            // Semua eksemplar baru tersedia; buildImportBook sudah memastikan stock == max_stock
+            copies := 0
+            switch {
+            case record.Data.MaxStock != nil:
+                copies = *record.Data.MaxStock
+            case record.Data.Stock != nil:
+                copies = *record.Data.Stock
+            }
+            if err := rowTx.CreateBook(book, copies); err != nil {
+                return err
+            }
+            result.Status = ImportStatusCreated
+            result.BookID = &book.ID
+            return recordEvent(rowTx, bookChanged(events.TypeBookCreated, book))
+        }
+
+        // Stok buku yang sudah ada dihitung dari status eksemplar, jadi import hanya boleh menambah eksemplar
+        if record.Data.Stock != nil {
+            return errors.New("stock cannot be set for an existing book; it is computed from its copies")
+        }
+        addCopies := 0
+        if record.Data.MaxStock != nil {
+            if *record.Data.MaxStock < existing.MaxStock {
+                return fmt.Errorf("max_stock %d is below the %d copies the book already has; withdraw copies instead", *record.Data.MaxStock, existing.MaxStock)
+            }
+            addCopies = *record.Data.MaxStock - existing.MaxStock
+        }
+
+        existing.Title = book.Title
+        existing.AuthorID = book.AuthorID
+        existing.PublisherID = book.PublisherID
+        existing.Summary = book.Summary
+        existing.Category = book.Category
+        existing.PublicationYear = book.PublicationYear
+        existing.Edition = book.Edition
+        existing.Language = book.Language
+        existing.PageCount = book.PageCount
+        existing.Subjects = book.Subjects
+        existing.CoverImageURL = book.CoverImageURL
+        existing.Contributors = book.Contributors
+        if err := rowTx.UpdateBook(existing, addCopies); err != nil {
+            return err
+        }
+        result.Status = ImportStatusUpdated
+        result.BookID = &existing.ID
+        return recordEvent(rowTx, bookChanged(events.TypeBookUpdated, existing))
+    })
+    if err != nil {
+        result.Status = ImportStatusFailed
+        result.BookID = nil
+        return fail(err)
+    }
+    return result, counts
+}
+
+// contributorRole memisahkan peran opsional di akhir nama, mis. "Jane Doe (translator)"
+var contributorRole = regexp.MustCompile(`^(.*?)\s*\(\s*([A-Za-z]+)\s*\)$`)
+
+// buildImportBook memvalidasi satu baris tanpa menyentuh database. names berisi nama author
+// untuk setiap kontributor dengan urutan yang sama; AuthorID-nya diisi setelah dicocokkan.
+func buildImportBook(row ImportRow) (*models.Book, []string, error) {
+    title := strings.TrimSpace(row.Title)
+    if title == "" {
+        return nil, nil, fmt.Errorf("%w: title is required", ErrInvalidBook)
+    }
+    if strings.TrimSpace(row.Publisher) == "" {
+        return nil, nil, fmt.Errorf("%w: publisher is required", ErrInvalidBook)
+    }
+    if len(row.Authors) == 0 {
+        return nil, nil, fmt.Errorf("%w: at least one author is required", ErrInvalidBook)
+    }
+    if row.MaxStock != nil && (*row.MaxStock < 0 || *row.MaxStock > maxImportCopies) {
+        return nil, nil, fmt.Errorf("%w: max_stock must be between 0 and %d", ErrInvalidBook, maxImportCopies)
+    }
+    if row.Stock != nil && *row.Stock < 0 {
+        return nil, nil, fmt.Errorf("%w: stock cannot be negative", ErrInvalidBook)
+    }
+    // Import tidak tahu eksemplar mana yang tidak tersedia atau kenapa, jadi tidak menebaknya
+    if row.Stock != nil && row.MaxStock != nil && *row.Stock != *row.MaxStock {
+        return nil, nil, fmt.Errorf("%w: stock (%d) must equal max_stock (%d); set the status of unavailable copies after importing", ErrInvalidBook, *row.Stock, *row.MaxStock)
+    }
+    if row.Stock != nil && row.MaxStock == nil && *row.Stock > maxImportCopies {
+        return nil, nil, fmt.Errorf("%w: stock must be at most %d", ErrInvalidBook, maxImportCopies)
+    }
+
+    book := &models.Book{
+        Title:           title,
+        Summary:         strings.TrimSpace(row.Summary),
+        Category:        strings.TrimSpace(row.Category),
+        PublicationYear: row.PublicationYear,
+        Edition:         row.Edition,
+        Language:        row.Language,
+        PageCount:       row.PageCount,
+        Subjects:        models.StringList(row.Subjects),
+        CoverImageURL:   row.CoverImageURL,
+    }
+    if isbn := strings.TrimSpace(row.ISBN); isbn != "" {
+        book.ISBN = &isbn
+    }
+    if err := normalizeBookFields(book); err != nil {
+        return nil, nil, err
+    }
+
+    var names []string
+    for _, entry := range row.Authors {
+        name, role := strings.TrimSpace(entry), models.ContributorRoleAuthor
+        if match := contributorRole.FindStringSubmatch(name); match != nil && models.IsValidContributorRole(strings.ToLower(match[2])) {
+            name, role = match[1], strings.ToLower(match[2])
+        }
+        name = strings.Join(strings.Fields(name), " ")
+        if name == "" {
+            return nil, nil, fmt.Errorf("%w: author names cannot be empty", ErrInvalidBook)
+        }
+        names = append(names, name)
+        book.Contributors = append(book.Contributors, models.BookContributor{Role: role})
+    }
+    return book, names, nil
+}
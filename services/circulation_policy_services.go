@@ -0,0 +1,143 @@
+// services/circulation_policy_services.go
+package services
+
+import (
+    "errors"
+    "math"
+    "time"
+    "auth-user-api/models"
+    "auth-user-api/repository"
+)
+
+var ErrInvalidPolicy = errors.New("loan_period_days and renewal_period_days must be positive, other limits cannot be negative")
+
+type CirculationPolicyService interface {
+    CreatePolicy(policy *models.CirculationPolicy) error
+    GetPolicyByID(id uint) (*models.CirculationPolicy, error)
+    GetAllPolicies() ([]*models.CirculationPolicy, error)
+    UpdatePolicy(policy *models.CirculationPolicy) error
+    DeletePolicy(id uint) error
+    ResolvePolicy(role int, category string) (*models.CirculationPolicy, error)
+    EnsureDefaultPolicy() error
+}
+
+type circulationPolicyService struct {
+    repo repository.CirculationPolicyRepository
+}
+
+func NewCirculationPolicyService(repo repository.CirculationPolicyRepository) CirculationPolicyService {
+    return &circulationPolicyService{repo}
+}
+
+// DefaultCirculationPolicy mengembalikan aturan bawaan (sebelumnya hardcode di LoanService)
+func DefaultCirculationPolicy() *models.CirculationPolicy {
+    return &models.CirculationPolicy{
+        Role:               0,
+        Category:           "",
+        CanBorrow:          true,
+        LoanPeriodDays:     3,
+        MaxConcurrentLoans: 0,
+        MaxRenewals:        2,
+        RenewalPeriodDays:  3,
+        DailyFine:          5000,
+        FineCap:            0,
+        GraceDays:          0,
+    }
+}
+
+func (s *circulationPolicyService) CreatePolicy(policy *models.CirculationPolicy) error {
+    if err := validatePolicy(policy); err != nil {
+        return err
+    }
+    return s.repo.CreatePolicy(policy)
+}
+
+func (s *circulationPolicyService) GetPolicyByID(id uint) (*models.CirculationPolicy, error) {
+    return s.repo.GetPolicyByID(id)
+}
+
+func (s *circulationPolicyService) GetAllPolicies() ([]*models.CirculationPolicy, error) {
+    return s.repo.GetAllPolicies()
+}
+
+func (s *circulationPolicyService) UpdatePolicy(policy *models.CirculationPolicy) error {
+    if err := validatePolicy(policy); err != nil {
+        return err
+    }
+    return s.repo.UpdatePolicy(policy)
+}
+
+func (s *circulationPolicyService) DeletePolicy(id uint) error {
+    return s.repo.DeletePolicy(id)
+}
+
+// ResolvePolicy memilih aturan paling spesifik untuk role dan kategori:
+// role+kategori, lalu role saja, lalu kategori saja, lalu aturan umum.
+// Jika tabel kosong, DefaultCirculationPolicy dipakai.
+func (s *circulationPolicyService) ResolvePolicy(role int, category string) (*models.CirculationPolicy, error) {
+    candidates, err := s.repo.FindMatchingPolicies(role, category)
+    if err != nil {
+        return nil, err
+    }
+
+    var best *models.CirculationPolicy
+    bestScore := -1
+    for _, policy := range candidates {
+        score := 0
+        if policy.Role == role && role != 0 {
+            score += 2
+        }
+        if policy.Category == category && category != "" {
+            score++
+        }
+        if score > bestScore {
+            best = policy
+            bestScore = score
+        }
+    }
+
+    if best == nil {
+        return DefaultCirculationPolicy(), nil
+    }
+    return best, nil
+}
+
+// EnsureDefaultPolicy menyimpan aturan bawaan jika belum ada aturan sama sekali
+func (s *circulationPolicyService) EnsureDefaultPolicy() error {
+    count, err := s.repo.CountPolicies()
+    if err != nil || count > 0 {
+        return err
+    }
+    return s.repo.CreatePolicy(DefaultCirculationPolicy())
+}
+
+func validatePolicy(policy *models.CirculationPolicy) error {
+    if policy.LoanPeriodDays <= 0 || policy.RenewalPeriodDays <= 0 {
+        return ErrInvalidPolicy
+    }
+    if policy.MaxConcurrentLoans < 0 || policy.MaxRenewals < 0 || policy.DailyFine < 0 ||
+        policy.FineCap < 0 || policy.GraceDays < 0 {
+        return ErrInvalidPolicy
+    }
+    return nil
+}
+
+// CalculateLateFee menghitung denda keterlambatan per hari setelah masa tenggang,
+// dibatasi FineCap jika diisi.
+func CalculateLateFee(policy *models.CirculationPolicy, dueDate, returnedAt time.Time) int {
+    if !returnedAt.After(dueDate) {
+        return 0
+    }
+
+    daysLate := int(math.Floor(returnedAt.Sub(dueDate).Hours() / 24))
+    chargeableDays := daysLate - policy.GraceDays
+    if chargeableDays <= 0 {
+        return 0
+    }
+
+    fee := chargeableDays * policy.DailyFine
+    if policy.FineCap > 0 && fee > policy.FineCap {
+        fee = policy.FineCap
+    }
+    return fee
+}
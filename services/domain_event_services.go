@@ -0,0 +1,167 @@
+// services/domain_event_services.go
+package services
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "log"
+    "strings"
+    "time"
+    "auth-user-api/events"
+    "auth-user-api/models"
+    "auth-user-api/repository"
+)
+
+// eventBatchSize adalah jumlah event yang diambil dispatcher sekali jalan
+const eventBatchSize = 20
+
+// eventAppender dipenuhi setiap repository yang bisa menulis ke outbox event
+type eventAppender interface {
+    AppendEvent(event *models.DomainEvent) error
+}
+
+// recordEvent menyimpan event ke outbox lewat tx. Selalu dipanggil di dalam transaksi
+// yang sama dengan perubahan yang dilaporkan event itu.
+func recordEvent(tx eventAppender, e events.Event) error {
+    payload, err := json.Marshal(e)
+    if err != nil {
+        return err
+    }
+    return tx.AppendEvent(&models.DomainEvent{
+        Type:          e.EventType(),
+        AggregateType: e.AggregateType(),
+        AggregateID:   e.AggregateID(),
+        Payload:       string(payload),
+        OccurredAt:    time.Now(),
+    })
+}
+
+// EventDispatchResult summarizes one dispatcher run
+type EventDispatchResult struct {
+    Dispatched int `json:"dispatched"`
+    Retried    int `json:"retried"` // Sebagian subscriber gagal, dijadwalkan ulang
+    Failed     int `json:"failed"`  // Gagal dan tidak dicoba lagi
+}
+
+// EventDispatcher membaca outbox event dan meneruskannya ke subscriber Bus (in-process dan
+// webhook). Pengiriman at-least-once: subscriber yang gagal dicoba lagi dengan backoff,
+// sedangkan yang sudah berhasil tidak menerima event yang sama lagi.
+type EventDispatcher struct {
+    Repo        *repository.EventRepository
+    Bus         *events.Bus
+    MaxAttempts int
+    Timeout     time.Duration // Batas waktu satu subscriber untuk satu event
+}
+
+func NewEventDispatcher(repo *repository.EventRepository, bus *events.Bus, maxAttempts int, timeout time.Duration) *EventDispatcher {
+    return &EventDispatcher{Repo: repo, Bus: bus, MaxAttempts: maxAttempts, Timeout: timeout}
+}
+
+// DispatchPending mengirim semua event yang sudah waktunya, per batch sampai habis
+func (d *EventDispatcher) DispatchPending(ctx context.Context) (*EventDispatchResult, error) {
+    result := &EventDispatchResult{}
+
+    for {
+        // Lease dihitung dari jumlah subscriber saat ini agar batch penuh sempat selesai
+        lease := d.Timeout*time.Duration(eventBatchSize*(d.Bus.Len()+1)) + time.Minute
+        claimed, err := d.Repo.ClaimDueEvents(time.Now(), eventBatchSize, lease)
+        if err != nil {
+            return result, err
+        }
+        for _, e := range claimed {
+            if err := d.dispatch(ctx, e); err != nil {
+                return result, err
+            }
+            switch e.Status {
+            case models.DomainEventStatusDispatched:
+                result.Dispatched++
+            case models.DomainEventStatusFailed:
+                result.Failed++
+            default:
+                result.Retried++
+            }
+        }
+        if len(claimed) < eventBatchSize || ctx.Err() != nil {
+            return result, ctx.Err()
+        }
+    }
+}
+
+// dispatch mengirim satu event ke subscriber yang belum menerimanya. Error hanya dikembalikan
+// jika hasilnya gagal disimpan.
+func (d *EventDispatcher) dispatch(ctx context.Context, e *models.DomainEvent) error {
+    envelope := &events.Envelope{
+        ID:            e.ID,
+        Type:          e.Type,
+        AggregateType: e.AggregateType,
+        AggregateID:   e.AggregateID,
+        OccurredAt:    e.OccurredAt,
+        Payload:       json.RawMessage(e.Payload),
+    }
+    delivered := map[string]bool{}
+    for _, name := range e.DeliveredTo {
+        delivered[name] = true
+    }
+
+    e.Attempts++
+    var failures []string
+    for _, s := range d.Bus.Subscribers(e.Type) {
+        if delivered[s.Name] {
+            continue
+        }
+        if err := d.handle(ctx, s, envelope); err != nil {
+            log.Printf("Event %d (%s) to %s failed: %v", e.ID, e.Type, s.Name, err)
+            failures = append(failures, fmt.Sprintf("%s: %v", s.Name, err))
+            continue
+        }
+        e.DeliveredTo = append(e.DeliveredTo, s.Name)
+    }
+
+    now := time.Now()
+    if len(failures) == 0 {
+        e.Status = models.DomainEventStatusDispatched
+        e.DispatchedAt = &now
+        return d.Repo.MarkEventDispatched(e, now)
+    }
+
+    lastError := strings.Join(failures, "; ")
+    e.LastError = &lastError
+    e.NextAttemptAt = now.Add(retryBackoff(e.Attempts))
+    final := e.Attempts >= d.MaxAttempts
+    if final {
+        e.Status = models.DomainEventStatusFailed
+    }
+    return d.Repo.MarkEventFailed(e, final)
+}
+
+// handle memanggil satu subscriber dengan batas waktu; panic di subscriber in-process
+// dianggap kegagalan biasa agar tidak menghentikan dispatcher
+func (d *EventDispatcher) handle(ctx context.Context, s *events.Subscriber, e *events.Envelope) (err error) {
+    defer func() {
+        if r := recover(); r != nil {
+            err = fmt.Errorf("panic: %v", r)
+        }
+    }()
+
+    ctx, cancel := context.WithTimeout(ctx, d.Timeout)
+    defer cancel()
+    return s.Handler(ctx, e)
+}
+
+// RunWorker runs DispatchPending every interval until the process exits
+func (d *EventDispatcher) RunWorker(interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for range ticker.C {
+        result, err := d.DispatchPending(context.Background())
+        if err != nil {
+            log.Printf("Failed to dispatch domain events: %v", err)
+            continue
+        }
+        if result.Retried > 0 || result.Failed > 0 {
+            log.Printf("Domain events: %d dispatched, %d retried, %d failed", result.Dispatched, result.Retried, result.Failed)
+        }
+    }
+}
@@ -0,0 +1,140 @@
+// services/eligibility_services.go
+
+package services
+
+import (
+    "auth-user-api/models"
+    "auth-user-api/repository"
+    "fmt"
+    "strings"
+
+    "github.com/google/uuid"
+)
+
+// Kode alasan penolakan pengajuan pinjaman
+const (
+    ReasonBorrowingNotAllowed = "BORROWING_NOT_ALLOWED"
+    ReasonDuplicateRequest    = "DUPLICATE_REQUEST"
+    ReasonAlreadyBorrowed     = "ALREADY_BORROWED"
+    ReasonLoanLimitReached    = "LOAN_LIMIT_REACHED"
+    ReasonOverdueLoans        = "OVERDUE_LOANS"
+    ReasonOutstandingFines    = "OUTSTANDING_FINES"
+)
+
+// EligibilityReason explains one rule that blocks a loan request
+type EligibilityReason struct {
+    Code    string `json:"code"`
+    Message string `json:"message"`
+}
+
+// EligibilityError is returned when a member may not file a loan request.
+// Reasons lists every rule that failed, not just the first one.
+type EligibilityError struct {
+    Reasons []EligibilityReason
+}
+
+func (e *EligibilityError) Error() string {
+    codes := make([]string, len(e.Reasons))
+    for i, reason := range e.Reasons {
+        codes[i] = reason.Code
+    }
+    return "loan request not eligible: " + strings.Join(codes, ", ")
+}
+
+type EligibilityService struct {
+    Repo         *repository.LoanRepository
+    Policies     CirculationPolicyService
+    Fines        *FineService
+    MaxOpenItems int // Batas pinjaman aktif + request PENDING jika aturan sirkulasi tidak mengatur
+}
+
+func NewEligibilityService(repo *repository.LoanRepository, policies CirculationPolicyService, fines *FineService, maxOpenItems int) *EligibilityService {
+    return &EligibilityService{Repo: repo, Policies: policies, Fines: fines, MaxOpenItems: maxOpenItems}
+}
+
+// Evaluate menjalankan seluruh aturan kelayakan dan mengembalikan semua alasan penolakan.
+// Slice kosong berarti anggota boleh mengajukan pinjaman.
+func (s *EligibilityService) Evaluate(userID uuid.UUID, book *models.Book) ([]EligibilityReason, error) {
+    return s.evaluate(s.Repo, userID, book)
+}
+
+func (s *EligibilityService) evaluate(repo *repository.LoanRepository, userID uuid.UUID, book *models.Book) ([]EligibilityReason, error) {
+    reasons := []EligibilityReason{}
+
+    role, err := repo.GetUserRole(userID)
+    if err != nil {
+        return nil, err
+    }
+    policy, err := s.Policies.ResolvePolicy(role, book.Category)
+    if err != nil {
+        return nil, err
+    }
+    if !policy.CanBorrow {
+        reasons = append(reasons, EligibilityReason{ReasonBorrowingNotAllowed, ErrBorrowingNotAllowed.Error()})
+    }
+
+    duplicate, err := repo.HasPendingRequest(userID, book.ID)
+    if err != nil {
+        return nil, err
+    }
+    if duplicate {
+        reasons = append(reasons, EligibilityReason{ReasonDuplicateRequest, "a pending request for this book already exists"})
+    }
+
+    borrowed, err := repo.HasActiveLoanForBook(userID, book.ID)
+    if err != nil {
+        return nil, err
+    }
+    if borrowed {
+        reasons = append(reasons, EligibilityReason{ReasonAlreadyBorrowed, "a copy of this book is already on loan to the member"})
+    }
+
+    activeLoans, err := repo.CountActiveLoans(userID)
+    if err != nil {
+        return nil, err
+    }
+    pending, err := repo.CountPendingRequests(userID)
+    if err != nil {
+        return nil, err
+    }
+    maxOpen := policy.MaxConcurrentLoans
+    if maxOpen == 0 {
+        maxOpen = s.MaxOpenItems
+    }
+    if maxOpen > 0 && activeLoans+pending >= int64(maxOpen) {
+        reasons = append(reasons, EligibilityReason{ReasonLoanLimitReached,
+            fmt.Sprintf("active loans plus pending requests (%d) reached the limit of %d", activeLoans+pending, maxOpen)})
+    }
+
+    overdue, err := repo.CountOverdueLoans(userID)
+    if err != nil {
+        return nil, err
+    }
+    if overdue > 0 {
+        reasons = append(reasons, EligibilityReason{ReasonOverdueLoans, fmt.Sprintf("member has %d overdue loan(s)", overdue)})
+    }
+
+    balance, err := repo.GetOutstandingBalance(userID)
+    if err != nil {
+        return nil, err
+    }
+    if balance > s.Fines.BlockThreshold {
+        reasons = append(reasons, EligibilityReason{ReasonOutstandingFines,
+            fmt.Sprintf("outstanding fines of %d exceed the allowed balance of %d", balance, s.Fines.BlockThreshold)})
+    }
+
+    return reasons, nil
+}
+
+// Check returns an EligibilityError listing every failed rule, or nil if eligible. Aturan
+// dibaca lewat tx agar hasilnya tetap berlaku sampai request disimpan di transaksi yang sama.
+func (s *EligibilityService) Check(tx *repository.LoanRepository, userID uuid.UUID, book *models.Book) error {
+    reasons, err := s.evaluate(tx, userID, book)
+    if err != nil {
+        return err
+    }
+    if len(reasons) > 0 {
+        return &EligibilityError{Reasons: reasons}
+    }
+    return nil
+}
@@ -0,0 +1,174 @@
+// services/fine_services.go
+
+package services
+
+import (
+    "auth-user-api/models"
+    "auth-user-api/repository"
+    "errors"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+)
+
+var (
+    ErrInvalidAmount     = errors.New("amount must be positive and not exceed the outstanding balance")
+    ErrFineSettled       = errors.New("fine is already settled")
+    ErrWaiveReasonNeeded = errors.New("a reason is required to waive a fine")
+)
+
+type FineService struct {
+    Repo           *repository.LoanRepository
+    BlockThreshold int // Anggota dengan saldo denda di atas nilai ini tidak bisa mengajukan pinjaman
+}
+
+func NewFineService(repo *repository.LoanRepository, blockThreshold int) *FineService {
+    return &FineService{Repo: repo, BlockThreshold: blockThreshold}
+}
+
+// RecordLateFee menetapkan total denda keterlambatan pinjaman di dalam transaksi. Job overdue
+// memanggilnya berulang kali selama buku belum kembali dan pengembalian memanggilnya sekali
+// lagi, semuanya memperbarui fine yang sama. Denda tidak pernah diturunkan; bagian yang sudah
+// dibayar atau dihapus tetap tercatat dan fine dibuka lagi jika jumlahnya bertambah.
+func (s *FineService) RecordLateFee(tx *repository.LoanRepository, loan *models.LoanRecord, amount int) (*models.Fine, error) {
+    fine, _, err := s.accrueLateFee(tx, loan, amount)
+    return fine, err
+}
+
+func (s *FineService) accrueLateFee(tx *repository.LoanRepository, loan *models.LoanRecord, amount int) (*models.Fine, bool, error) {
+    fine, err := tx.LockLateFee(loan.ID)
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        if amount <= 0 {
+            return nil, false, nil
+        }
+        fine = &models.Fine{
+            UserID:       loan.UserID,
+            LoanRecordID: &loan.ID,
+            Amount:       amount,
+            Status:       models.FineStatusOutstanding,
+            Reason:       models.FineReasonLateReturn,
+        }
+        if err := tx.CreateFine(fine); err != nil {
+            return nil, false, err
+        }
+        return fine, true, nil
+    }
+    if err != nil {
+        return nil, false, err
+    }
+
+    if amount <= fine.Amount {
+        return fine, false, nil
+    }
+    fine.Amount = amount
+    fine.Status = models.FineStatusOutstanding
+    if err := tx.UpdateFine(fine); err != nil {
+        return nil, false, err
+    }
+    return fine, true, nil
+}
+
+// GetAccount retrieves all fines of a member together with the outstanding balance
+func (s *FineService) GetAccount(userID uuid.UUID) ([]*models.Fine, int, error) {
+    fines, err := s.Repo.GetFinesByUser(userID)
+    if err != nil {
+        return nil, 0, err
+    }
+    balance, err := s.Repo.GetOutstandingBalance(userID)
+    if err != nil {
+        return nil, 0, err
+    }
+    return fines, balance, nil
+}
+
+// GetFineByID retrieves a fine by ID
+func (s *FineService) GetFineByID(id uint) (*models.Fine, error) {
+    return s.Repo.GetFineByID(id)
+}
+
+// RecordPayment mencatat pembayaran penuh atau sebagian atas denda
+func (s *FineService) RecordPayment(fineID uint, amount int, method, receivedBy string) (*models.Fine, *models.Payment, error) {
+    var fine *models.Fine
+    var payment *models.Payment
+
+    err := s.Repo.Transaction(func(tx *repository.LoanRepository) error {
+        var err error
+        fine, err = tx.LockFine(fineID)
+        if err != nil {
+            return err
+        }
+        if fine.Status != models.FineStatusOutstanding {
+            return &ConflictError{Err: ErrFineSettled}
+        }
+        if amount <= 0 || amount > fine.Balance() {
+            return ErrInvalidAmount
+        }
+
+        payment = &models.Payment{
+            FineID:     fine.ID,
+            UserID:     fine.UserID,
+            Amount:     amount,
+            Method:     method,
+            ReceivedBy: receivedBy,
+        }
+        if err := tx.CreatePayment(payment); err != nil {
+            return err
+        }
+
+        fine.PaidAmount += amount
+        settleFine(fine)
+        return tx.UpdateFine(fine)
+    })
+    if err != nil {
+        return nil, nil, err
+    }
+    return fine, payment, nil
+}
+
+// WaiveFine menghapus sebagian atau seluruh sisa denda oleh admin dengan alasan.
+// Jika amount 0, seluruh sisa denda dihapus.
+func (s *FineService) WaiveFine(fineID uint, amount int, reason, waivedBy string) (*models.Fine, error) {
+    if reason == "" {
+        return nil, ErrWaiveReasonNeeded
+    }
+
+    var fine *models.Fine
+    err := s.Repo.Transaction(func(tx *repository.LoanRepository) error {
+        var err error
+        fine, err = tx.LockFine(fineID)
+        if err != nil {
+            return err
+        }
+        if fine.Status != models.FineStatusOutstanding {
+            return &ConflictError{Err: ErrFineSettled}
+        }
+        if amount == 0 {
+            amount = fine.Balance()
+        }
+        if amount < 0 || amount > fine.Balance() {
+            return ErrInvalidAmount
+        }
+
+        fine.WaivedAmount += amount
+        fine.WaiveReason = &reason
+        fine.WaivedBy = &waivedBy
+        settleFine(fine)
+        return tx.UpdateFine(fine)
+    })
+    if err != nil {
+        return nil, err
+    }
+    return fine, nil
+}
+
+// settleFine marks a fine PAID or WAIVED once nothing is owed
+func settleFine(fine *models.Fine) {
+    if fine.Balance() > 0 {
+        return
+    }
+    if fine.PaidAmount > 0 {
+        fine.Status = models.FineStatusPaid
+    } else {
+        fine.Status = models.FineStatusWaived
+    }
+}
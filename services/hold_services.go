@@ -0,0 +1,307 @@
+// services/hold_services.go
+
+package services
+
+import (
+    "auth-user-api/models"
+    "auth-user-api/repository"
+    "errors"
+    "log"
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+)
+
+var (
+    ErrAlreadyInQueue    = errors.New("user already has an active hold on this book")
+    ErrBookAvailable     = errors.New("book is available, request a loan instead")
+    ErrHoldNotActive     = errors.New("hold is no longer active")
+    ErrHoldNotWaiting    = errors.New("only waiting holds can be reordered")
+    ErrInvalidQueueOrder = errors.New("position is outside the queue")
+)
+
+type HoldService struct {
+    Repo         *repository.LoanRepository
+    PickupWindow time.Duration // Lama waktu eksemplar disisihkan untuk anggota sebelum hangus
+}
+
+func NewHoldService(repo *repository.LoanRepository, pickupWindow time.Duration) *HoldService {
+    return &HoldService{Repo: repo, PickupWindow: pickupWindow}
+}
+
+// JoinQueue menambahkan anggota ke akhir antrean reservasi buku.
+// Admin (override) boleh memasukkan anggota meskipun stok masih ada.
+func (s *HoldService) JoinQueue(bookID int, userID uuid.UUID, override bool) (*models.Hold, error) {
+    var hold *models.Hold
+
+    err := s.Repo.Transaction(func(tx *repository.LoanRepository) error {
+        book, err := tx.LockBook(bookID)
+        if err != nil {
+            return err
+        }
+        if book.DeletedAt.Valid {
+            return &ConflictError{Err: ErrBookDeleted}
+        }
+
+        if book.Stock > 0 && !override {
+            return ErrBookAvailable
+        }
+
+        if _, err := tx.GetActiveHold(bookID, userID); err == nil {
+            return ErrAlreadyInQueue
+        } else if !errors.Is(err, gorm.ErrRecordNotFound) {
+            return err
+        }
+
+        position, err := tx.NextHoldPosition(bookID)
+        if err != nil {
+            return err
+        }
+
+        hold = &models.Hold{
+            BookID:   bookID,
+            UserID:   userID,
+            Position: position,
+            Status:   models.HoldStatusWaiting,
+        }
+        return tx.CreateHold(hold)
+    })
+    if err != nil {
+        return nil, err
+    }
+    return hold, nil
+}
+
+// LeaveQueue membatalkan reservasi. Jika eksemplar sudah disisihkan, eksemplar itu
+// diteruskan ke anggota berikutnya.
+func (s *HoldService) LeaveQueue(holdID uint) (*models.Hold, error) {
+    var hold *models.Hold
+
+    err := s.Repo.Transaction(func(tx *repository.LoanRepository) error {
+        current, err := tx.GetHoldByID(holdID)
+        if err != nil {
+            return err
+        }
+        if _, err := tx.LockBook(current.BookID); err != nil {
+            return err
+        }
+
+        hold, err = tx.LockHold(holdID)
+        if err != nil {
+            return err
+        }
+        if hold.Status != models.HoldStatusWaiting && hold.Status != models.HoldStatusReady {
+            return &ConflictError{Err: ErrHoldNotActive}
+        }
+
+        wasReady := hold.Status == models.HoldStatusReady
+        hold.Status = models.HoldStatusCancelled
+        if err := tx.UpdateHold(hold); err != nil {
+            return err
+        }
+
+        if wasReady && hold.CopyID != nil {
+            bookCopy := &models.BookCopy{ID: *hold.CopyID, BookID: hold.BookID}
+            return s.passCopyToNextHold(tx, bookCopy, models.CopyStatusOnHold)
+        }
+        return s.compactQueue(tx, hold.BookID)
+    })
+    if err != nil {
+        return nil, err
+    }
+    return hold, nil
+}
+
+// MoveInQueue memindahkan reservasi WAITING ke posisi baru (1 = terdepan)
+func (s *HoldService) MoveInQueue(holdID uint, position int) (*models.Hold, error) {
+    var hold *models.Hold
+
+    err := s.Repo.Transaction(func(tx *repository.LoanRepository) error {
+        current, err := tx.GetHoldByID(holdID)
+        if err != nil {
+            return err
+        }
+        if _, err := tx.LockBook(current.BookID); err != nil {
+            return err
+        }
+
+        queue, err := tx.GetWaitingHolds(current.BookID)
+        if err != nil {
+            return err
+        }
+        if position < 1 || position > len(queue) {
+            return ErrInvalidQueueOrder
+        }
+
+        index := -1
+        for i, h := range queue {
+            if h.ID == holdID {
+                index = i
+                break
+            }
+        }
+        if index < 0 {
+            return ErrHoldNotWaiting
+        }
+
+        hold = queue[index]
+        queue = append(queue[:index], queue[index+1:]...)
+        queue = append(queue[:position-1], append([]*models.Hold{hold}, queue[position-1:]...)...)
+
+        return renumberQueue(tx, queue)
+    })
+    if err != nil {
+        return nil, err
+    }
+    return hold, nil
+}
+
+// GetQueue retrieves the active holds of a book, ready holds first. Hanya staff (staff = true)
+// yang melihat user_id dan username anggota; yang lain hanya melihat posisi dan reservasinya sendiri.
+func (s *HoldService) GetQueue(bookID int, viewerID uuid.UUID, staff bool) ([]map[string]interface{}, error) {
+    if staff {
+        return s.Repo.GetHoldQueue(bookID)
+    }
+    return s.Repo.GetHoldQueuePositions(bookID, viewerID)
+}
+
+// GetHoldByID retrieves a hold by ID
+func (s *HoldService) GetHoldByID(id uint) (*models.Hold, error) {
+    return s.Repo.GetHoldByID(id)
+}
+
+// ProcessHolds menghanguskan reservasi READY yang lewat batas pengambilan dan
+// membagikan eksemplar AVAILABLE ke anggota yang masih menunggu.
+func (s *HoldService) ProcessHolds() error {
+    expired, err := s.Repo.GetExpiredReadyHolds(time.Now())
+    if err != nil {
+        return err
+    }
+
+    // Setiap reservasi diproses dalam transaksi sendiri dengan urutan kunci buku lalu reservasi
+    for _, candidate := range expired {
+        err := s.Repo.Transaction(func(tx *repository.LoanRepository) error {
+            if _, err := tx.LockBook(candidate.BookID); err != nil {
+                return err
+            }
+            hold, err := tx.LockHold(candidate.ID)
+            if err != nil {
+                return err
+            }
+            if hold.Status != models.HoldStatusReady || hold.ExpiresAt == nil || hold.ExpiresAt.After(time.Now()) {
+                return nil
+            }
+
+            hold.Status = models.HoldStatusExpired
+            if err := tx.UpdateHold(hold); err != nil {
+                return err
+            }
+            if hold.CopyID == nil {
+                return nil
+            }
+            bookCopy := &models.BookCopy{ID: *hold.CopyID, BookID: hold.BookID}
+            return s.passCopyToNextHold(tx, bookCopy, models.CopyStatusOnHold)
+        })
+        if err != nil {
+            return err
+        }
+    }
+
+    bookIDs, err := s.Repo.GetBooksAwaitingCopies()
+    if err != nil {
+        return err
+    }
+    for _, bookID := range bookIDs {
+        err := s.Repo.Transaction(func(tx *repository.LoanRepository) error {
+            if _, err := tx.LockBook(bookID); err != nil {
+                return err
+            }
+            for {
+                waiting, err := tx.CountWaitingHolds(bookID)
+                if err != nil || waiting == 0 {
+                    return err
+                }
+                bookCopy, err := tx.ClaimAvailableCopy(bookID)
+                if errors.Is(err, gorm.ErrRecordNotFound) {
+                    return nil
+                }
+                if err != nil {
+                    return err
+                }
+                if err := s.passCopyToNextHold(tx, bookCopy, models.CopyStatusAvailable); err != nil {
+                    return err
+                }
+            }
+        })
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// RunExpiryWorker runs ProcessHolds every interval until the process exits
+func (s *HoldService) RunExpiryWorker(interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for range ticker.C {
+        if err := s.ProcessHolds(); err != nil {
+            log.Printf("Failed to process holds: %v", err)
+        }
+    }
+}
+
+// passCopyToNextHold menyisihkan eksemplar untuk anggota terdepan di antrean, atau
+// mengembalikannya ke rak jika antrean kosong. Buku harus sudah dikunci dengan LockBook.
+func (s *HoldService) passCopyToNextHold(tx *repository.LoanRepository, bookCopy *models.BookCopy, from string) error {
+    queue, err := tx.GetWaitingHolds(bookCopy.BookID)
+    if err != nil {
+        return err
+    }
+
+    if len(queue) == 0 {
+        return conflictOnRowChange(tx.UpdateCopyStatus(bookCopy, from, models.CopyStatusAvailable), ErrCopyNotOnLoan)
+    }
+
+    if from != models.CopyStatusOnHold {
+        if err := conflictOnRowChange(tx.UpdateCopyStatus(bookCopy, from, models.CopyStatusOnHold), ErrCopyNotOnLoan); err != nil {
+            return err
+        }
+    }
+
+    now := time.Now()
+    next := queue[0]
+    next.Status = models.HoldStatusReady
+    next.CopyID = &bookCopy.ID
+    next.ReadyAt = &now
+    next.ExpiresAt = timePtr(now.Add(s.PickupWindow))
+    if err := tx.UpdateHold(next); err != nil {
+        return err
+    }
+
+    return renumberQueue(tx, queue[1:])
+}
+
+// compactQueue renumbers the waiting holds of a book so positions stay 1..n
+func (s *HoldService) compactQueue(tx *repository.LoanRepository, bookID int) error {
+    queue, err := tx.GetWaitingHolds(bookID)
+    if err != nil {
+        return err
+    }
+    return renumberQueue(tx, queue)
+}
+
+func renumberQueue(tx *repository.LoanRepository, queue []*models.Hold) error {
+    for i, hold := range queue {
+        if hold.Position == i+1 {
+            continue
+        }
+        hold.Position = i + 1
+        if err := tx.UpdateHold(hold); err != nil {
+            return err
+        }
+    }
+    return nil
+}
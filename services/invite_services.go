@@ -0,0 +1,187 @@
+// services/invite_services.go
+package services
+
+import (
+    "crypto/hmac"
+    "crypto/sha256"
+    "crypto/subtle"
+    "errors"
+    "strings"
+    "time"
+    "auth-user-api/models"
+    "auth-user-api/repository"
+
+    "github.com/golang-jwt/jwt/v4"
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+)
+
+var (
+    ErrInvalidInvite       = errors.New("invalid invite code")
+    ErrInviteUnavailable   = errors.New("invite code has expired, been used or been revoked")
+    ErrInviteEmailMismatch = errors.New("invite code was issued for a different email")
+    ErrUnknownRole         = errors.New("unknown role")
+    ErrInvalidInviteTTL    = errors.New("invite lifetime must be positive and at most the configured maximum")
+    ErrBootstrapClosed     = errors.New("bootstrap token can only be used while no admin exists")
+)
+
+const inviteAudience = "invite"
+
+// inviteClaims are carried by a signed invite code; ID (jti) is the Invite row
+type inviteClaims struct {
+    Role int `json:"role"`
+    jwt.RegisteredClaims
+}
+
+type InviteService interface {
+    CreateInvite(role int, email string, ttl time.Duration, createdBy *uuid.UUID) (*models.Invite, string, error)
+    GetAllInvites() ([]*models.Invite, error)
+    RevokeInvite(id uuid.UUID) error
+    RegisterWithInvite(code, username, email, password1, password2 string) (*models.User, error)
+}
+
+type inviteService struct {
+    repo           repository.InviteRepository
+    roles          repository.RoleRepository
+    key            []byte
+    defaultTTL     time.Duration
+    maxTTL         time.Duration
+    bootstrapToken string
+}
+
+// NewInviteService membuat InviteService. Kode undangan ditandatangani dengan kunci turunan
+// dari secret sehingga tidak bisa dipakai sebagai access token. bootstrapToken (boleh kosong)
+// mengizinkan registrasi admin pertama selama belum ada admin.
+func NewInviteService(repo repository.InviteRepository, roles repository.RoleRepository, secret []byte, defaultTTL, maxTTL time.Duration, bootstrapToken string) InviteService {
+    mac := hmac.New(sha256.New, secret)
+    mac.Write([]byte("invite-codes"))
+    return &inviteService{repo: repo, roles: roles, key: mac.Sum(nil), defaultTTL: defaultTTL, maxTTL: maxTTL, bootstrapToken: bootstrapToken}
+}
+
+// CreateInvite menyimpan undangan baru dan mengembalikan kode bertanda tangan untuk invitee.
+// ttl 0 memakai masa berlaku default.
+func (s *inviteService) CreateInvite(role int, email string, ttl time.Duration, createdBy *uuid.UUID) (*models.Invite, string, error) {
+    if ttl == 0 {
+        ttl = s.defaultTTL
+    }
+    if ttl < 0 || ttl > s.maxTTL {
+        return nil, "", ErrInvalidInviteTTL
+    }
+    if _, err := s.roles.GetRoleByID(role); err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return nil, "", ErrUnknownRole
+        }
+        return nil, "", err
+    }
+
+    invite := &models.Invite{
+        ID:        uuid.New(),
+        Role:      role,
+        Email:     strings.ToLower(strings.TrimSpace(email)),
+        ExpiresAt: time.Now().Add(ttl),
+        CreatedBy: createdBy,
+    }
+    if err := s.repo.CreateInvite(invite); err != nil {
+        return nil, "", err
+    }
+
+    claims := &inviteClaims{
+        Role: role,
+        RegisteredClaims: jwt.RegisteredClaims{
+            ID:        invite.ID.String(),
+            Audience:  jwt.ClaimStrings{inviteAudience},
+            IssuedAt:  jwt.NewNumericDate(time.Now()),
+            ExpiresAt: jwt.NewNumericDate(invite.ExpiresAt),
+        },
+    }
+    code, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.key)
+    if err != nil {
+        return nil, "", err
+    }
+    return invite, code, nil
+}
+
+func (s *inviteService) GetAllInvites() ([]*models.Invite, error) {
+    return s.repo.GetAllInvites()
+}
+
+// RevokeInvite membatalkan undangan yang belum dipakai
+func (s *inviteService) RevokeInvite(id uuid.UUID) error {
+    if _, err := s.repo.GetInviteByID(id); err != nil {
+        return err
+    }
+    if err := s.repo.RevokeInvite(id); err != nil {
+        if errors.Is(err, repository.ErrRowConflict) {
+            return &ConflictError{Err: ErrInviteUnavailable}
+        }
+        return err
+    }
+    return nil
+}
+
+// RegisterWithInvite mendaftarkan user dengan role dari undangan (atau admin pertama lewat bootstrap token)
+func (s *inviteService) RegisterWithInvite(code, username, email, password1, password2 string) (*models.User, error) {
+    if s.bootstrapToken != "" && subtle.ConstantTimeCompare([]byte(code), []byte(s.bootstrapToken)) == 1 {
+        user, err := newUser(username, email, password1, password2, 1)
+        if err != nil {
+            return nil, err
+        }
+        if err := s.repo.CreateFirstAdmin(user); err != nil {
+            if errors.Is(err, repository.ErrAdminExists) {
+                return nil, ErrBootstrapClosed
+            }
+            return nil, err
+        }
+        return user, nil
+    }
+
+    invite, err := s.verify(code)
+    if err != nil {
+        return nil, err
+    }
+    if invite.Email != "" && !strings.EqualFold(invite.Email, strings.TrimSpace(email)) {
+        return nil, ErrInviteEmailMismatch
+    }
+
+    user, err := newUser(username, email, password1, password2, invite.Role)
+    if err != nil {
+        return nil, err
+    }
+    if err := s.repo.RedeemInvite(invite, user); err != nil {
+        if errors.Is(err, repository.ErrRowConflict) {
+            return nil, ErrInviteUnavailable
+        }
+        return nil, err
+    }
+    return user, nil
+}
+
+// verify memeriksa tanda tangan dan masa berlaku kode lalu memastikan undangannya masih bisa dipakai
+func (s *inviteService) verify(code string) (*models.Invite, error) {
+    claims := &inviteClaims{}
+    token, err := jwt.ParseWithClaims(code, claims, func(token *jwt.Token) (interface{}, error) {
+        if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+            return nil, ErrInvalidInvite
+        }
+        return s.key, nil
+    })
+    if err != nil || !token.Valid || !claims.VerifyAudience(inviteAudience, true) {
+        return nil, ErrInvalidInvite
+    }
+
+    id, err := uuid.Parse(claims.ID)
+    if err != nil {
+        return nil, ErrInvalidInvite
+    }
+    invite, err := s.repo.GetInviteByID(id)
+    if err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return nil, ErrInvalidInvite
+        }
+        return nil, err
+    }
+    if invite.UsedAt != nil || invite.RevokedAt != nil || time.Now().After(invite.ExpiresAt) {
+        return nil, ErrInviteUnavailable
+    }
+    return invite, nil
+}
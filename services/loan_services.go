@@ -3,128 +3,487 @@
 package services
 
 import (
+    "auth-user-api/events"
     "auth-user-api/models"
+    "auth-user-api/notify"
+    "auth-user-api/query"
     "auth-user-api/repository"
     "errors"
     "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
 )
 
 // Tambahkan variabel error untuk kode 404
 var ErrBookOutOfStock = errors.New("book out of stock")
 
+// ErrBookDeleted menolak pinjaman atau reservasi baru untuk buku yang ada di trash
+var ErrBookDeleted = errors.New("book has been deleted")
+
+var (
+    ErrRequestAlreadyProcessed = errors.New("request already processed")
+    ErrBookAlreadyReturned     = errors.New("book already returned")
+    ErrCopyNotOnLoan           = errors.New("copy is no longer on loan")
+)
+
+var (
+    ErrLoanOverdue         = errors.New("overdue loans cannot be renewed")
+    ErrRenewalLimitReached = errors.New("maximum number of renewals reached")
+    ErrHoldsPending        = errors.New("other members are waiting for this book")
+    ErrBorrowingNotAllowed = errors.New("circulation policy does not allow this user to borrow this book")
+    ErrLoanLimitReached    = errors.New("maximum number of concurrent loans reached")
+)
+
+// ConflictError menandakan operasi bentrok dengan perubahan lain yang terjadi bersamaan,
+// misalnya stok habis saat persetujuan atau request sudah diproses admin lain.
+type ConflictError struct {
+    Err error
+}
+
+func (e *ConflictError) Error() string {
+    return "conflict: " + e.Err.Error()
+}
+
+func (e *ConflictError) Unwrap() error {
+    return e.Err
+}
+
 type LoanService struct {
-    Repo *repository.LoanRepository
+    Repo          *repository.LoanRepository
+    Holds         *HoldService
+    Policies      CirculationPolicyService
+    Fines         *FineService
+    Eligibility   *EligibilityService
+    Notifications *NotificationService
 }
 
-func NewLoanService(repo *repository.LoanRepository) *LoanService {
-    return &LoanService{Repo: repo}
+func NewLoanService(repo *repository.LoanRepository, holds *HoldService, policies CirculationPolicyService, fines *FineService, eligibility *EligibilityService, notifications *NotificationService) *LoanService {
+    return &LoanService{Repo: repo, Holds: holds, Policies: policies, Fines: fines, Eligibility: eligibility, Notifications: notifications}
 }
 
-// Cek stok buku sebelum membuat request peminjaman
-func (s *LoanService) CreateLoanRequest(req *models.LoanRequest) error {
-    // Periksa apakah stok buku ada
-    book, err := s.Repo.GetBookByID(int(req.BookID))
+// policyFor resolves the circulation policy for a borrower and a book
+func (s *LoanService) policyFor(tx *repository.LoanRepository, userID uuid.UUID, book *models.Book) (*models.CirculationPolicy, error) {
+    role, err := tx.GetUserRole(userID)
     if err != nil {
-        return err
-    }
-    if book.Stock <= 0 {
-        return ErrBookOutOfStock
+        return nil, err
     }
-    
-    req.RequestTime = time.Now()
-    req.Status = "PENDING"
-    return s.Repo.CreateLoanRequest(req)
+    return s.Policies.ResolvePolicy(role, book.Category)
 }
 
-func (s *LoanService) ApproveLoanRequest(requestID uint) (*models.LoanRecord, error) {
-    req, err := s.Repo.GetLoanRequestByID(requestID)
+// loanPolicyFor resolves the circulation policy for a loan already on record. Berbeda dengan
+// policyFor, peminjam yang sudah dihapus tetap dipakai rolenya.
+func (s *LoanService) loanPolicyFor(tx *repository.LoanRepository, loan *models.LoanRecord, book *models.Book) (*models.CirculationPolicy, error) {
+    role, err := tx.GetBorrowerRole(loan.UserID)
     if err != nil {
         return nil, err
     }
+    return s.Policies.ResolvePolicy(role, book.Category)
+}
 
-    if req.Status != "PENDING" {
-        return nil, errors.New("request already processed")
+// checkBorrowingAllowed menolak peminjaman jika aturan melarang atau batas pinjaman aktif tercapai
+func (s *LoanService) checkBorrowingAllowed(tx *repository.LoanRepository, policy *models.CirculationPolicy, userID uuid.UUID) error {
+    if !policy.CanBorrow {
+        return ErrBorrowingNotAllowed
     }
-
-    book, err := s.Repo.GetBookByID(req.BookID)
-    if err != nil || book.Stock <= 0 {
-        return nil, errors.New("book out of stock")
+    if policy.MaxConcurrentLoans > 0 {
+        active, err := tx.CountActiveLoans(userID)
+        if err != nil {
+            return err
+        }
+        if active >= int64(policy.MaxConcurrentLoans) {
+            return ErrLoanLimitReached
+        }
     }
+    return nil
+}
 
-    req.Status = "APPROVED"
-    if err := s.Repo.UpdateLoanRequest(req); err != nil {
-        return nil, err
-    }
+// Cek stok buku dan kelayakan anggota lalu simpan request peminjaman dalam satu transaksi
+func (s *LoanService) CreateLoanRequest(req *models.LoanRequest) error {
+    req.RequestTime = time.Now()
+    req.Status = "PENDING"
+    return s.Repo.Transaction(func(tx *repository.LoanRepository) error {
+        // Request bersamaan dari anggota yang sama diperiksa bergiliran, sehingga batas
+        // max_open_items dan aturan duplikat tidak bisa dilewati dengan balapan
+        if err := tx.LockUser(req.UserID); err != nil {
+            return err
+        }
 
-    loan := &models.LoanRecord{
-        BookID:   req.BookID,
-        UserID:   req.UserID,
-        LoanDate: time.Now(),
-        DueDate:  time.Now().AddDate(0, 0, 3), // Menetapkan tanggal pengembalian otomatis 3 hari dari sekarang
-    }
+        // Kunci buku agar request tidak lolos di tengah penghapusan buku
+        book, err := tx.LockBook(req.BookID)
+        if err != nil {
+            return err
+        }
+        if book.DeletedAt.Valid {
+            return &ConflictError{Err: ErrBookDeleted}
+        }
+
+        // Periksa apakah stok buku ada
+        if book.Stock <= 0 {
+            // Anggota dengan reservasi READY boleh mengajukan pinjaman untuk eksemplar yang disisihkan
+            hold, err := tx.GetActiveHold(req.BookID, req.UserID)
+            if err != nil || hold.Status != models.HoldStatusReady {
+                return ErrBookOutOfStock
+            }
+        }
+
+        // Jalankan seluruh aturan kelayakan sebelum request diterima
+        if err := s.Eligibility.Check(tx, req.UserID, book); err != nil {
+            return err
+        }
+
+        if err := tx.CreateLoanRequest(req); err != nil {
+            if repository.IsUniqueViolation(err, "idx_loan_requests_pending") {
+                return &EligibilityError{Reasons: []EligibilityReason{{ReasonDuplicateRequest, "a pending request for this book already exists"}}}
+            }
+            return err
+        }
+        return recordEvent(tx, events.LoanRequested{RequestID: req.ID, BookID: req.BookID, UserID: req.UserID})
+    })
+}
+
+func (s *LoanService) ApproveLoanRequest(requestID uint) (*models.LoanRecord, error) {
+    var loan *models.LoanRecord
+
+    // Seluruh proses persetujuan berjalan dalam satu transaksi dengan row lock
+    err := s.Repo.Transaction(func(tx *repository.LoanRepository) error {
+        req, err := tx.LockLoanRequest(requestID)
+        if err != nil {
+            return err
+        }
+
+        if req.Status != "PENDING" {
+            return &ConflictError{Err: ErrRequestAlreadyProcessed}
+        }
+
+        book, err := tx.LockBook(req.BookID)
+        if err != nil {
+            return err
+        }
+        if book.DeletedAt.Valid {
+            return &ConflictError{Err: ErrBookDeleted}
+        }
+
+        // Periksa ulang aturan sirkulasi saat persetujuan
+        policy, err := s.policyFor(tx, req.UserID, book)
+        if err != nil {
+            return err
+        }
+        if err := s.checkBorrowingAllowed(tx, policy, req.UserID); err != nil {
+            return &ConflictError{Err: err}
+        }
+
+        // Pakai eksemplar yang disisihkan jika peminjam punya reservasi READY,
+        // selain itu ambil dan kunci eksemplar yang tersedia di rak
+        var bookCopy *models.BookCopy
+        copyStatus := models.CopyStatusAvailable
+        hold, err := tx.GetActiveHold(req.BookID, req.UserID)
+        if err == nil && hold.Status == models.HoldStatusReady && hold.CopyID != nil {
+            bookCopy = &models.BookCopy{ID: *hold.CopyID, BookID: req.BookID}
+            copyStatus = models.CopyStatusOnHold
 
-    if err := s.Repo.CreateLoanRecord(loan); err != nil {
+            hold.Status = models.HoldStatusFulfilled
+            if err := tx.UpdateHold(hold); err != nil {
+                return err
+            }
+        } else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+            return err
+        } else {
+            bookCopy, err = tx.ClaimAvailableCopy(req.BookID)
+            if errors.Is(err, gorm.ErrRecordNotFound) {
+                return &ConflictError{Err: ErrBookOutOfStock}
+            }
+            if err != nil {
+                return err
+            }
+        }
+
+        req.Status = "APPROVED"
+        if err := tx.UpdateLoanRequest(req); err != nil {
+            return err
+        }
+
+        loan = &models.LoanRecord{
+            BookID:   req.BookID,
+            CopyID:   &bookCopy.ID,
+            UserID:   req.UserID,
+            LoanDate: time.Now(),
+            DueDate:  time.Now().AddDate(0, 0, policy.LoanPeriodDays), // Menetapkan tanggal pengembalian sesuai aturan sirkulasi
+        }
+
+        if err := tx.CreateLoanRecord(loan); err != nil {
+            return err
+        }
+
+        // Tandai eksemplar sebagai dipinjam, stok buku dihitung ulang
+        if err := conflictOnRowChange(tx.UpdateCopyStatus(bookCopy, copyStatus, models.CopyStatusOnLoan), ErrBookOutOfStock); err != nil {
+            return err
+        }
+
+        err = recordEvent(tx, events.LoanApproved{
+            RequestID: req.ID,
+            LoanID:    loan.ID,
+            BookID:    loan.BookID,
+            CopyID:    loan.CopyID,
+            UserID:    loan.UserID,
+            DueDate:   loan.DueDate,
+        })
+        if err != nil {
+            return err
+        }
+
+        // Notifikasi masuk outbox di transaksi ini dan baru dikirim setelah commit
+        return s.Notifications.Enqueue(tx, req.UserID, notify.EventLoanRequestApproved, map[string]interface{}{
+            "loan_id":  loan.ID,
+            "title":    book.Title,
+            "due_date": loan.DueDate.Format("2006-01-02"),
+        })
+    })
+    if err != nil {
         return nil, err
     }
-
-    s.Repo.UpdateBookStock(req.BookID, -1) // Kurangi stok
     return loan, nil
 }
 
 // RejectLoanRequest rejects a loan request with a custom reason
 func (s *LoanService) RejectLoanRequest(requestID uint, reason string) error {
-    req, err := s.Repo.GetLoanRequestByID(requestID)
-    if err != nil {
-        return err
-    }
+    return s.Repo.Transaction(func(tx *repository.LoanRepository) error {
+        req, err := tx.LockLoanRequest(requestID)
+        if err != nil {
+            return err
+        }
 
-    if req.Status != "PENDING" {
-        return errors.New("request already processed")
-    }
+        if req.Status != "PENDING" {
+            return &ConflictError{Err: ErrRequestAlreadyProcessed}
+        }
 
-    req.Status = "REJECTED"
-    req.RejectReason = &reason // Set the custom rejection reason
+        req.Status = "REJECTED"
+        req.RejectReason = &reason // Set the custom rejection reason
 
-    return s.Repo.UpdateLoanRequest(req)
+        if err := tx.UpdateLoanRequest(req); err != nil {
+            return err
+        }
+        if err := recordEvent(tx, events.LoanRejected{RequestID: req.ID, BookID: req.BookID, UserID: req.UserID, Reason: reason}); err != nil {
+            return err
+        }
+
+        book, err := tx.GetBookByID(req.BookID)
+        if err != nil {
+            return err
+        }
+        return s.Notifications.Enqueue(tx, req.UserID, notify.EventLoanRequestRejected, map[string]interface{}{
+            "request_id": req.ID,
+            "title":      book.Title,
+            "reason":     reason,
+        })
+    })
 }
 
 func (s *LoanService) ReturnBook(loanID uint) (*models.LoanRecord, int, error) {
-    loan, err := s.Repo.GetLoanRecordByID(loanID)
+    return s.returnLoan(loanID, false)
+}
+
+// ForceReturn menutup pinjaman walaupun status eksemplarnya sudah tidak ON_LOAN
+// (mis. ditandai hilang); eksemplar hanya dikembalikan ke rak/antrean jika masih ON_LOAN.
+// Denda keterlambatan tetap dicatat dan bisa dihapus lewat WaiveFine.
+func (s *LoanService) ForceReturn(loanID uint) (*models.LoanRecord, int, error) {
+    return s.returnLoan(loanID, true)
+}
+
+func (s *LoanService) returnLoan(loanID uint, force bool) (*models.LoanRecord, int, error) {
+    var loan *models.LoanRecord
+    lateFee := 0
+
+    err := s.Repo.Transaction(func(tx *repository.LoanRepository) error {
+        var err error
+        loan, err = tx.LockLoanRecord(loanID)
+        if err != nil {
+            return err
+        }
+
+        if loan.Returned {
+            return &ConflictError{Err: ErrBookAlreadyReturned}
+        }
+
+        loan.Returned = true
+        loan.ReturnDate = timePtr(time.Now())
+
+        if err := tx.UpdateLoanRecord(loan); err != nil {
+            return err
+        }
+
+        book, err := tx.LockBook(loan.BookID)
+        if err != nil {
+            return err
+        }
+
+        // Denda keterlambatan mengikuti aturan sirkulasi peminjam dan kategori buku
+        policy, err := s.loanPolicyFor(tx, loan, book)
+        if err != nil {
+            return err
+        }
+        lateFee = CalculateLateFee(policy, loan.DueDate, *loan.ReturnDate)
+        if _, err := s.Fines.RecordLateFee(tx, loan, lateFee); err != nil {
+            return err
+        }
+
+        err = recordEvent(tx, events.LoanReturned{
+            LoanID:     loan.ID,
+            BookID:     loan.BookID,
+            CopyID:     loan.CopyID,
+            UserID:     loan.UserID,
+            ReturnedAt: *loan.ReturnDate,
+            LateFee:    lateFee,
+            Forced:     force,
+        })
+        if err != nil {
+            return err
+        }
+
+        // Kembalikan eksemplar ke antrean reservasi atau ke rak;
+        // pinjaman lama tanpa copy_id memakai eksemplar ON_LOAN yang tersisa
+        var bookCopy *models.BookCopy
+        if loan.CopyID != nil {
+            bookCopy, err = tx.LockCopy(*loan.CopyID)
+        } else {
+            bookCopy, err = tx.GetUntrackedLoanedCopy(loan.BookID)
+        }
+        if err != nil {
+            if force && errors.Is(err, gorm.ErrRecordNotFound) {
+                return nil
+            }
+            return err
+        }
+        if force && bookCopy.Status != models.CopyStatusOnLoan {
+            return nil
+        }
+        return s.Holds.passCopyToNextHold(tx, bookCopy, models.CopyStatusOnLoan)
+    })
     if err != nil {
         return nil, 0, err
     }
+    return loan, lateFee, nil
+}
 
-    if loan.Returned {
-        return nil, 0, errors.New("book already returned")
-    }
+// RenewLoan memperpanjang tanggal jatuh tempo pinjaman dan mencatat riwayatnya.
+// Ditolak jika pinjaman sudah terlambat, batas perpanjangan tercapai, atau ada antrean reservasi.
+func (s *LoanService) RenewLoan(loanID uint, renewedBy string) (*models.LoanRecord, *models.CirculationPolicy, error) {
+    var loan *models.LoanRecord
+    var policy *models.CirculationPolicy
 
-    loan.Returned = true
-    loan.ReturnDate = timePtr(time.Now())
+    err := s.Repo.Transaction(func(tx *repository.LoanRepository) error {
+        var err error
+        loan, err = tx.LockLoanRecord(loanID)
+        if err != nil {
+            return err
+        }
 
-    lateFee := 0
-    if time.Now().After(loan.DueDate) {
-        daysLate := int(time.Since(loan.DueDate).Hours() / 24)
-        lateFee = daysLate * 5000
-    }
+        book, err := tx.GetBookByID(loan.BookID)
+        if err != nil {
+            return err
+        }
+        policy, err = s.loanPolicyFor(tx, loan, book)
+        if err != nil {
+            return err
+        }
 
-    s.Repo.UpdateLoanRecord(loan)
-    s.Repo.UpdateBookStock(loan.BookID, 1) // Increase stock
+        if loan.Returned {
+            return &ConflictError{Err: ErrBookAlreadyReturned}
+        }
+        if time.Now().After(loan.DueDate) {
+            return &ConflictError{Err: ErrLoanOverdue}
+        }
+        if loan.RenewalCount >= policy.MaxRenewals {
+            return &ConflictError{Err: ErrRenewalLimitReached}
+        }
 
-    return loan, lateFee, nil
+        waiting, err := tx.CountWaitingHolds(loan.BookID)
+        if err != nil {
+            return err
+        }
+        if waiting > 0 {
+            return &ConflictError{Err: ErrHoldsPending}
+        }
+
+        renewal := &models.LoanRenewal{
+            LoanRecordID:    loan.ID,
+            PreviousDueDate: loan.DueDate,
+            NewDueDate:      loan.DueDate.AddDate(0, 0, policy.RenewalPeriodDays),
+            RenewedBy:       renewedBy,
+            RenewedAt:       time.Now(),
+        }
+        if err := tx.CreateLoanRenewal(renewal); err != nil {
+            return err
+        }
+
+        loan.DueDate = renewal.NewDueDate
+        loan.RenewalCount++
+        if err := tx.UpdateLoanRecord(loan); err != nil {
+            return err
+        }
+
+        loan.Renewals, err = tx.GetLoanRenewals(loan.ID)
+        return err
+    })
+    if err != nil {
+        return nil, nil, err
+    }
+    return loan, policy, nil
+}
+
+// conflictOnRowChange converts a failed conditional update into a ConflictError
+func conflictOnRowChange(err error, cause error) error {
+    if errors.Is(err, repository.ErrRowConflict) {
+        return &ConflictError{Err: cause}
+    }
+    return err
 }
 
 func timePtr(t time.Time) *time.Time {
     return &t
 }
 
-// GetAllLoanRequests fetches all loan requests with borrower names.
-func (s *LoanService) GetAllLoanRequests() ([]map[string]interface{}, error) {
-    return s.Repo.GetAllLoanRequests()
+// GetLoanRequest returns one loan request
+func (s *LoanService) GetLoanRequest(id uint) (*models.LoanRequest, error) {
+    return s.Repo.GetLoanRequestByID(id)
+}
+
+// GetLoanRecord returns one loan record
+func (s *LoanService) GetLoanRecord(id uint) (*models.LoanRecord, error) {
+    return s.Repo.GetLoanRecordByID(id)
+}
+
+// GetBorrowerName returns the username shown as borrower_name in loan responses
+func (s *LoanService) GetBorrowerName(userID uuid.UUID) (string, error) {
+    return s.Repo.GetUsernameByUserID(userID)
+}
+
+// CheckEligibility mengembalikan semua alasan yang akan menolak loan request user untuk buku.
+// Buku di trash dianggap tidak ada (gorm.ErrRecordNotFound).
+func (s *LoanService) CheckEligibility(userID uuid.UUID, bookID int) ([]EligibilityReason, error) {
+    book, err := s.Repo.GetBookByID(bookID)
+    if err != nil {
+        return nil, err
+    }
+    if book.DeletedAt.Valid {
+        return nil, gorm.ErrRecordNotFound
+    }
+    return s.Eligibility.Evaluate(userID, book)
 }
 
-// GetAllLoanRecords retrieves all loan records.
-func (s *LoanService) GetAllLoanRecords() ([]map[string]interface{}, error) {
-    return s.Repo.GetAllLoanRecords()
+// GetAllLoanRequests fetches one page of loan requests with borrower names.
+func (s *LoanService) GetAllLoanRequests(spec *query.Spec) ([]map[string]interface{}, *query.Page, error) {
+    return s.Repo.GetAllLoanRequests(spec)
+}
+
+// GetOverdueLoans lists every unreturned loan past its due date
+func (s *LoanService) GetOverdueLoans() ([]map[string]interface{}, error) {
+    return s.Repo.GetOverdueLoans()
+}
+
+// GetAllLoanRecords retrieves one page of loan records.
+func (s *LoanService) GetAllLoanRecords(spec *query.Spec) ([]map[string]interface{}, *query.Page, error) {
+    return s.Repo.GetAllLoanRecords(spec)
 }
 
 // SearchLoansByUsername retrieves all loans associated with a given username.
@@ -132,19 +491,41 @@ func (s *LoanService) SearchLoansByUsername(username string) ([]map[string]inter
     return s.Repo.GetLoansByUsername(username)
 }
 
+// GetLoansByUserID retrieves all loans of the given user.
+func (s *LoanService) GetLoansByUserID(userID uuid.UUID) ([]map[string]interface{}, error) {
+    return s.Repo.GetLoansByUserID(userID)
+}
+
+// GetLoanRequestsByUserID retrieves all loan requests of the given user.
+func (s *LoanService) GetLoanRequestsByUserID(userID uuid.UUID) ([]map[string]interface{}, error) {
+    return s.Repo.GetLoanRequestsByUserID(userID)
+}
+
 // CancelLoanRequest cancels a loan request with a custom reason
-func (s *LoanService) CancelLoanRequest(requestID uint, reason string) error {
-    req, err := s.Repo.GetLoanRequestByID(requestID)
-    if err != nil {
-        return err
-    }
+func (s *LoanService) CancelLoanRequest(requestID uint, reason string, cancelledBy uuid.UUID) error {
+    return s.Repo.Transaction(func(tx *repository.LoanRepository) error {
+        req, err := tx.LockLoanRequest(requestID)
+        if err != nil {
+            return err
+        }
 
-    if req.Status != "PENDING" {
-        return errors.New("request already processed")
-    }
+        if req.Status != "PENDING" {
+            return &ConflictError{Err: ErrRequestAlreadyProcessed}
+        }
 
-    req.Status = "CANCELLED"
-    req.RejectReason = &reason // Set the custom cancellation reason
+        req.Status = "CANCELLED"
+        req.RejectReason = &reason // Set the custom cancellation reason
+        req.CancelledBy = &cancelledBy
 
-    return s.Repo.UpdateLoanRequest(req)
+        if err := tx.UpdateLoanRequest(req); err != nil {
+            return err
+        }
+        return recordEvent(tx, events.LoanCancelled{
+            RequestID:   req.ID,
+            BookID:      req.BookID,
+            UserID:      req.UserID,
+            CancelledBy: cancelledBy,
+            Reason:      reason,
+        })
+    })
 }
@@ -0,0 +1,317 @@
+// services/notification_services.go
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "time"
+    "auth-user-api/models"
+    "auth-user-api/notify"
+    "auth-user-api/query"
+    "auth-user-api/repository"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+)
+
+var (
+    ErrUnsupportedLocale   = errors.New("locale must be id or en")
+    ErrChannelNotEnabled   = errors.New("notification channel is not enabled")
+    ErrNotificationPending = errors.New("notification was not delivered")
+)
+
+// notificationBatchSize adalah jumlah notifikasi yang diambil worker sekali jalan
+const notificationBatchSize = 50
+
+// NotificationDeliveryResult summarizes one delivery run
+type NotificationDeliveryResult struct {
+    Sent    int `json:"sent"`
+    Retried int `json:"retried"` // Gagal dan dijadwalkan ulang
+    Failed  int `json:"failed"`  // Gagal dan tidak dicoba lagi
+}
+
+// NotificationPreferences is what a user can see and change about their notifications
+type NotificationPreferences struct {
+    Locale   string   `json:"locale"`
+    Channels []string `json:"channels"` // Channel yang aktif di server, mengikuti konfigurasi
+}
+
+// NotificationService merender notifikasi ke outbox (tabel notifications) di dalam transaksi
+// pemanggil, lalu mengirimnya lewat Notifier masing-masing channel di luar transaksi itu.
+// Pengiriman yang gagal dicoba lagi dengan backoff sampai MaxAttempts.
+type NotificationService struct {
+    Repo          *repository.LoanRepository
+    Notifiers     map[string]notify.Notifier // Channel aktif
+    DefaultLocale string
+    MaxAttempts   int
+    Timeout       time.Duration // Batas waktu satu pengiriman
+}
+
+func NewNotificationService(repo *repository.LoanRepository, notifiers map[string]notify.Notifier, defaultLocale string, maxAttempts int, timeout time.Duration) *NotificationService {
+    return &NotificationService{Repo: repo, Notifiers: notifiers, DefaultLocale: defaultLocale, MaxAttempts: maxAttempts, Timeout: timeout}
+}
+
+// Enqueue merender event untuk user dalam bahasanya dan membuat satu notifikasi per channel
+// aktif. Dipanggil dengan tx dari transaksi yang memicu event, sehingga notifikasi hanya ada
+// jika perubahan itu tersimpan. Notifikasi tidak boleh membatalkan transaksi tersebut: jika
+// penerimanya sudah dihapus atau template gagal dirender, notifikasi dilewati dan dicatat di log.
+// Hanya kegagalan menulis ke outbox yang dikembalikan.
+func (s *NotificationService) Enqueue(tx *repository.LoanRepository, userID uuid.UUID, event string, data map[string]interface{}) error {
+    _, err := s.enqueue(tx, userID, event, data, s.channels(), time.Now())
+    var renderErr *notificationRenderError
+    switch {
+    case errors.Is(err, gorm.ErrRecordNotFound):
+        log.Printf("Notification %s skipped: user %s no longer exists", event, userID)
+        return nil
+    case errors.As(err, &renderErr):
+        log.Printf("Notification %s skipped for user %s: %v", event, userID, renderErr.Err)
+        return nil
+    }
+    return err
+}
+
+// notificationRenderError membedakan kegagalan template dari kegagalan database di Enqueue
+type notificationRenderError struct {
+    Err error
+}
+
+func (e *notificationRenderError) Error() string {
+    return "render notification: " + e.Err.Error()
+}
+
+func (e *notificationRenderError) Unwrap() error {
+    return e.Err
+}
+
+func (s *NotificationService) enqueue(tx *repository.LoanRepository, userID uuid.UUID, event string, data map[string]interface{}, channels []string, nextAttempt time.Time) ([]*models.Notification, error) {
+    user, err := tx.GetNotificationRecipient(userID)
+    if err != nil {
+        return nil, err
+    }
+    locale := user.Locale
+    if !models.IsSupportedLocale(locale) {
+        locale = s.DefaultLocale
+    }
+
+    values := models.JSONMap{"username": user.Username}
+    for key, value := range data {
+        values[key] = value
+    }
+    subject, body, err := notify.Render(event, locale, values)
+    if err != nil {
+        return nil, &notificationRenderError{Err: err}
+    }
+
+    var notifications []*models.Notification
+    for _, channel := range channels {
+        // User tanpa email tidak bisa dikirimi lewat SMTP; channel lain tetap jalan
+        if channel == models.NotificationChannelEmail && user.Email == "" {
+            continue
+        }
+        notifications = append(notifications, &models.Notification{
+            UserID:        userID,
+            Channel:       channel,
+            Event:         event,
+            Locale:        locale,
+            Subject:       subject,
+            Body:          body,
+            Data:          values,
+            Status:        models.NotificationStatusPending,
+            NextAttemptAt: nextAttempt,
+        })
+    }
+    if err := tx.CreateNotifications(notifications); err != nil {
+        return nil, err
+    }
+    return notifications, nil
+}
+
+// DeliverPending mengirim semua notifikasi yang sudah waktunya, per batch sampai habis.
+// Aman dijalankan di beberapa instance: baris yang sudah diambil instance lain dilewati.
+func (s *NotificationService) DeliverPending(ctx context.Context) (*NotificationDeliveryResult, error) {
+    result := &NotificationDeliveryResult{}
+    // Lease cukup panjang untuk mengirim satu batch penuh yang semuanya timeout
+    lease := s.Timeout*notificationBatchSize + time.Minute
+
+    for {
+        notifications, err := s.Repo.ClaimDueNotifications(time.Now(), notificationBatchSize, lease)
+        if err != nil {
+            return result, err
+        }
+        for _, n := range notifications {
+            if err := s.deliver(ctx, n); err != nil {
+                return result, err
+            }
+            switch n.Status {
+            case models.NotificationStatusSent:
+                result.Sent++
+            case models.NotificationStatusFailed:
+                result.Failed++
+            default:
+                result.Retried++
+            }
+        }
+        if len(notifications) < notificationBatchSize || ctx.Err() != nil {
+            return result, ctx.Err()
+        }
+    }
+}
+
+// deliver mengirim satu notifikasi yang sudah di-claim dan menyimpan hasilnya. Error hanya
+// dikembalikan jika hasilnya gagal disimpan; kegagalan kirim dicatat di notifikasi.
+func (s *NotificationService) deliver(ctx context.Context, n *models.Notification) error {
+    n.Attempts++
+    sendErr := s.send(ctx, n)
+
+    now := time.Now()
+    if sendErr == nil {
+        n.Status = models.NotificationStatusSent
+        n.SentAt = &now
+        n.LastError = nil
+        return s.Repo.MarkNotificationSent(n.ID, n.Attempts, now)
+    }
+
+    message := sendErr.Error()
+    n.LastError = &message
+    n.NextAttemptAt = now.Add(retryBackoff(n.Attempts))
+    final := n.Attempts >= s.MaxAttempts
+    if final {
+        n.Status = models.NotificationStatusFailed
+    }
+    log.Printf("Notification %d (%s) attempt %d failed: %v", n.ID, n.Channel, n.Attempts, sendErr)
+    return s.Repo.MarkNotificationFailed(n.ID, n.Attempts, message, n.NextAttemptAt, final)
+}
+
+func (s *NotificationService) send(ctx context.Context, n *models.Notification) error {
+    notifier, ok := s.Notifiers[n.Channel]
+    if !ok {
+        return fmt.Errorf("%w: %s", ErrChannelNotEnabled, n.Channel)
+    }
+
+    // Alamat dibaca saat kirim agar perubahan email user sebelum retry ikut terpakai
+    user, err := s.Repo.GetNotificationRecipient(n.UserID)
+    if err != nil {
+        return err
+    }
+
+    ctx, cancel := context.WithTimeout(ctx, s.Timeout)
+    defer cancel()
+    return notifier.Send(ctx, &notify.Message{
+        ID:        n.ID,
+        Event:     n.Event,
+        Locale:    n.Locale,
+        Subject:   n.Subject,
+        Body:      n.Body,
+        Data:      n.Data,
+        UserID:    n.UserID,
+        Username:  user.Username,
+        Email:     user.Email,
+        CreatedAt: n.CreatedAt,
+    })
+}
+
+// retryBackoff: 1, 2, 4, ... menit, paling lama satu jam
+func retryBackoff(attempts int) time.Duration {
+    if attempts > 6 {
+        return time.Hour
+    }
+    return time.Minute << (attempts - 1)
+}
+
+// SendTest membuat notifikasi percobaan untuk user di satu channel dan langsung mengirimnya,
+// mis. untuk memeriksa konfigurasi SMTP terhadap server SMTP palsu lokal. Notifikasi dibuat
+// seolah sudah di-claim sehingga worker tidak ikut mengirimnya.
+func (s *NotificationService) SendTest(ctx context.Context, userID uuid.UUID, channel string) (*models.Notification, error) {
+    if _, ok := s.Notifiers[channel]; !ok {
+        return nil, fmt.Errorf("%w: %s", ErrChannelNotEnabled, channel)
+    }
+
+    notifications, err := s.enqueue(s.Repo, userID, notify.EventTest, nil, []string{channel}, time.Now().Add(s.Timeout+time.Minute))
+    if err != nil {
+        return nil, err
+    }
+    if len(notifications) == 0 {
+        return nil, notify.ErrNoRecipient
+    }
+
+    n := notifications[0]
+    if err := s.deliver(ctx, n); err != nil {
+        return nil, err
+    }
+    if n.Status != models.NotificationStatusSent {
+        return n, fmt.Errorf("%w: %s", ErrNotificationPending, *n.LastError)
+    }
+    return n, nil
+}
+
+// RunDeliveryWorker runs DeliverPending every interval until the process exits
+func (s *NotificationService) RunDeliveryWorker(interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for range ticker.C {
+        result, err := s.DeliverPending(context.Background())
+        if err != nil {
+            log.Printf("Failed to deliver notifications: %v", err)
+            continue
+        }
+        if result.Sent > 0 || result.Retried > 0 || result.Failed > 0 {
+            log.Printf("Notifications: %d sent, %d retried, %d failed", result.Sent, result.Retried, result.Failed)
+        }
+    }
+}
+
+// GetInbox returns one page of the user's in-app notifications and the number still unread
+func (s *NotificationService) GetInbox(userID uuid.UUID, spec *query.Spec) ([]*models.Notification, *query.Page, int64, error) {
+    notifications, page, err := s.Repo.GetInbox(userID, spec)
+    if err != nil {
+        return nil, nil, 0, err
+    }
+    unread, err := s.Repo.CountUnreadNotifications(userID)
+    if err != nil {
+        return nil, nil, 0, err
+    }
+    return notifications, page, unread, nil
+}
+
+func (s *NotificationService) MarkRead(userID uuid.UUID, id uint) (*models.Notification, error) {
+    return s.Repo.MarkNotificationRead(userID, id, time.Now())
+}
+
+func (s *NotificationService) MarkAllRead(userID uuid.UUID) (int64, error) {
+    return s.Repo.MarkAllNotificationsRead(userID, time.Now())
+}
+
+func (s *NotificationService) GetPreferences(userID uuid.UUID) (*NotificationPreferences, error) {
+    user, err := s.Repo.GetNotificationRecipient(userID)
+    if err != nil {
+        return nil, err
+    }
+    locale := user.Locale
+    if !models.IsSupportedLocale(locale) {
+        locale = s.DefaultLocale
+    }
+    return &NotificationPreferences{Locale: locale, Channels: s.channels()}, nil
+}
+
+func (s *NotificationService) UpdateLocale(userID uuid.UUID, locale string) (*NotificationPreferences, error) {
+    if !models.IsSupportedLocale(locale) {
+        return nil, ErrUnsupportedLocale
+    }
+    if err := s.Repo.UpdateUserLocale(userID, locale); err != nil {
+        return nil, err
+    }
+    return &NotificationPreferences{Locale: locale, Channels: s.channels()}, nil
+}
+
+func (s *NotificationService) channels() []string {
+    var channels []string
+    for _, channel := range []string{models.NotificationChannelInApp, models.NotificationChannelEmail, models.NotificationChannelWebhook} {
+        if _, ok := s.Notifiers[channel]; ok {
+            channels = append(channels, channel)
+        }
+    }
+    return channels
+}
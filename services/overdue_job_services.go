@@ -0,0 +1,193 @@
+// services/overdue_job_services.go
+package services
+
+import (
+    "log"
+    "time"
+    "auth-user-api/models"
+    "auth-user-api/notify"
+    "auth-user-api/repository"
+)
+
+// overdueJobLockKey adalah kunci advisory lock Postgres untuk job overdue. Hanya satu
+// instance (server atau libctl) yang memproses pada satu waktu; yang lain melewati putaran itu.
+const overdueJobLockKey int64 = 0x4c4f414e4455 // "LOANDU"
+
+// OverdueJobResult summarizes one run of the overdue job
+type OverdueJobResult struct {
+    Skipped           bool      `json:"skipped"` // Instance lain sedang menjalankan job
+    MarkedOverdue     int64     `json:"marked_overdue"`
+    FinesAccrued      int       `json:"fines_accrued"` // Denda yang dibuat atau dinaikkan
+    RemindersQueued   int       `json:"reminders_queued"`
+    EscalationsQueued int       `json:"escalations_queued"`
+    Failed            int       `json:"failed"` // Pinjaman yang gagal diproses, dicoba lagi di putaran berikutnya
+    StartedAt         time.Time `json:"started_at"`
+    FinishedAt        time.Time `json:"finished_at"`
+}
+
+// OverdueJobService menandai pinjaman yang terlambat, menambah dendanya, dan mengantrekan
+// pengingat sebelum jatuh tempo serta eskalasi sesudahnya. Setiap langkah idempoten sehingga
+// aman dijalankan berulang kali atau setelah terhenti di tengah jalan.
+type OverdueJobService struct {
+    Loans                  *LoanService
+    ReminderDaysBefore     int // 0 = tanpa pengingat sebelum jatuh tempo
+    EscalationIntervalDays int
+    MaxEscalations         int // 0 = tanpa eskalasi
+}
+
+func NewOverdueJobService(loans *LoanService, reminderDaysBefore, escalationIntervalDays, maxEscalations int) *OverdueJobService {
+    return &OverdueJobService{
+        Loans:                  loans,
+        ReminderDaysBefore:     reminderDaysBefore,
+        EscalationIntervalDays: escalationIntervalDays,
+        MaxEscalations:         maxEscalations,
+    }
+}
+
+// RunOnce processes every open loan that is overdue or due within ReminderDaysBefore days
+func (s *OverdueJobService) RunOnce(now time.Time) (*OverdueJobResult, error) {
+    result := &OverdueJobResult{StartedAt: now}
+
+    acquired, err := s.Loans.Repo.WithAdvisoryLock(overdueJobLockKey, func(conn *repository.LoanRepository) error {
+        marked, err := conn.MarkOverdueLoans(now)
+        if err != nil {
+            return err
+        }
+        result.MarkedOverdue = marked
+
+        ids, err := conn.GetOpenLoanIDsDueBefore(now.AddDate(0, 0, s.ReminderDaysBefore))
+        if err != nil {
+            return err
+        }
+        // Satu transaksi per pinjaman agar satu kegagalan tidak membatalkan yang lain
+        for _, id := range ids {
+            err := conn.Transaction(func(tx *repository.LoanRepository) error {
+                return s.processLoan(tx, id, now, result)
+            })
+            if err != nil {
+                log.Printf("Overdue job: loan %d: %v", id, err)
+                result.Failed++
+            }
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    result.Skipped = !acquired
+    result.FinishedAt = time.Now()
+    return result, nil
+}
+
+func (s *OverdueJobService) processLoan(tx *repository.LoanRepository, loanID uint, now time.Time, result *OverdueJobResult) error {
+    loan, err := tx.LockLoanRecord(loanID)
+    if err != nil {
+        return err
+    }
+    if loan.Returned {
+        return nil
+    }
+
+    if !loan.DueDate.Before(now) {
+        if s.ReminderDaysBefore == 0 {
+            return nil
+        }
+        reminder := &models.LoanReminder{
+            LoanRecordID: loan.ID,
+            UserID:       loan.UserID,
+            Kind:         models.ReminderKindDueSoon,
+            DueDate:      loan.DueDate,
+            Status:       models.ReminderStatusPending,
+        }
+        queued, err := tx.QueueReminder(reminder)
+        if err != nil || !queued {
+            return err
+        }
+        result.RemindersQueued++
+
+        book, err := tx.GetBookByID(loan.BookID)
+        if err != nil {
+            return err
+        }
+        return s.sendReminder(tx, reminder, notify.EventLoanDueSoon, map[string]interface{}{
+            "loan_id":  loan.ID,
+            "title":    book.Title,
+            "due_date": loan.DueDate.Format("2006-01-02"),
+        }, now)
+    }
+
+    // Denda dihitung ulang dari awal setiap putaran, jadi menjalankan job dua kali tidak menggandakannya
+    book, err := tx.GetBookByID(loan.BookID)
+    if err != nil {
+        return err
+    }
+    policy, err := s.Loans.loanPolicyFor(tx, loan, book)
+    if err != nil {
+        return err
+    }
+    _, changed, err := s.Loans.Fines.accrueLateFee(tx, loan, CalculateLateFee(policy, loan.DueDate, now))
+    if err != nil {
+        return err
+    }
+    if changed {
+        result.FinesAccrued++
+    }
+
+    if s.MaxEscalations == 0 {
+        return nil
+    }
+    // Level 1 begitu terlambat, lalu naik setiap EscalationIntervalDays. Jika job lama tidak
+    // berjalan, hanya level terkini yang diantrekan.
+    daysOverdue := int(now.Sub(loan.DueDate).Hours() / 24)
+    level := 1 + daysOverdue/s.EscalationIntervalDays
+    if level > s.MaxEscalations {
+        level = s.MaxEscalations
+    }
+    reminder := &models.LoanReminder{
+        LoanRecordID: loan.ID,
+        UserID:       loan.UserID,
+        Kind:         models.ReminderKindOverdue,
+        Level:        level,
+        DueDate:      loan.DueDate,
+        Status:       models.ReminderStatusPending,
+    }
+    queued, err := tx.QueueReminder(reminder)
+    if err != nil || !queued {
+        return err
+    }
+    result.EscalationsQueued++
+
+    return s.sendReminder(tx, reminder, notify.EventLoanOverdue, map[string]interface{}{
+        "loan_id":      loan.ID,
+        "title":        book.Title,
+        "due_date":     loan.DueDate.Format("2006-01-02"),
+        "days_overdue": daysOverdue,
+        "level":        level,
+    }, now)
+}
+
+// sendReminder menyerahkan pengingat ke outbox notifikasi dan menandainya SENT di transaksi yang sama
+func (s *OverdueJobService) sendReminder(tx *repository.LoanRepository, reminder *models.LoanReminder, event string, data map[string]interface{}, now time.Time) error {
+    if err := s.Loans.Notifications.Enqueue(tx, reminder.UserID, event, data); err != nil {
+        return err
+    }
+    return tx.MarkReminderSent(reminder.ID, now)
+}
+
+// RunWorker runs RunOnce every interval until the process exits
+func (s *OverdueJobService) RunWorker(interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for range ticker.C {
+        result, err := s.RunOnce(time.Now())
+        if err != nil {
+            log.Printf("Failed to run overdue job: %v", err)
+            continue
+        }
+        if result.MarkedOverdue > 0 || result.FinesAccrued > 0 || result.RemindersQueued > 0 || result.EscalationsQueued > 0 || result.Failed > 0 {
+            log.Printf("Overdue job: %d marked overdue, %d fines accrued, %d reminders and %d escalations queued, %d failed",
+                result.MarkedOverdue, result.FinesAccrued, result.RemindersQueued, result.EscalationsQueued, result.Failed)
+        }
+    }
+}
@@ -1,14 +1,16 @@
 package services
 
 import (
+    "auth-user-api/events"
     "auth-user-api/models"
+    "auth-user-api/query"
     "auth-user-api/repository"
 )
 
 type PublisherService interface {
     CreatePublisher(publisher *models.Publisher) error
     GetPublisherByID(id int) (*models.Publisher, error)
-    GetAllPublishers() ([]*models.Publisher, error)
+    GetAllPublishers(spec *query.Spec) ([]*models.Publisher, *query.Page, error)
     UpdatePublisher(publisher *models.Publisher) error
     DeletePublisher(id int) error
 }
@@ -22,21 +24,36 @@ func NewPublisherService(repo repository.PublisherRepository) PublisherService {
 }
 
 func (s *publisherService) CreatePublisher(publisher *models.Publisher) error {
-    return s.repo.CreatePublisher(publisher)
+    return s.repo.Transaction(func(tx repository.PublisherRepository) error {
+        if err := tx.CreatePublisher(publisher); err != nil {
+            return err
+        }
+        return recordEvent(tx, events.PublisherChanged{Type: events.TypePublisherCreated, PublisherID: publisher.ID, Name: publisher.Name})
+    })
 }
 
 func (s *publisherService) GetPublisherByID(id int) (*models.Publisher, error) {
     return s.repo.GetPublisherByID(id)
 }
 
-func (s *publisherService) GetAllPublishers() ([]*models.Publisher, error) {
-    return s.repo.GetAllPublishers()
+func (s *publisherService) GetAllPublishers(spec *query.Spec) ([]*models.Publisher, *query.Page, error) {
+    return s.repo.GetAllPublishers(spec)
 }
 
 func (s *publisherService) UpdatePublisher(publisher *models.Publisher) error {
-    return s.repo.UpdatePublisher(publisher)
+    return s.repo.Transaction(func(tx repository.PublisherRepository) error {
+        if err := tx.UpdatePublisher(publisher); err != nil {
+            return err
+        }
+        return recordEvent(tx, events.PublisherChanged{Type: events.TypePublisherUpdated, PublisherID: publisher.ID, Name: publisher.Name})
+    })
 }
 
 func (s *publisherService) DeletePublisher(id int) error {
-    return s.repo.DeletePublisher(id)
+    return s.repo.Transaction(func(tx repository.PublisherRepository) error {
+        if err := tx.DeletePublisher(id); err != nil {
+            return err
+        }
+        return recordEvent(tx, events.PublisherChanged{Type: events.TypePublisherDeleted, PublisherID: id})
+    })
 }
@@ -0,0 +1,146 @@
+// services/role_services.go
+package services
+
+import (
+    "errors"
+    "sync"
+    "time"
+    "auth-user-api/models"
+    "auth-user-api/repository"
+)
+
+var ErrUnknownPermission = errors.New("unknown permission")
+
+type RoleService interface {
+    GetAllRoles() ([]*models.Role, error)
+    UpdateRolePermissions(roleID int, permissions []string) (*models.Role, error)
+    HasPermission(role int, permission string) (bool, error)
+    GetPermissions(role int) (map[string]bool, error)
+    EnsureDefaultRoles() error
+}
+
+type roleService struct {
+    repo repository.RoleRepository
+    ttl  time.Duration
+
+    mu       sync.RWMutex
+    cache    map[int]map[string]bool
+    loadedAt time.Time
+}
+
+// NewRoleService membuat RoleService dengan cache permission per role yang dimuat ulang setiap ttl
+func NewRoleService(repo repository.RoleRepository, ttl time.Duration) RoleService {
+    return &roleService{repo: repo, ttl: ttl}
+}
+
+// DefaultRoles returns the built-in admin and member roles
+func DefaultRoles() []*models.Role {
+    adminPermissions := make([]models.RolePermission, len(models.AllPermissions))
+    for i, permission := range models.AllPermissions {
+        adminPermissions[i] = models.RolePermission{RoleID: 1, Permission: permission}
+    }
+
+    memberPermissions := []models.RolePermission{}
+    for _, permission := range []string{
+        models.PermCatalogRead,
+        models.PermLoansRequest,
+        models.PermHoldsRequest,
+        models.PermFinesRead,
+        models.PermPoliciesRead,
+        models.PermUsersSelf,
+    } {
+        memberPermissions = append(memberPermissions, models.RolePermission{RoleID: 2, Permission: permission})
+    }
+
+    return []*models.Role{
+        {ID: 1, Name: "admin", Permissions: adminPermissions},
+        {ID: 2, Name: "member", Permissions: memberPermissions},
+    }
+}
+
+func (s *roleService) GetAllRoles() ([]*models.Role, error) {
+    return s.repo.GetAllRoles()
+}
+
+// UpdateRolePermissions mengganti seluruh permission role dan menyegarkan cache
+func (s *roleService) UpdateRolePermissions(roleID int, permissions []string) (*models.Role, error) {
+    if _, err := s.repo.GetRoleByID(roleID); err != nil {
+        return nil, err
+    }
+    for _, permission := range permissions {
+        if !models.IsValidPermission(permission) {
+            return nil, ErrUnknownPermission
+        }
+    }
+
+    if err := s.repo.ReplacePermissions(roleID, permissions); err != nil {
+        return nil, err
+    }
+    s.invalidate()
+    return s.repo.GetRoleByID(roleID)
+}
+
+func (s *roleService) HasPermission(role int, permission string) (bool, error) {
+    permissions, err := s.GetPermissions(role)
+    if err != nil {
+        return false, err
+    }
+    return permissions[permission], nil
+}
+
+// GetPermissions returns the permission set of a role from the cache
+func (s *roleService) GetPermissions(role int) (map[string]bool, error) {
+    s.mu.RLock()
+    fresh := s.cache != nil && time.Since(s.loadedAt) < s.ttl
+    permissions := s.cache[role]
+    s.mu.RUnlock()
+    if fresh {
+        return permissions, nil
+    }
+
+    if err := s.reload(); err != nil {
+        return nil, err
+    }
+
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    return s.cache[role], nil
+}
+
+// EnsureDefaultRoles menyimpan role admin dan member bawaan jika belum ada
+func (s *roleService) EnsureDefaultRoles() error {
+    for _, role := range DefaultRoles() {
+        if err := s.repo.CreateRoleIfMissing(role); err != nil {
+            return err
+        }
+    }
+    s.invalidate()
+    return nil
+}
+
+func (s *roleService) reload() error {
+    byRole, err := s.repo.GetPermissionsByRole()
+    if err != nil {
+        return err
+    }
+
+    cache := make(map[int]map[string]bool, len(byRole))
+    for role, permissions := range byRole {
+        cache[role] = make(map[string]bool, len(permissions))
+        for _, permission := range permissions {
+            cache[role][permission] = true
+        }
+    }
+
+    s.mu.Lock()
+    s.cache = cache
+    s.loadedAt = time.Now()
+    s.mu.Unlock()
+    return nil
+}
+
+func (s *roleService) invalidate() {
+    s.mu.Lock()
+    s.cache = nil
+    s.mu.Unlock()
+}
@@ -0,0 +1,242 @@
+// services/trash_services.go
+package services
+
+import (
+    "errors"
+    "fmt"
+    "log"
+    "time"
+    "auth-user-api/events"
+    "auth-user-api/models"
+    "auth-user-api/query"
+    "auth-user-api/repository"
+
+    "gorm.io/gorm"
+)
+
+var ErrNotInTrash = errors.New("item is not in the trash")
+
+// trashPurgeLockKey adalah kunci advisory lock untuk purge trash agar worker di beberapa
+// instance dan libctl tidak menghapus baris yang sama bersamaan
+const trashPurgeLockKey int64 = 0x5452415348 // "TRASH"
+
+// TrashPurgeResult counts the rows one purge removed permanently
+type TrashPurgeResult struct {
+    Skipped    bool  `json:"skipped"` // Instance lain sedang menjalankan purge
+    Books      int64 `json:"books"`
+    Authors    int64 `json:"authors"`
+    Publishers int64 `json:"publishers"`
+    Users      int64 `json:"users"`
+}
+
+// TrashService mengelola baris soft-deleted: menampilkan, memulihkan dan menghapus permanen
+// setelah Retention. Pemulihan memeriksa ulang keunikan yang hanya berlaku untuk baris aktif.
+type TrashService struct {
+    Repo      *repository.TrashRepository
+    Retention time.Duration
+}
+
+func NewTrashService(repo *repository.TrashRepository, retention time.Duration) *TrashService {
+    return &TrashService{Repo: repo, Retention: retention}
+}
+
+// PurgeAt returns when a row deleted at deletedAt becomes eligible for purging
+func (s *TrashService) PurgeAt(deletedAt time.Time) time.Time {
+    return deletedAt.Add(s.Retention)
+}
+
+func (s *TrashService) GetTrashedBooks(spec *query.Spec) ([]*models.Book, *query.Page, error) {
+    return s.Repo.GetTrashedBooks(spec)
+}
+
+func (s *TrashService) GetTrashedAuthors(spec *query.Spec) ([]*models.Author, *query.Page, error) {
+    return s.Repo.GetTrashedAuthors(spec)
+}
+
+func (s *TrashService) GetTrashedPublishers(spec *query.Spec) ([]*models.Publisher, *query.Page, error) {
+    return s.Repo.GetTrashedPublishers(spec)
+}
+
+func (s *TrashService) GetTrashedUsers(spec *query.Spec) ([]*models.User, *query.Page, error) {
+    return s.Repo.GetTrashedUsers(spec)
+}
+
+// RestoreBook mengembalikan buku dari trash. Ditolak jika ISBN-nya sudah dipakai buku lain
+// atau author/publisher utamanya masih di trash (pulihkan mereka lebih dulu).
+func (s *TrashService) RestoreBook(id int) (*models.Book, error) {
+    var book *models.Book
+    err := s.Repo.Transaction(func(tx *repository.TrashRepository) error {
+        var err error
+        if book, err = tx.GetTrashedBook(id); err != nil {
+            return notInTrash(err)
+        }
+
+        if book.ISBN != nil {
+            inUse, err := tx.ISBNInUse(*book.ISBN, book.ID)
+            if err != nil {
+                return err
+            }
+            if inUse {
+                return &ConflictError{Err: ErrDuplicateISBN}
+            }
+        }
+        authorTrashed, err := tx.IsTrashed(&models.Author{}, book.AuthorID)
+        if err != nil {
+            return err
+        }
+        if authorTrashed {
+            return &ConflictError{Err: fmt.Errorf("author %d is in the trash; restore it first", book.AuthorID)}
+        }
+        publisherTrashed, err := tx.IsTrashed(&models.Publisher{}, book.PublisherID)
+        if err != nil {
+            return err
+        }
+        if publisherTrashed {
+            return &ConflictError{Err: fmt.Errorf("publisher %d is in the trash; restore it first", book.PublisherID)}
+        }
+
+        if err := tx.RestoreBook(id); err != nil {
+            // Buku lain bisa mengambil ISBN yang sama di antara pengecekan dan pemulihan
+            return notInTrash(duplicateISBN(err))
+        }
+        book.DeletedAt = gorm.DeletedAt{}
+        return recordEvent(tx, bookChanged(events.TypeBookRestored, book))
+    })
+    if err != nil {
+        return nil, err
+    }
+    return book, nil
+}
+
+func (s *TrashService) RestoreAuthor(id int) (*models.Author, error) {
+    var author *models.Author
+    err := s.Repo.Transaction(func(tx *repository.TrashRepository) error {
+        var err error
+        if author, err = tx.GetTrashedAuthor(id); err != nil {
+            return notInTrash(err)
+        }
+        if err := tx.RestoreAuthor(id); err != nil {
+            return notInTrash(err)
+        }
+        author.DeletedAt = gorm.DeletedAt{}
+        return recordEvent(tx, events.AuthorChanged{Type: events.TypeAuthorRestored, AuthorID: author.ID, Name: author.Name})
+    })
+    if err != nil {
+        return nil, err
+    }
+    return author, nil
+}
+
+func (s *TrashService) RestorePublisher(id int) (*models.Publisher, error) {
+    var publisher *models.Publisher
+    err := s.Repo.Transaction(func(tx *repository.TrashRepository) error {
+        var err error
+        if publisher, err = tx.GetTrashedPublisher(id); err != nil {
+            return notInTrash(err)
+        }
+        if err := tx.RestorePublisher(id); err != nil {
+            return notInTrash(err)
+        }
+        publisher.DeletedAt = gorm.DeletedAt{}
+        return recordEvent(tx, events.PublisherChanged{Type: events.TypePublisherRestored, PublisherID: publisher.ID, Name: publisher.Name})
+    })
+    if err != nil {
+        return nil, err
+    }
+    return publisher, nil
+}
+
+// RestoreUser mengembalikan user dari trash selama username dan email-nya belum dipakai
+// user aktif lain; jika sudah, user lain itu harus diganti namanya dulu.
+func (s *TrashService) RestoreUser(id string) (*models.User, error) {
+    var user *models.User
+    err := s.Repo.Transaction(func(tx *repository.TrashRepository) error {
+        var err error
+        if user, err = tx.GetTrashedUser(id); err != nil {
+            return notInTrash(err)
+        }
+
+        for _, field := range []struct{ column, value string }{
+            {"username", user.Username},
+            {"email", user.Email},
+        } {
+            inUse, err := tx.UserFieldInUse(field.column, field.value, user.ID)
+            if err != nil {
+                return err
+            }
+            if inUse {
+                return &ConflictError{Err: fmt.Errorf("%s %q is already used by another user", field.column, field.value)}
+            }
+        }
+
+        if err := tx.RestoreUser(id); err != nil {
+            switch {
+            case repository.IsUniqueViolation(err, "idx_users_username"):
+                return &ConflictError{Err: fmt.Errorf("username %q is already used by another user", user.Username)}
+            case repository.IsUniqueViolation(err, "idx_users_email"):
+                return &ConflictError{Err: fmt.Errorf("email %q is already used by another user", user.Email)}
+            }
+            return notInTrash(err)
+        }
+        user.DeletedAt = gorm.DeletedAt{}
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    return user, nil
+}
+
+// Purge menghapus permanen semua baris yang sudah lebih lama dari Retention di trash. Baris
+// yang masih dirujuk riwayat pinjaman, denda atau buku tetap disimpan. Jika instance lain
+// sedang melakukan purge, tidak ada yang dihapus dan Skipped bernilai true.
+func (s *TrashService) Purge(now time.Time) (*TrashPurgeResult, error) {
+    cutoff := now.Add(-s.Retention)
+    result := &TrashPurgeResult{}
+    acquired, err := s.Repo.WithAdvisoryLock(trashPurgeLockKey, func(conn *repository.TrashRepository) error {
+        return conn.Transaction(func(tx *repository.TrashRepository) error {
+            var err error
+            // Buku lebih dulu agar author dan publisher yang hanya dipakai buku tersebut ikut lepas
+            if result.Books, err = tx.PurgeBooks(cutoff); err != nil {
+                return err
+            }
+            if result.Authors, err = tx.PurgeAuthors(cutoff); err != nil {
+                return err
+            }
+            if result.Publishers, err = tx.PurgePublishers(cutoff); err != nil {
+                return err
+            }
+            result.Users, err = tx.PurgeUsers(cutoff)
+            return err
+        })
+    })
+    if err != nil {
+        return nil, err
+    }
+    result.Skipped = !acquired
+    return result, nil
+}
+
+// RunPurgeWorker runs Purge every interval until the process exits
+func (s *TrashService) RunPurgeWorker(interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for range ticker.C {
+        result, err := s.Purge(time.Now())
+        if err != nil {
+            log.Printf("Failed to purge trash: %v", err)
+            continue
+        }
+        if total := result.Books + result.Authors + result.Publishers + result.Users; total > 0 {
+            log.Printf("Trash purged: %d books, %d authors, %d publishers, %d users", result.Books, result.Authors, result.Publishers, result.Users)
+        }
+    }
+}
+
+func notInTrash(err error) error {
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        return ErrNotInTrash
+    }
+    return err
+}
@@ -5,6 +5,7 @@ package services
 import (
     "errors"
     "auth-user-api/models"
+    "auth-user-api/query"
     "auth-user-api/repository"
     "auth-user-api/utils"
 
@@ -16,7 +17,7 @@ type UserService interface {
     Update(id, username, email, password1, password2 string) error
     Delete(id string) error
     Authenticate(username, password string) (*models.User, error)
-    GetAllUsers() ([]*models.User, error)
+    GetAllUsers(spec *query.Spec) ([]*models.User, *query.Page, error)
     GetUserByID(id string) (*models.User, error)
     GetUserByUsername(username string) (*models.User, error)
 }
@@ -31,36 +32,43 @@ func NewUserService(repo repository.UserRepository) UserService {
 
 // Register - Untuk mendaftarkan user baru
 func (s *userService) Register(username, email, password1, password2 string, role int) error {
+    user, err := newUser(username, email, password1, password2, role)
+    if err != nil {
+        return err
+    }
+    return s.repo.CreateUser(user)
+}
+
+// newUser memvalidasi password lalu menyiapkan user baru dengan password yang sudah di-hash
+func newUser(username, email, password1, password2 string, role int) (*models.User, error) {
     if password1 != password2 {
-        return errors.New("password didn't match")
+        return nil, errors.New("password didn't match")
     }
 
     if err := utils.ValidatePassword(password1); err != nil {
-        return err
+        return nil, err
     }
 
     hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password1), bcrypt.DefaultCost)
     if err != nil {
-        return err
+        return nil, err
     }
 
-    user := &models.User{
+    return &models.User{
         Username: username,
         Email:    email,
         Password: string(hashedPassword),
         Role:     role, // Set the role here
-    }
-
-    return s.repo.CreateUser(user)
+    }, nil
 }
 
-// GetAllUsers - Mendapatkan semua user
-func (s *userService) GetAllUsers() ([]*models.User, error) {
-    users, err := s.repo.GetAllUsers()
+// GetAllUsers - Mendapatkan satu halaman user sesuai filter dan urutan
+func (s *userService) GetAllUsers(spec *query.Spec) ([]*models.User, *query.Page, error) {
+    users, page, err := s.repo.GetAllUsers(spec)
     if err != nil {
-        return nil, err
+        return nil, nil, err
     }
-    return users, nil
+    return users, page, nil
 }
 
 // Update - Mengupdate data user
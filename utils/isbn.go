@@ -0,0 +1,70 @@
+// utils/isbn.go
+
+package utils
+
+import (
+    "errors"
+    "strings"
+)
+
+var ErrInvalidISBN = errors.New("invalid ISBN: expected 10 or 13 digits with a valid check digit")
+
+// NormalizeISBN validates an ISBN-10 or ISBN-13 (tanda hubung dan spasi diabaikan) and
+// returns it as a 13-digit ISBN-13, sehingga kedua bentuk disimpan dan dicari dengan cara yang sama.
+func NormalizeISBN(raw string) (string, error) {
+    isbn := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(raw)))
+    isbn = strings.TrimPrefix(isbn, "ISBN")
+
+    switch len(isbn) {
+    case 10:
+        sum := 0
+        for i, r := range isbn {
+            var digit int
+            switch {
+            case r >= '0' && r <= '9':
+                digit = int(r - '0')
+            case r == 'X' && i == 9:
+                digit = 10
+            default:
+                return "", ErrInvalidISBN
+            }
+            sum += digit * (10 - i)
+        }
+        if sum%11 != 0 {
+            return "", ErrInvalidISBN
+        }
+        body := "978" + isbn[:9]
+        return body + string(isbn13CheckDigit(body)), nil
+    case 13:
+        if !isDigits(isbn) || !(strings.HasPrefix(isbn, "978") || strings.HasPrefix(isbn, "979")) {
+            return "", ErrInvalidISBN
+        }
+        if isbn13CheckDigit(isbn[:12]) != isbn[12] {
+            return "", ErrInvalidISBN
+        }
+        return isbn, nil
+    }
+    return "", ErrInvalidISBN
+}
+
+// isbn13CheckDigit menghitung digit terakhir dari 12 digit pertama (bobot 1 dan 3 bergantian)
+func isbn13CheckDigit(body string) byte {
+    sum := 0
+    for i := 0; i < 12; i++ {
+        digit := int(body[i] - '0')
+        if i%2 == 1 {
+            digit *= 3
+        }
+        sum += digit
+    }
+    return byte('0' + (10-sum%10)%10)
+}
+
+func isDigits(value string) bool {
+    for _, r := range value {
+        if r < '0' || r > '9' {
+            return false
+        }
+    }
+    return true
+}
@@ -0,0 +1,48 @@
+// utils/isbn_test.go
+
+package utils
+
+import (
+    "errors"
+    "testing"
+)
+
+func TestNormalizeISBN(t *testing.T) {
+    tests := []struct {
+        name string
+        raw  string
+        want string
+        err  error
+    }{
+        {name: "isbn13", raw: "9780306406157", want: "9780306406157"},
+        {name: "isbn13 with hyphens", raw: "978-0-306-40615-7", want: "9780306406157"},
+        {name: "isbn13 with spaces and prefix", raw: "  ISBN 978 0 306 40615 7 ", want: "9780306406157"},
+        {name: "isbn13 979 prefix", raw: "979-10-90636-07-1", want: "9791090636071"},
+        {name: "isbn10", raw: "0306406152", want: "9780306406157"},
+        {name: "isbn10 with hyphens", raw: "0-306-40615-2", want: "9780306406157"},
+        {name: "isbn10 check digit X", raw: "0-8044-2957-X", want: "9780804429573"},
+        {name: "isbn10 lowercase x", raw: "080442957x", want: "9780804429573"},
+        {name: "isbn10 lowercase prefix", raw: "isbn 0306406152", want: "9780306406157"},
+
+        {name: "empty", raw: "", err: ErrInvalidISBN},
+        {name: "isbn13 wrong check digit", raw: "9780306406158", err: ErrInvalidISBN},
+        {name: "isbn13 unknown prefix", raw: "9770306406158", err: ErrInvalidISBN},
+        {name: "isbn13 with letter", raw: "97803064061X7", err: ErrInvalidISBN},
+        {name: "isbn10 wrong check digit", raw: "0306406153", err: ErrInvalidISBN},
+        {name: "isbn10 X not last", raw: "03064X6152", err: ErrInvalidISBN},
+        {name: "too short", raw: "030640615", err: ErrInvalidISBN},
+        {name: "too long", raw: "97803064061570", err: ErrInvalidISBN},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := NormalizeISBN(tt.raw)
+            if !errors.Is(err, tt.err) {
+                t.Fatalf("NormalizeISBN(%q) error = %v, want %v", tt.raw, err, tt.err)
+            }
+            if got != tt.want {
+                t.Errorf("NormalizeISBN(%q) = %q, want %q", tt.raw, got, tt.want)
+            }
+        })
+    }
+}